@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+var checkCommand = &command{
+	name:    "check",
+	summary: "validate the configuration and check database connectivity and schema version",
+	run:     runCheck,
+}
+
+func runCheck(env *environment, args []string) error {
+	// The configuration was validated while loading it.
+	fmt.Println("config: ok")
+
+	db, err := env.DB()
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	fmt.Println("database: ok")
+
+	latest, err := postgresql.LatestMigration()
+	if err != nil {
+		return err
+	}
+	version, dirty, ok, err := postgresql.MigrationVersion(env.ctx, db)
+	switch {
+	case err != nil:
+		return fmt.Errorf("migrations: %w", err)
+	case !ok:
+		return fmt.Errorf("migrations: none applied, latest is %d", latest)
+	case dirty:
+		return fmt.Errorf("migrations: version %d is dirty", version)
+	case version != latest:
+		return fmt.Errorf("migrations: at version %d, latest is %d", version, latest)
+	}
+	fmt.Printf("migrations: ok (version %d)\n", version)
+	return nil
+}
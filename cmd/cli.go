@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/metrics"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// command is one subcommand of the binary. Every command shares the config
+// flags registered by config.NewFlags; flags adds its own.
+type command struct {
+	name    string
+	args    string
+	summary string
+	flags   func(fs *flag.FlagSet)
+	run     func(env *environment, args []string) error
+}
+
+// environment is what a command runs with: the effective configuration and,
+// on demand, a database pool.
+type environment struct {
+	ctx    context.Context
+	config config.Config
+	db     *sql.DB
+}
+
+func (env *environment) DB() (*sql.DB, error) {
+	if env.db != nil {
+		return env.db, nil
+	}
+	db, err := postgresql.Open(env.ctx, env.config.Database)
+	if err != nil {
+		return nil, err
+	}
+	metrics.RegisterDB(db)
+	env.db = db
+	return db, nil
+}
+
+func (env *environment) Close() {
+	if env.db != nil {
+		env.db.Close()
+	}
+}
+
+func (env *environment) timeouts() postgresql.Timeouts {
+	return postgresql.TimeoutsFromConfig(env.config.Database)
+}
+
+func progName() string {
+	return filepath.Base(os.Args[0])
+}
+
+var commands = []*command{
+	serveCommand,
+	migrateCommand,
+	seedCommand,
+	importCommand,
+	exportCommand,
+	userCommand,
+	similarCommand,
+	checkCommand,
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s [command] [flags] [args]\n\ncommands:\n", progName())
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nWithout a command the server is started. Run '%s <command> -h' for the flags of a command.\n", progName())
+}
+
+// execute picks the command from args, parses its flags and loads the
+// configuration. It returns the process exit code.
+func execute(ctx context.Context, args []string) int {
+	cmd := findCommand("serve")
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			usage(os.Stdout)
+			return 0
+		}
+		if cmd = findCommand(args[0]); cmd == nil {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+			usage(os.Stderr)
+			return 2
+		}
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s [flags] %s\n\n%s\n\nflags:\n", progName(), cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	flags := config.NewFlags(fs)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets masked and exit")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	cfg, err := config.Load(flags)
+	if *printConfig {
+		cfg.Print(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *printConfig {
+		return 0
+	}
+	utils.InitLog(cfg)
+
+	env := &environment{ctx: ctx, config: cfg}
+	defer env.Close()
+	if err := cmd.run(env, fs.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -1,23 +1,14 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-gonic/gin"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/golang-migrate/migrate/v4/source/github"
 	"github.com/joho/godotenv"
-	swaggerfiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 
-	"github.com/nikuma0/test-effective-mobile-golang/config"
 	_ "github.com/nikuma0/test-effective-mobile-golang/docs"
-	"github.com/nikuma0/test-effective-mobile-golang/internal/http"
-	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
-	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
 )
 
 //	@title			Swagger Songs API
@@ -37,46 +28,17 @@ import (
 
 //	@securityDefinitions.basic	BasicAuth
 
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer " followed by a token from POST /auth/token.
+
 func main() {
 	// Env Variables
 	godotenv.Load()
-	config, err := config.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Init Logging
-	utils.InitLog(config)
-
-	// connect DB
-	db, err := sql.Open("postgres", config.Db)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
-
-	// migrations
-	migrationDriver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatal(err)
-	}
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres", migrationDriver)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal(err)
-	}
 
-	// gin
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(utils.LoggerMiddleware())
-	handler := http.New(func() postgresql.SongsRepositoryI { return postgresql.NewSongsRepository(db) })
-	v1 := r.Group("/api/v1")
-	handler.Routes(v1)
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
-	r.Run(":8080")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	code := execute(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
 }
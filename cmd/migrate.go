@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+const migrateUsage = `migrate commands:
+  up [N]       apply all or N pending migrations
+  down [N]     roll back N migrations (default 1)
+  goto V       migrate up or down to version V
+  version      print the current version
+  force V      set the version without running migrations, clearing the dirty flag`
+
+var migrateCommand = &command{
+	name:    "migrate",
+	args:    "up|down|goto|version|force [N]",
+	summary: "apply or inspect the embedded database migrations",
+	run:     runMigrate,
+}
+
+func runMigrate(env *environment, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	cmd, args := args[0], args[1:]
+
+	number := func(def int) (int, error) {
+		if len(args) == 0 {
+			if def < 0 {
+				return 0, fmt.Errorf("migrate %s: missing argument\n%s", cmd, migrateUsage)
+			}
+			return def, nil
+		}
+		return strconv.Atoi(args[0])
+	}
+
+	return postgresql.Migrate(env.ctx, db, func(m *migrate.Migrate) error {
+		var err error
+		switch cmd {
+		case "up":
+			var n int
+			if n, err = number(0); err != nil {
+				return err
+			}
+			if n == 0 {
+				err = m.Up()
+			} else {
+				err = m.Steps(n)
+			}
+		case "down":
+			var n int
+			if n, err = number(1); err != nil {
+				return err
+			}
+			err = m.Steps(-n)
+		case "goto":
+			var v int
+			if v, err = number(-1); err != nil {
+				return err
+			}
+			err = m.Migrate(uint(v))
+		case "force":
+			var v int
+			if v, err = number(-1); err != nil {
+				return err
+			}
+			err = m.Force(v)
+		case "version":
+		default:
+			return fmt.Errorf("unknown migrate command %q\n%s", cmd, migrateUsage)
+		}
+		if errors.Is(err, migrate.ErrNoChange) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+
+		version, dirty, err := m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Println("no migrations applied")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version %d (dirty: %t)\n", version, dirty)
+		return nil
+	})
+}
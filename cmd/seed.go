@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+var seedOpts struct {
+	songs  int
+	groups int
+	verses int
+	seed   uint64
+}
+
+var seedCommand = &command{
+	name:    "seed",
+	summary: "fill the database with generated songs for development",
+	flags: func(fs *flag.FlagSet) {
+		fs.IntVar(&seedOpts.songs, "songs", 100, "number of songs")
+		fs.IntVar(&seedOpts.groups, "groups", 10, "number of groups the songs are spread over")
+		fs.IntVar(&seedOpts.verses, "verses", 3, "verses per song")
+		fs.Uint64Var(&seedOpts.seed, "seed", 1, "random seed, the same seed produces the same songs")
+	},
+	run: runSeed,
+}
+
+var seedWords = strings.Fields(`love night heart fire road rain light dream
+	city river sky home gold stone time shadow song wind dance summer`)
+
+func runSeed(env *environment, args []string) error {
+	if seedOpts.songs < 1 || seedOpts.groups < 1 || seedOpts.verses < 1 {
+		return fmt.Errorf("songs, groups and verses must be positive")
+	}
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	repo := postgresql.NewSongsRepository(db).WithTimeouts(env.timeouts())
+	tr, err := repo.Begin()
+	if err != nil {
+		return err
+	}
+
+	rnd := rand.New(rand.NewPCG(seedOpts.seed, seedOpts.seed))
+	for i := 1; i <= seedOpts.songs; i++ {
+		song := generateSong(rnd, i)
+		if _, err := repo.CreateSong(env.ctx, &song); err != nil {
+			tr.Rollback()
+			return err
+		}
+	}
+	if err := tr.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("created %d songs in %d groups\n", seedOpts.songs, min(seedOpts.songs, seedOpts.groups))
+	return nil
+}
+
+func generateSong(rnd *rand.Rand, i int) models.SongCreateQuery {
+	word := func() string { return seedWords[rnd.IntN(len(seedWords))] }
+
+	verses := make([]string, seedOpts.verses)
+	for v := range verses {
+		lines := make([]string, 4)
+		for l := range lines {
+			lines[l] = fmt.Sprintf("%s %s, %s and %s", word(), word(), word(), word())
+		}
+		verses[v] = strings.Join(lines, "\n")
+	}
+	released := time.Now().AddDate(0, 0, -rnd.IntN(365*30))
+
+	return models.SongCreateQuery{
+		Group:       fmt.Sprintf("Group %d", rnd.IntN(seedOpts.groups)+1),
+		Song:        fmt.Sprintf("Song %d", i),
+		Text:        strings.Join(verses, "\n\n"),
+		Link:        fmt.Sprintf("https://example.com/songs/%d", i),
+		ReleaseDate: utils.Ptr(models.DateFormat(released)),
+	}
+}
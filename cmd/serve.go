@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/auth"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/enrichment"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/events"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/http"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/jobs"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/links"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/metrics"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/similar"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/webhooks"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	summary: "start the HTTP server (default)",
+	run:     runServe,
+}
+
+func runServe(env *environment, args []string) error {
+	config := env.config
+
+	// tracing
+	shutdownTracing, err := tracing.Init(env.ctx, config.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	// connect DB
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+
+	// migrations
+	if config.Database.AutoMigrate {
+		if err := postgresql.MigrateUp(env.ctx, db); err != nil {
+			return err
+		}
+	}
+
+	// gin
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(config.Tracing.ServiceName))
+	r.Use(utils.RequestIDMiddleware())
+	r.Use(utils.LoggerMiddleware(config.Logging))
+	r.Use(metrics.Middleware())
+	// change feed
+	broker := events.NewBroker()
+	go listen(env, postgresql.SongEventsChannel, broker)
+
+	timeouts := env.timeouts()
+
+	// webhooks, woken by the change feed
+	dispatcher := webhooks.NewDispatcher(postgresql.NewSongsRepository(db).WithTimeouts(timeouts), config.Webhooks)
+	wake, _ := broker.Subscribe()
+	dispatcherCtx, stopDispatcher := context.WithCancel(env.ctx)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(dispatcherCtx, wake)
+		close(dispatcherDone)
+	}()
+
+	// handlers, built before the jobs as the enricher drops their caches
+	handler := http.New(func() postgresql.SongsRepositoryI { return postgresql.NewSongsRepository(db).WithTimeouts(timeouts) }).
+		WithAuth(postgresql.NewUsersRepository(db).WithTimeouts(timeouts), auth.NewTokens(config.Auth.TokenSecret, config.Auth.TokenTTL)).
+		WithEvents(broker)
+	if config.Enrichment.URL != "" {
+		handler = handler.WithEnrichment()
+	}
+
+	// background jobs, woken when one is enqueued
+	jobsBroker := events.NewBroker()
+	go listen(env, postgresql.JobsChannel, jobsBroker)
+	pool := jobs.NewPool(postgresql.NewSongsRepository(db).WithTimeouts(timeouts), config.Jobs)
+	if config.Enrichment.URL != "" {
+		enricher := enrichment.NewEnricher(enrichment.NewClient(config.Enrichment), func() enrichment.Store {
+			return postgresql.NewSongsRepository(db).WithTimeouts(timeouts)
+		}).OnApplied(handler.InvalidateStats)
+		pool.Register(models.JobEnrichSong, enricher.Run)
+	}
+	refresher := similar.NewRefresher(func() similar.Store {
+		// Bounded by the job timeout rather than the one meant for requests.
+		return postgresql.NewSongsRepository(db).WithTimeouts(timeouts.With("RefreshSimilarSongs", config.Jobs.Timeout))
+	})
+	pool.Register(models.JobRefreshSimilar, refresher.Run)
+	if config.Links.Interval > 0 {
+		checker := links.NewChecker(postgresql.NewSongsRepository(db).WithTimeouts(timeouts), config.Links)
+		pool.Register(models.JobCheckLinks, checker.Run)
+		pool.Every(models.JobCheckLinks, time.Hour)
+	}
+	jobsWake, _ := jobsBroker.Subscribe()
+	poolCtx, stopPool := context.WithCancel(env.ctx)
+	poolDone := make(chan struct{})
+	go func() {
+		pool.Run(poolCtx, jobsWake)
+		close(poolDone)
+	}()
+
+	v1 := r.Group("/api/v1")
+	handler.Routes(v1)
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	r.GET("/metrics", metrics.Handler())
+
+	srv := http.NewServer(config.Http, r)
+	err = http.Serve(env.ctx, srv, config.Http.ShutdownTimeout)
+	// Interrupted jobs record that they are to be retried.
+	stopPool()
+	<-poolDone
+	// Deliveries in flight end before the database is closed.
+	stopDispatcher()
+	<-dispatcherDone
+	return err
+}
+
+// listen notifies b of the notifications on channel until the server shuts
+// down, and then closes b.
+func listen(env *environment, channel string, b *events.Broker) {
+	if err := events.Listen(env.ctx, env.config.Database.DSN(), channel, b); err != nil {
+		log.WithError(err).WithField("channel", channel).Error("listener stopped")
+	}
+	// Listen returns on shutdown too; open streams must end then.
+	<-env.ctx.Done()
+	b.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+var similarOpts struct {
+	limit   int
+	timeout time.Duration
+}
+
+var similarCommand = &command{
+	name:    "similar",
+	summary: "precompute the similar songs of every song",
+	flags: func(fs *flag.FlagSet) {
+		fs.IntVar(&similarOpts.limit, "limit", models.MaxSimilar, "similar songs stored per song")
+		fs.DurationVar(&similarOpts.timeout, "timeout", 10*time.Minute, "deadline of the computation")
+	},
+	run: runSimilar,
+}
+
+func runSimilar(env *environment, args []string) error {
+	if similarOpts.limit < 1 || similarOpts.limit > models.MaxSimilar {
+		return fmt.Errorf("limit must be between 1 and %d", models.MaxSimilar)
+	}
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	timeouts := env.timeouts().With("RefreshSimilarSongs", similarOpts.timeout)
+	repo := postgresql.NewSongsRepository(db).WithTimeouts(timeouts)
+	tr, err := repo.Begin()
+	if err != nil {
+		return err
+	}
+	stored, err := repo.RefreshSimilarSongs(env.ctx, similarOpts.limit)
+	if err != nil {
+		tr.Rollback()
+		return err
+	}
+	if err := tr.Commit(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "stored %d similar songs\n", stored)
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+// Songs are exchanged as a JSON array of models.SongCreateQuery, the same
+// shape POST /songs accepts, so an export can be imported elsewhere.
+
+var transferFile string
+
+func fileFlag(fs *flag.FlagSet) {
+	fs.StringVar(&transferFile, "file", "-", "JSON file, - for stdin/stdout")
+}
+
+var importCommand = &command{
+	name:    "import",
+	summary: "create songs from a JSON file",
+	flags:   fileFlag,
+	run:     runImport,
+}
+
+var exportCommand = &command{
+	name:    "export",
+	summary: "write all songs to a JSON file",
+	flags:   fileFlag,
+	run:     runExport,
+}
+
+func runImport(env *environment, args []string) error {
+	var r io.Reader = os.Stdin
+	if transferFile != "-" {
+		f, err := os.Open(transferFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var songs []models.SongCreateQuery
+	if err := json.NewDecoder(r).Decode(&songs); err != nil {
+		return fmt.Errorf("decode %s: %w", transferFile, err)
+	}
+	for i, s := range songs {
+		if s.Group == "" || s.Song == "" {
+			return fmt.Errorf("song #%d: group and song are required", i)
+		}
+	}
+
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	repo := postgresql.NewSongsRepository(db).WithTimeouts(env.timeouts())
+	tr, err := repo.Begin()
+	if err != nil {
+		return err
+	}
+	for i := range songs {
+		if _, err := repo.CreateSong(env.ctx, &songs[i]); err != nil {
+			tr.Rollback()
+			return fmt.Errorf("song #%d: %w", i, err)
+		}
+	}
+	if err := tr.Commit(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "imported %d songs\n", len(songs))
+	return nil
+}
+
+func runExport(env *environment, args []string) (err error) {
+	var w io.Writer = os.Stdout
+	if transferFile != "-" {
+		f, err := os.Create(transferFile)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, f.Close()) }()
+		w = f
+	}
+
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	repo := postgresql.NewSongsRepository(db).WithTimeouts(env.timeouts())
+
+	// Written element by element so large catalogues are not held in memory.
+	count := 0
+	if _, err = io.WriteString(w, "[\n"); err != nil {
+		return err
+	}
+	err = repo.ExportSongs(env.ctx, func(sd models.SongDetail) error {
+		song := models.SongCreateQuery{Group: sd.GroupName, Song: sd.Name, Text: sd.Text, Link: sd.Link, Tags: sd.Tags}
+		// A NULL date scans as the zero time, which would export as 0001.01.01.
+		if !time.Time(sd.ReleaseDate).IsZero() {
+			song.ReleaseDate = &sd.ReleaseDate
+		}
+		b, err := json.Marshal(song)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if count == 0 {
+			sep = ""
+		}
+		count++
+		_, err = fmt.Fprintf(w, "%s  %s", sep, b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(w, "\n]\n"); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d songs\n", count)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+var userOpts models.UserCreate
+
+var userCommand = &command{
+	name:    "user",
+	args:    "create",
+	summary: "manage API users",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&userOpts.Username, "username", "", "user name")
+		fs.StringVar(&userOpts.Password, "password", "", "password, read from stdin when empty")
+		fs.BoolVar(&userOpts.IsAdmin, "admin", false, "grant admin rights")
+	},
+	run: runUser,
+}
+
+func runUser(env *environment, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New("usage: user create -username NAME [-password PASS] [-admin]")
+	}
+	if userOpts.Username == "" {
+		return errors.New("-username is required")
+	}
+	if userOpts.Password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		userOpts.Password = strings.TrimRight(line, "\r\n")
+	}
+	if userOpts.Password == "" {
+		return errors.New("password must not be empty")
+	}
+
+	db, err := env.DB()
+	if err != nil {
+		return err
+	}
+	user, err := postgresql.NewUsersRepository(db).WithTimeouts(env.timeouts()).CreateUser(env.ctx, &userOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %q with id %d\n", user.Username, user.Id)
+	return nil
+}
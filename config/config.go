@@ -0,0 +1,368 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config is assembled by Load from defaults, an optional YAML/TOML file,
+// environment variables and command line flags, in that order. The `env`
+// tags name the variables; defaults live in Default rather than in envDefault
+// tags so that the environment only overrides what is actually set.
+type Config struct {
+	Debug      bool             `yaml:"debug" toml:"debug" env:"DEBUG"`
+	Http       HttpConfig       `yaml:"http" toml:"http"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	Logging    LoggingConfig    `yaml:"logging" toml:"logging"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Enrichment EnrichmentConfig `yaml:"enrichment" toml:"enrichment"`
+	Webhooks   WebhooksConfig   `yaml:"webhooks" toml:"webhooks"`
+	Jobs       JobsConfig       `yaml:"jobs" toml:"jobs"`
+	Links      LinksConfig      `yaml:"links" toml:"links"`
+}
+
+type HttpConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"HTTP_ADDR"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" toml:"readHeaderTimeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" toml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" toml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes" toml:"maxHeaderBytes" env:"HTTP_MAX_HEADER_BYTES"`
+	// Time given to in-flight requests to finish after SIGTERM/SIGINT.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+}
+
+// DatabaseConfig takes either a full URL or discrete connection fields; see DSN.
+type DatabaseConfig struct {
+	URL      string `yaml:"url" toml:"url" env:"DB" secret:"dsn"`
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslMode" toml:"sslMode" env:"DB_SSLMODE"`
+
+	ApplicationName  string        `yaml:"applicationName" toml:"applicationName" env:"DB_APPLICATION_NAME"`
+	StatementTimeout time.Duration `yaml:"statementTimeout" toml:"statementTimeout" env:"DB_STATEMENT_TIMEOUT"`
+
+	MaxOpenConns    int           `yaml:"maxOpenConns" toml:"maxOpenConns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" toml:"maxIdleConns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	// Startup ping: attempts and the first delay, doubled after each failure.
+	ConnectRetries int           `yaml:"connectRetries" toml:"connectRetries" env:"DB_CONNECT_RETRIES"`
+	ConnectBackoff time.Duration `yaml:"connectBackoff" toml:"connectBackoff" env:"DB_CONNECT_BACKOFF"`
+
+	// Apply pending migrations when the server starts.
+	AutoMigrate bool `yaml:"autoMigrate" toml:"autoMigrate" env:"DB_AUTO_MIGRATE"`
+
+	// Deadline of each repository method, overridable per method name
+	// (e.g. GetSongs) in the config file.
+	QueryTimeout      time.Duration            `yaml:"queryTimeout" toml:"queryTimeout" env:"DB_QUERY_TIMEOUT"`
+	OperationTimeouts map[string]time.Duration `yaml:"operationTimeouts" toml:"operationTimeouts"`
+}
+
+// DSN returns the connection string. URL wins over the discrete fields;
+// application_name and statement_timeout are added unless already present.
+func (d DatabaseConfig) DSN() string {
+	u := &url.URL{Scheme: "postgres"}
+	if d.URL != "" {
+		parsed, err := url.Parse(d.URL)
+		if err != nil || parsed.Scheme == "" {
+			// key=value form, leave it to the driver.
+			return d.URL
+		}
+		u = parsed
+	} else {
+		u.Host = d.Host
+		if d.Port != 0 {
+			u.Host = fmt.Sprintf("%s:%d", d.Host, d.Port)
+		}
+		if d.User != "" {
+			u.User = url.UserPassword(d.User, d.Password)
+			if d.Password == "" {
+				u.User = url.User(d.User)
+			}
+		}
+		u.Path = "/" + d.Name
+	}
+
+	q := u.Query()
+	setDefault := func(k, v string) {
+		if v != "" && !q.Has(k) {
+			q.Set(k, v)
+		}
+	}
+	setDefault("sslmode", d.SSLMode)
+	setDefault("application_name", d.ApplicationName)
+	if d.StatementTimeout > 0 {
+		setDefault("statement_timeout", fmt.Sprint(d.StatementTimeout.Milliseconds()))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+type LoggingConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// text or json
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Zero the host part of client IPs (last octet for IPv4, last 80 bits for IPv6).
+	MaskIP bool `yaml:"maskIP" toml:"maskIP" env:"LOG_MASK_IP"`
+	// Fields removed from every log entry, e.g. userAgent,referer.
+	DropFields []string `yaml:"dropFields" toml:"dropFields" env:"LOG_DROP_FIELDS" envSeparator:","`
+	// String values longer than this are cut; 0 disables truncation.
+	MaxFieldLength int `yaml:"maxFieldLength" toml:"maxFieldLength" env:"LOG_MAX_FIELD_LENGTH"`
+	// Share of access log lines kept per level, from 0 to 1. Errors are
+	// always logged.
+	SampleInfo float64 `yaml:"sampleInfo" toml:"sampleInfo" env:"LOG_SAMPLE_INFO"`
+	SampleWarn float64 `yaml:"sampleWarn" toml:"sampleWarn" env:"LOG_SAMPLE_WARN"`
+	// Request paths that never produce an access log line.
+	SkipPaths []string `yaml:"skipPaths" toml:"skipPaths" env:"LOG_SKIP_PATHS" envSeparator:","`
+}
+
+type TracingConfig struct {
+	// none, stdout, file or otlp. The OTLP exporter is configured with the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	File        string  `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	ServiceName string  `yaml:"serviceName" toml:"serviceName" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sampleRatio" toml:"sampleRatio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type AuthConfig struct {
+	// Secret used to sign access tokens.
+	TokenSecret string        `yaml:"tokenSecret" toml:"tokenSecret" env:"AUTH_TOKEN_SECRET" secret:"true"`
+	TokenTTL    time.Duration `yaml:"tokenTTL" toml:"tokenTTL" env:"AUTH_TOKEN_TTL"`
+}
+
+type EnrichmentConfig struct {
+	// Base URL of the music info service; empty disables enrichment.
+	URL     string        `yaml:"url" toml:"url" env:"ENRICHMENT_URL"`
+	APIKey  string        `yaml:"apiKey" toml:"apiKey" env:"ENRICHMENT_API_KEY" secret:"true"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"ENRICHMENT_TIMEOUT"`
+}
+
+type WebhooksConfig struct {
+	// Attempts per delivery before it is dead-lettered.
+	MaxAttempts int `yaml:"maxAttempts" toml:"maxAttempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	// Wait before the first retry, doubled after every further failure up
+	// to BackoffMax.
+	Backoff    time.Duration `yaml:"backoff" toml:"backoff" env:"WEBHOOKS_BACKOFF"`
+	BackoffMax time.Duration `yaml:"backoffMax" toml:"backoffMax" env:"WEBHOOKS_BACKOFF_MAX"`
+	// Deadline of one delivery request.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOKS_TIMEOUT"`
+	// Due retries are picked up at least this often.
+	PollInterval time.Duration `yaml:"pollInterval" toml:"pollInterval" env:"WEBHOOKS_POLL_INTERVAL"`
+}
+
+type JobsConfig struct {
+	// Jobs run at once by a server.
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"JOBS_CONCURRENCY"`
+	// Runs of a job before it dies.
+	MaxAttempts int `yaml:"maxAttempts" toml:"maxAttempts" env:"JOBS_MAX_ATTEMPTS"`
+	// Wait before the first retry, doubled after every further failure up
+	// to BackoffMax.
+	Backoff    time.Duration `yaml:"backoff" toml:"backoff" env:"JOBS_BACKOFF"`
+	BackoffMax time.Duration `yaml:"backoffMax" toml:"backoffMax" env:"JOBS_BACKOFF_MAX"`
+	// Deadline of one run.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"JOBS_TIMEOUT"`
+	// Due jobs are picked up at least this often.
+	PollInterval time.Duration `yaml:"pollInterval" toml:"pollInterval" env:"JOBS_POLL_INTERVAL"`
+	// Finished jobs are kept this long; 0 keeps them forever.
+	Retention time.Duration `yaml:"retention" toml:"retention" env:"JOBS_RETENTION"`
+}
+
+type LinksConfig struct {
+	// Song links are checked again once their last check is this old; 0
+	// disables the checks.
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"LINKS_INTERVAL"`
+	// Links checked at once.
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"LINKS_CONCURRENCY"`
+	// Deadline of checking one link, redirects included.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"LINKS_TIMEOUT"`
+	// Links are set by anyone, so loopback, link-local and private addresses
+	// are refused unless this is set, for local setups.
+	AllowPrivate bool `yaml:"allowPrivate" toml:"allowPrivate" env:"LINKS_ALLOW_PRIVATE"`
+}
+
+func Default() Config {
+	return Config{
+		Http: HttpConfig{
+			Addr:              ":8080",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Port:             5432,
+			ApplicationName:  "songs-api",
+			StatementTimeout: 30 * time.Second,
+			MaxOpenConns:     25,
+			MaxIdleConns:     25,
+			ConnMaxLifetime:  30 * time.Minute,
+			ConnMaxIdleTime:  5 * time.Minute,
+			ConnectRetries:   5,
+			ConnectBackoff:   500 * time.Millisecond,
+			AutoMigrate:      true,
+			QueryTimeout:     5 * time.Second,
+		},
+		Logging: LoggingConfig{
+			Level:          "debug",
+			Format:         "text",
+			MaxFieldLength: 256,
+			SampleInfo:     1,
+			SampleWarn:     1,
+			SkipPaths:      []string{"/metrics"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.json",
+			ServiceName: "songs-api",
+			SampleRatio: 1,
+		},
+		Auth: AuthConfig{
+			TokenTTL: 24 * time.Hour,
+		},
+		Enrichment: EnrichmentConfig{
+			Timeout: 5 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:  8,
+			Backoff:      10 * time.Second,
+			BackoffMax:   time.Hour,
+			Timeout:      10 * time.Second,
+			PollInterval: 5 * time.Second,
+		},
+		Jobs: JobsConfig{
+			Concurrency:  4,
+			MaxAttempts:  5,
+			Backoff:      30 * time.Second,
+			BackoffMax:   time.Hour,
+			Timeout:      5 * time.Minute,
+			PollInterval: 5 * time.Second,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Links: LinksConfig{
+			Interval:    24 * time.Hour,
+			Concurrency: 8,
+			Timeout:     10 * time.Second,
+		},
+	}
+}
+
+// Validate reports every problem found, not just the first one.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Http.Addr != "", "http.addr must be set")
+	for name, d := range map[string]time.Duration{
+		"http.readTimeout":       c.Http.ReadTimeout,
+		"http.readHeaderTimeout": c.Http.ReadHeaderTimeout,
+		"http.writeTimeout":      c.Http.WriteTimeout,
+		"http.idleTimeout":       c.Http.IdleTimeout,
+		"http.shutdownTimeout":   c.Http.ShutdownTimeout,
+	} {
+		check(d > 0, "%s must be positive, got %s", name, d)
+	}
+	check(c.Http.MaxHeaderBytes > 0, "http.maxHeaderBytes must be positive")
+
+	check(c.Database.URL != "" || (c.Database.Host != "" && c.Database.Name != ""),
+		"database.url (DB) or database.host and database.name must be set")
+	check(c.Database.MaxOpenConns >= 0, "database.maxOpenConns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.maxIdleConns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.maxIdleConns must not exceed database.maxOpenConns")
+	check(c.Database.ConnectRetries >= 1, "database.connectRetries must be at least 1")
+	check(c.Database.QueryTimeout > 0, "database.queryTimeout must be positive")
+	for method, d := range c.Database.OperationTimeouts {
+		check(d > 0, "database.operationTimeouts.%s must be positive, got %s", method, d)
+	}
+
+	if _, err := log.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	check(c.Logging.Format == "text" || c.Logging.Format == "json",
+		"logging.format must be text or json, got %q", c.Logging.Format)
+	check(c.Logging.MaxFieldLength >= 0, "logging.maxFieldLength must not be negative")
+	check(c.Logging.SampleInfo >= 0 && c.Logging.SampleInfo <= 1, "logging.sampleInfo must be within [0, 1]")
+	check(c.Logging.SampleWarn >= 0 && c.Logging.SampleWarn <= 1, "logging.sampleWarn must be within [0, 1]")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "file", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be one of none, stdout, file, otlp, got %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sampleRatio must be within [0, 1]")
+
+	check(c.Auth.TokenTTL > 0, "auth.tokenTTL must be positive")
+
+	if c.Enrichment.URL != "" {
+		_, err := url.ParseRequestURI(c.Enrichment.URL)
+		check(err == nil, "enrichment.url is not a valid URL")
+		check(c.Enrichment.Timeout > 0, "enrichment.timeout must be positive")
+	}
+
+	check(c.Webhooks.MaxAttempts >= 1, "webhooks.maxAttempts must be at least 1")
+	check(c.Webhooks.Backoff > 0, "webhooks.backoff must be positive")
+	check(c.Webhooks.BackoffMax >= c.Webhooks.Backoff, "webhooks.backoffMax must not be less than webhooks.backoff")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.PollInterval > 0, "webhooks.pollInterval must be positive")
+	check(c.Jobs.Concurrency >= 1, "jobs.concurrency must be at least 1")
+	check(c.Jobs.MaxAttempts >= 1, "jobs.maxAttempts must be at least 1")
+	check(c.Jobs.Backoff > 0, "jobs.backoff must be positive")
+	check(c.Jobs.BackoffMax >= c.Jobs.Backoff, "jobs.backoffMax must not be less than jobs.backoff")
+	check(c.Jobs.Timeout > 0, "jobs.timeout must be positive")
+	check(c.Jobs.PollInterval > 0, "jobs.pollInterval must be positive")
+	check(c.Jobs.Retention >= 0, "jobs.retention must not be negative")
+	check(c.Links.Interval >= 0, "links.interval must not be negative")
+	check(c.Links.Concurrency >= 1, "links.concurrency must be at least 1")
+	check(c.Links.Timeout > 0, "links.timeout must be positive")
+
+	return errors.Join(errs...)
+}
+
+// Masked returns a copy safe to print: fields tagged `secret:"true"` are
+// replaced and passwords are removed from fields tagged `secret:"dsn"`.
+func (c Config) Masked() Config {
+	maskStruct(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func maskStruct(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch tag := v.Type().Field(i).Tag.Get("secret"); {
+		case f.Kind() == reflect.Struct:
+			maskStruct(f)
+		case f.Kind() != reflect.String || f.String() == "":
+		case tag == "true":
+			f.SetString("****")
+		case tag == "dsn":
+			f.SetString(maskDSN(f.String()))
+		}
+	}
+}
+
+func maskDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		// key=value DSNs are not parsed; hide them entirely.
+		return "****"
+	}
+	return u.Redacted()
+}
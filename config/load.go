@@ -0,0 +1,117 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags are the command line overrides understood by Load. Only flags that
+// were actually passed take precedence over the file and the environment.
+type Flags struct {
+	fs *flag.FlagSet
+
+	File     string
+	addr     string
+	db       string
+	logLevel string
+	logFmt   string
+	debug    bool
+}
+
+func NewFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.File, "config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file (CONFIG_FILE)")
+	fs.StringVar(&f.addr, "http-addr", "", "HTTP listen address (HTTP_ADDR)")
+	fs.StringVar(&f.db, "db", "", "database URL (DB)")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level (LOG_LEVEL)")
+	fs.StringVar(&f.logFmt, "log-format", "", "log format: text or json (LOG_FORMAT)")
+	fs.BoolVar(&f.debug, "debug", false, "debug mode (DEBUG)")
+	return f
+}
+
+func (f *Flags) apply(c *Config) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "http-addr":
+			c.Http.Addr = f.addr
+		case "db":
+			c.Database.URL = f.db
+		case "log-level":
+			c.Logging.Level = f.logLevel
+		case "log-format":
+			c.Logging.Format = f.logFmt
+		case "debug":
+			c.Debug = f.debug
+		}
+	})
+}
+
+// Load layers defaults, the config file, the environment and flags, then
+// validates the result. flags may be nil.
+func Load(flags *Flags) (Config, error) {
+	c := Default()
+
+	file := os.Getenv("CONFIG_FILE")
+	if flags != nil {
+		file = flags.File
+	}
+	if file != "" {
+		if err := loadFile(file, &c); err != nil {
+			return c, err
+		}
+	}
+
+	if err := env.Parse(&c); err != nil {
+		return c, err
+	}
+
+	if flags != nil {
+		flags.apply(&c)
+	}
+
+	return c, c.Validate()
+}
+
+func loadFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		// go-toml cannot decode durations, so the document is converted to
+		// YAML, which can.
+		var raw map[string]any
+		if err = toml.Unmarshal(data, &raw); err != nil {
+			break
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			break
+		}
+		err = yaml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes the masked configuration as YAML.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(c.Masked())
+}
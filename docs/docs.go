@@ -24,17 +24,31 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/songs": {
+        "/admin/duplicates": {
             "get": {
-                "description": "Paginate all songs filtered by song name or/and group name.",
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate pairs of songs of the same group, compared ignoring case, whitespace and punctuation, that\nhave the same title or lyrics at least minLyrics similar (Jaccard index of their word sets). Pairs\nwith the same title come first, then the most similar lyrics.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Songs"
+                    "Admin"
                 ],
-                "summary": "Show all songs",
+                "summary": "Find duplicate songs",
                 "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Minimum lyrics similarity of differently titled songs, 0 to 1 (default 0.8)",
+                        "name": "minLyrics",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Page (starts with 0)",
@@ -46,84 +60,231 @@ const docTemplate = `{
                         "description": "Maximum elements (default 10)",
                         "name": "max",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Duplicate pairs with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListDuplicates"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid minLyrics",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
                     },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate the background jobs, newest first, with their attempts and last error. Failed jobs are\nqueued again with exponential backoff until they run out of attempts and die.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List background jobs",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Group name",
-                        "name": "group",
+                        "description": "Job kind",
+                        "name": "kind",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "queued",
+                            "running",
+                            "done",
+                            "dead"
+                        ],
                         "type": "string",
-                        "description": "Song name",
-                        "name": "song",
+                        "description": "Job status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of songs with pagination details",
+                        "description": "Jobs with pagination details",
                         "schema": {
-                            "$ref": "#/definitions/models.ListAllSongs"
+                            "$ref": "#/definitions/models.ListJobs"
                         }
                     },
                     "400": {
-                        "description": "Bad request, invalid parameters",
+                        "description": "Invalid status",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
-                    "404": {
-                        "description": "Not found, no songs match the criteria or page is empty",
+                    "401": {
+                        "description": "Not authenticated",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
-                    "500": {
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Creates a new song in the database with the provided details.",
-                "consumes": [
+            }
+        },
+        "/admin/jobs/summary": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Count the jobs of every kind in every status.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Count background jobs",
+                "responses": {
+                    "200": {
+                        "description": "Job counts",
+                        "schema": {
+                            "$ref": "#/definitions/models.JobsSummary"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue a dead job again with a fresh set of attempts.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Songs"
+                    "Admin"
                 ],
-                "summary": "Create a new song",
+                "summary": "Retry a dead job",
                 "parameters": [
                     {
-                        "description": "Song details",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.SongCreateQuery"
-                        }
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK response with success message",
+                        "description": "Queued",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
                     "400": {
-                        "description": "Bad request, invalid data",
+                        "description": "Invalid job ID",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
-                    "500": {
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "No dead job with this ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
@@ -132,46 +293,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/info": {
+        "/admin/links/report": {
             "get": {
-                "description": "Retrieve detailed information about a song based on the provided query parameters.",
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Count the checked, broken and redirected song links and paginate the broken ones, most recently\nchecked first. Links are checked by a background job, at most once per configured interval.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Songs"
+                    "Admin"
                 ],
-                "summary": "Get details of a specific song",
+                "summary": "Report broken song links",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Group name",
-                        "name": "group",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Song name",
-                        "name": "song",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Song details",
+                        "description": "Link counts and broken links with pagination details",
                         "schema": {
-                            "$ref": "#/definitions/models.SongDetail"
+                            "$ref": "#/definitions/models.LinkReport"
                         }
                     },
-                    "404": {
-                        "description": "Song not found",
+                    "400": {
+                        "description": "Invalid pagination",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
-                    "500": {
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
@@ -180,9 +359,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/{id}": {
-            "patch": {
-                "description": "Update one or more fields of a specific song by its ID.",
+        "/admin/songs/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fold the source songs into the target in one transaction. Empty text, link and release date of the\ntarget are taken from the first source that has them; tags, translations, favourites, ratings, plays\nand playlist entries move to the target. The sources are then deleted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -190,42 +377,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Songs"
+                    "Admin"
                 ],
-                "summary": "Update a song",
+                "summary": "Merge duplicate songs",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Song ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Fields to update",
+                        "description": "Target and sources",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SongUpdate"
+                            "$ref": "#/definitions/models.SongMerge"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Song successfully updated",
+                        "description": "Merged",
                         "schema": {
-                            "$ref": "#/definitions/models.Message"
+                            "$ref": "#/definitions/models.SongMergedResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid song ID",
+                        "description": "Invalid body, or the target among the sources",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     },
                     "404": {
-                        "description": "Song not found",
+                        "description": "Unknown song",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
@@ -239,124 +431,4344 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/{id}/text": {
+        "/analytics/groups": {
             "get": {
-                "description": "Fetches the text of a song given its ID, along with pagination details.",
+                "description": "Count the songs of each group, ordered by group name. Takes the same filters as /songs.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Songs"
+                    "Analytics"
                 ],
-                "summary": "Retrieve song text by ID",
+                "summary": "Songs per group",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Song ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page number for pagination",
+                        "description": "Page (starts with 0)",
                         "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Maximum number of items per page",
+                        "description": "Maximum elements (default 10)",
                         "name": "max",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successful response containing song text",
-                        "schema": {
-                            "$ref": "#/definitions/models.SongsText"
-                        }
                     },
-                    "404": {
-                        "description": "Song not found",
-                        "schema": {
-                            "$ref": "#/definitions/models.Message"
-                        }
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Songs per group with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListGroupCounts"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
                     },
                     "502": {
-                        "description": "Internal error or invalid input",
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/recent": {
+            "get": {
+                "description": "The max songs added last, newest first. Takes the same filters as /songs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Most recently added songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of songs (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Songs with the time they were added",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecentSongs"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/releases": {
+            "get": {
+                "description": "Count the songs released per year or month. Periods without releases between the first and the last one are included with zero songs. Takes the same filters as /songs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Releases over time",
+                "parameters": [
+                    {
+                        "enum": [
+                            "year",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "month",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Releases per period in chronological order",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReleaseBuckets"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/top-groups": {
+            "get": {
+                "description": "The max groups with the most songs, ties broken by group name. Takes the same filters as /songs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Groups with the most songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of groups (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Groups ordered by song count",
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupCounts"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/token": {
+            "post": {
+                "description": "Exchange a username and password for a bearer token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get an access token",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token and its expiry",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "501": {
+                        "description": "Token authentication is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.Message"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.ListAllSongs": {
+        },
+        "/groups/stats": {
+            "get": {
+                "description": "Aggregated line, verse and word counts over the songs of each group, ordered by group name. Songs without a group are not counted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Lyrics statistics per group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of most frequent words per group (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group statistics with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListGroupStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/favourites": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate the favourites of the authenticated user, most recently added first unless sort is given, with\nthe filters of /songs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favourites"
+                ],
+                "summary": "List favourite songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag names",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "popularity",
+                            "rating"
+                        ],
+                        "type": "string",
+                        "description": "Order by play count or average rating, highest first",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Favourite songs with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListAllSongs"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/playlists": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate the playlists of the authenticated user, most recently changed first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "List my playlists",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Playlists with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListPlaylists"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an empty playlist owned by the authenticated user. Playlists are private unless made public.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Create a playlist",
+                "parameters": [
+                    {
+                        "description": "Playlist",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created playlist",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}": {
+            "get": {
+                "description": "Public playlists are visible to everyone, private ones only to their owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Get a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Playlist",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Delete a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Playlist deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename a playlist or change its visibility.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Update a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated playlist",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID or fields",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/entries": {
+            "get": {
+                "description": "Paginate the entries of a playlist in order.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Playlist contents",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Entries with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListPlaylistEntries"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Insert a song at a position, moving later entries down, or append it. A song can be added several times.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Add a song to a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Song and position",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistEntryAdd"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Added entry",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistEntryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID or body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist or song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/entries/{entryId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Later entries move up to close the gap.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Remove an entry from a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entry ID",
+                        "name": "entryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Entry removed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist or entry not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/export.m3u8": {
+            "get": {
+                "description": "Extended M3U in UTF-8 with the link of each song; songs without a link are left out.",
+                "produces": [
+                    "audio/x-mpegurl"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Export a playlist as M3U8",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "M3U8 document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/export.xspf": {
+            "get": {
+                "description": "XML Shareable Playlist Format with the link of each song as its location.",
+                "produces": [
+                    "application/xspf+xml"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Export a playlist as XSPF",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "XSPF document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/order": {
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the order of the entries; the body must list every entry id of the playlist exactly once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlists"
+                ],
+                "summary": "Reorder a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Entry ids in the new order",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Playlist reordered",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid playlist ID or order",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Playlist not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/plays": {
+            "post": {
+                "description": "Record a batch of plays and add them to the play counts. Every play carries an event id chosen by the client;\nplays whose event id was already recorded are ignored, so a failed batch can be sent again.\nPlays of unknown songs are ignored too. Authenticated plays are attributed to the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ratings"
+                ],
+                "summary": "Record plays",
+                "parameters": [
+                    {
+                        "description": "Plays, at most 500",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaysBatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recorded and ignored plays",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaysResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "description": "Paginate all songs filtered by song name or/and group name.\nWith facets, the response also counts the matching songs per group, release year or tag.\nAuthenticated requests get an isFavourite flag on every song.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Show all songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag names",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Whether songs need any or all of the tags",
+                        "name": "tagMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated facets to count over all matching songs: group, year, tag",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "popularity",
+                            "rating"
+                        ],
+                        "type": "string",
+                        "description": "Order by play count or average rating, highest first",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only songs whose link was found broken (true) or not (false) by the last check",
+                        "name": "broken",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of songs with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListAllSongs"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found, no songs match the criteria or page is empty",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new song in the database with the provided details. Text and link are required unless\nenrichment is enabled: missing text, link or release date are then looked up in the music info service\nin the background, the response is 202 and the song's enrichmentStatus stays pending until the lookup\nis over.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Create a new song",
+                "parameters": [
+                    {
+                        "description": "Song details",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongCreateQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Song created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongCreatedResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Song created, details being looked up",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, invalid data",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/events": {
+            "get": {
+                "description": "Streams song create, update and delete events as Server-Sent Events, each with its id, type and song id.\nEvents are sent once their transaction is committed. A reconnecting EventSource resumes after the\nLast-Event-ID it sends; without one the stream starts with the next change.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Song change feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event, for clients that can't set headers",
+                        "name": "lastEventId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream of models.SongEvent",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid event id",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "503": {
+                        "description": "Change feed disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/info": {
+            "get": {
+                "description": "Retrieve detailed information about a song based on the provided query parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Get details of a specific song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the text; defaults to the best Accept-Language match, then the original",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Song details",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "delete": {
+                "description": "Delete a song with its texts, tags, ratings and playlist entries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Delete a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Song deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update one or more fields of a specific song by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Update a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Song successfully updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/enrich": {
+            "post": {
+                "description": "Queue a lookup of the song in the music info service, replacing its text, link and release date\nwith the ones found. Poll the song's enrichmentStatus for the outcome.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Look a song up again",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Lookup queued",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "503": {
+                        "description": "Enrichment is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/favourite": {
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a song to the favourites of the authenticated user. Adding it twice is not an error.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favourites"
+                ],
+                "summary": "Favourite a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Added",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a song from the favourites of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favourites"
+                ],
+                "summary": "Unfavourite a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Removed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics.lrc": {
+            "get": {
+                "description": "Returns the timed lines of a song as LRC. Lines without a timestamp are left out.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Get synchronized lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include per-word timings (enhanced LRC)",
+                        "name": "enhanced",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "LRC document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found or not synchronized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Stores the timestamps of an LRC or enhanced LRC document against the song text, which is left as\nit is. The timed lines, ordered by time, must be the non-blank lines of the text, in order; timed lines without\ntext, such as instrumental breaks, are ignored.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Set synchronized lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "LRC document",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Timestamps updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID or LRC document, or lines not matching the text",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/rating": {
+            "get": {
+                "description": "Returns the play count and average rating of a song. Authenticated requests also get their own stars.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ratings"
+                ],
+                "summary": "Get song rating",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rating",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRatingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Give a song 1 to 5 stars, replacing an earlier rating by the authenticated user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ratings"
+                ],
+                "summary": "Rate a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stars",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RatingSet"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated rating",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRatingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID or body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the rating the authenticated user gave a song.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ratings"
+                ],
+                "summary": "Remove a rating",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Removed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found or not rated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/similar": {
+            "get": {
+                "description": "Songs ranked by similarity to a song: lyrics compared by tf-idf cosine similarity (60%),\nshared tags by Jaccard index (30%) and the same group (10%). Only precomputed rankings are\nserved: a song without one, such as one edited since, has none until the background refresh\nit queues has run.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Similar songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum songs (1-50, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Similar songs, most similar first",
+                        "schema": {
+                            "$ref": "#/definitions/models.SimilarSongs"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID or limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/stats": {
+            "get": {
+                "description": "Line, verse and word counts of the original text, with its most frequent words. Verses are separated by empty lines.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Lyrics statistics of a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of most frequent words (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Song statistics",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID or top",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/text": {
+            "get": {
+                "description": "Fetches the text of a song given its ID, along with pagination details.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Songs"
+                ],
+                "summary": "Retrieve song text by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of items per page",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return lines as objects with their LRC timestamps (models.SongLines)",
+                        "name": "timestamps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the text; defaults to the best Accept-Language match, then the original",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "With a translation, return original and translated lines side by side (models.ParallelText)",
+                        "name": "parallel",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response containing song text",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsText"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid language",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song or translation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal error or invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/texts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "List the languages of a song text",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored languages, original first",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTexts"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/texts/{lang}": {
+            "put": {
+                "description": "Stores a translation. With original set, the language becomes the original one and the text replaces the song text.\nThe original can't be unmarked; mark another language original instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Add or update a song text in a language",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "BCP 47 language tag, e.g. en or pt-BR",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Text",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTextUpsert"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Text stored",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid song ID, language or body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Song not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "409": {
+                        "description": "Unmarking the original",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "All tags ordered by name, optionally only those of one kind.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "List tags",
+                "parameters": [
+                    {
+                        "enum": [
+                            "tag",
+                            "genre"
+                        ],
+                        "type": "string",
+                        "description": "Tag kind",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tags",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListTags"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid kind",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Names are stored trimmed and lower-cased. The kind defaults to tag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Create a tag",
+                "parameters": [
+                    {
+                        "description": "Tag",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created tag",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "409": {
+                        "description": "Tag already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/counts": {
+            "get": {
+                "description": "Number of songs per tag among the songs matching the /songs filters, most used first, for faceted navigation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Tag counts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag names",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Whether songs need any or all of the tags",
+                        "name": "tagMatch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tags with song counts",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagCounts"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a tag and remove it from every song, each getting an update event.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Delete a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Rename a tag or change its kind; songs keep it, and each gets an update event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Update a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated tag",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag ID or fields",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "409": {
+                        "description": "Tag name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListWebhooks"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Song events of the given types are POSTed to the URL once their transaction commits, signed in the\nX-Webhook-Signature header as t=\u003cunix time\u003e,v1=\u003chex HMAC-SHA256 of \"\u003cunix time\u003e.\u003cbody\u003e\"\u003e.\nFailed deliveries are retried with exponential backoff and dead-lettered after the last attempt.\nThe secret is generated unless given and is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Subscribe a webhook",
+                "parameters": [
+                    {
+                        "description": "Subscription",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created webhook with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate the deliveries of all webhooks that ran out of attempts, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List dead-lettered deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListDeliveries"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{deliveryId}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue a dead-lettered delivery again with a fresh set of attempts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Retry a dead delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Queued",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid delivery ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "No dead delivery with this ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a webhook with its deliveries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the URL or event types, or pause the webhook by deactivating it. Deliveries of an inactive\nwebhook wait until it is activated again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Update a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated webhook",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or body",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Paginate the deliveries of a webhook, newest first, with their attempts and last error.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "Delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (starts with 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum elements (default 10)",
+                        "name": "max",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries with pagination details",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListDeliveries"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or status",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "502": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Credentials": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DuplicatePair": {
+            "type": "object",
+            "properties": {
+                "duplicate": {
+                    "$ref": "#/definitions/models.Song"
+                },
+                "lyrics": {
+                    "description": "Jaccard index of the lyrics word sets, 0 to 1.",
+                    "type": "number"
+                },
+                "sameTitle": {
+                    "type": "boolean"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                }
+            }
+        },
+        "models.FacetBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GroupCount": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GroupCounts": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroupCount"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.GroupStats": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "integer"
+                },
+                "topWords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WordCount"
+                    }
+                },
+                "uniqueWords": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "integer"
+                },
+                "words": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts started, including a running one.",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "runAt": {
+                    "description": "When a queued job is due, or the lease of a running one expires.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobsSummary": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobCount"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.LinkCheck": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "type": "boolean"
+                },
+                "checkedAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "redirectUrl": {
+                    "description": "Where redirects led, if anywhere else.",
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "songId": {
+                    "type": "integer"
+                },
+                "statusCode": {
+                    "description": "Of the last response; nil if there was none.",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkReport": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkCheck"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.LinkSummary"
+                }
+            }
+        },
+        "models.LinkSummary": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "type": "integer"
+                },
+                "checked": {
+                    "type": "integer"
+                },
+                "links": {
+                    "description": "Songs with a link.",
+                    "type": "integer"
+                },
+                "redirected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListAllSongs": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "facets": {
+                    "$ref": "#/definitions/models.SongFacets"
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListDeliveries": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebhookDelivery"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListDuplicates": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DuplicatePair"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListGroupCounts": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroupCount"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListGroupStats": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroupStats"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListJobs": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Job"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListPlaylistEntries": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistEntry"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListPlaylists": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Playlist"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListTags": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ListWebhooks": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Webhook"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "msg": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlayEvent": {
+            "type": "object",
+            "required": [
+                "eventId",
+                "songId"
+            ],
+            "properties": {
+                "eventId": {
+                    "description": "Chosen by the client, so that a retried batch isn't counted twice.",
+                    "type": "string",
+                    "maxLength": 128
+                },
+                "playedAt": {
+                    "type": "string"
+                },
+                "songId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Playlist": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ownerId": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaylistCreate": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "title": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "models.PlaylistEntry": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                }
+            }
+        },
+        "models.PlaylistEntryAdd": {
+            "type": "object",
+            "required": [
+                "songId"
+            ],
+            "properties": {
+                "position": {
+                    "description": "Position to insert at, moving later entries down; appended if empty.",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "songId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PlaylistEntryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PlaylistEntry"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PlaylistOrder": {
+            "type": "object",
+            "required": [
+                "entryIds"
+            ],
+            "properties": {
+                "entryIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.PlaylistResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Playlist"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PlaylistUpdate": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "models.PlaysBatch": {
+            "type": "object",
+            "required": [
+                "plays"
+            ],
+            "properties": {
+                "plays": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.PlayEvent"
+                    }
+                }
+            }
+        },
+        "models.PlaysResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PlaysResult"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PlaysResult": {
+            "type": "object",
+            "properties": {
+                "ignored": {
+                    "type": "integer"
+                },
+                "recorded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Popularity": {
+            "type": "object",
+            "properties": {
+                "plays": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "ratings": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RatingSet": {
+            "type": "object",
+            "required": [
+                "stars"
+            ],
+            "properties": {
+                "stars": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.RecentSong": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "string"
+                },
+                "enrichmentStatus": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isFavourite": {
+                    "description": "Only set for authenticated requests.",
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "popularity": {
+                    "description": "Only set in song listings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Popularity"
+                        }
+                    ]
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.RecentSongs": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RecentSong"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ReleaseBucket": {
+            "type": "object",
+            "properties": {
+                "period": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReleaseBuckets": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReleaseBucket"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SimilarSong": {
+            "type": "object",
+            "properties": {
+                "enrichmentStatus": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isFavourite": {
+                    "description": "Only set for authenticated requests.",
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "popularity": {
+                    "description": "Only set in song listings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Popularity"
+                        }
+                    ]
+                },
+                "reasons": {
+                    "$ref": "#/definitions/models.SimilarityReasons"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SimilarSongs": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SimilarSong"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SimilarityReasons": {
+            "type": "object",
+            "properties": {
+                "lyrics": {
+                    "description": "Cosine similarity of the tf-idf weighted lyrics words, 0 to 1.",
+                    "type": "number"
+                },
+                "sameGroup": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "Jaccard index of the tag sets, 0 to 1.",
+                    "type": "number"
+                }
+            }
+        },
+        "models.Song": {
+            "type": "object",
+            "properties": {
+                "enrichmentStatus": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isFavourite": {
+                    "description": "Only set for authenticated requests.",
+                    "type": "boolean"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "popularity": {
+                    "description": "Only set in song listings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Popularity"
+                        }
+                    ]
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SongCreateQuery": {
+            "type": "object",
+            "required": [
+                "group",
+                "releaseDate",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tag names; unknown ones are created.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "description": "Text and Link are required unless enrichment is enabled, which looks\nthem and ReleaseDate up when missing.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongCreated": {
+            "type": "object",
+            "properties": {
+                "enrichmentStatus": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SongCreated"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SongDetail": {
+            "type": "object",
+            "properties": {
+                "enrichmentStatus": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lang": {
+                    "description": "Language of Text, when known.",
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongFacets": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "array",
+                "items": {
+                    "$ref": "#/definitions/models.FacetBucket"
+                }
+            }
+        },
+        "models.SongMerge": {
+            "type": "object",
+            "required": [
+                "sourceIds",
+                "targetId"
+            ],
+            "properties": {
+                "sourceIds": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "targetId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongMerged": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "description": "Fields of the target that were empty and taken from a source.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "merged": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.SongMergedResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SongMerged"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SongRating": {
+            "type": "object",
+            "properties": {
+                "mine": {
+                    "description": "Stars given by the authenticated user, if any.",
+                    "type": "integer"
+                },
+                "plays": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "ratings": {
+                    "type": "integer"
+                },
+                "songId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongRatingResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SongRating"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SongStats": {
             "type": "object",
             "properties": {
-                "amount": {
+                "lines": {
                     "type": "integer"
                 },
-                "data": {
+                "songId": {
+                    "type": "integer"
+                },
+                "topWords": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Song"
+                        "$ref": "#/definitions/models.WordCount"
                     }
                 },
-                "next": {
-                    "type": "boolean"
+                "uniqueWords": {
+                    "type": "integer"
                 },
-                "ok": {
-                    "type": "boolean"
+                "verses": {
+                    "type": "integer"
                 },
-                "page": {
+                "words": {
                     "type": "integer"
                 }
             }
         },
-        "models.Message": {
+        "models.SongStatsResponse": {
             "type": "object",
             "properties": {
-                "msg": {
-                    "type": "string"
+                "data": {
+                    "$ref": "#/definitions/models.SongStats"
                 },
                 "ok": {
                     "type": "boolean"
                 }
             }
         },
-        "models.Song": {
+        "models.SongTextInfo": {
             "type": "object",
             "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "link": {
+                "lang": {
                     "type": "string"
                 },
-                "releaseDate": {
-                    "type": "string"
+                "original": {
+                    "type": "boolean"
                 },
-                "song": {
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "models.SongCreateQuery": {
+        "models.SongTextUpsert": {
             "type": "object",
             "required": [
-                "group",
-                "link",
-                "releaseDate",
-                "song",
                 "text"
             ],
+            "properties": {
+                "original": {
+                    "description": "Mark this language as the one the song was written in. Its text then\nreplaces the song text.",
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongTexts": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongTextInfo"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SongUpdate": {
+            "type": "object",
             "properties": {
                 "group": {
                     "type": "string"
@@ -370,74 +4782,314 @@ const docTemplate = `{
                 "song": {
                     "type": "string"
                 },
+                "tags": {
+                    "description": "Replaces all tags of the song; unknown ones are created.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "text": {
                     "type": "string"
                 }
             }
         },
-        "models.SongDetail": {
+        "models.SongsText": {
             "type": "object",
             "properties": {
-                "group": {
-                    "type": "string"
+                "amount": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "next": {
+                    "type": "boolean"
+                },
+                "ok": {
+                    "type": "boolean"
                 },
+                "page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "link": {
+                "kind": {
                     "type": "string"
                 },
-                "releaseDate": {
+                "name": {
                     "type": "string"
+                }
+            }
+        },
+        "models.TagCount": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
                 },
-                "song": {
+                "kind": {
                     "type": "string"
                 },
-                "text": {
+                "name": {
                     "type": "string"
+                },
+                "songs": {
+                    "type": "integer"
                 }
             }
         },
-        "models.SongUpdate": {
+        "models.TagCounts": {
             "type": "object",
             "properties": {
-                "group": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TagCount"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.TagCreate": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "tag",
+                        "genre"
+                    ]
+                },
+                "name": {
                     "type": "string"
+                }
+            }
+        },
+        "models.TagResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Tag"
                 },
-                "link": {
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.TagUpdate": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "tag",
+                        "genre"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "minLength": 1
+                }
+            }
+        },
+        "models.Token": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
                     "type": "string"
                 },
-                "releaseDate": {
+                "token": {
                     "type": "string"
+                }
+            }
+        },
+        "models.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Token"
                 },
-                "song": {
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "text": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "url": {
+                    "description": "Receives a POST of the models.SongEvent for every subscribed event.",
                     "type": "string"
                 }
             }
         },
-        "models.SongsText": {
+        "models.WebhookCreate": {
             "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "data": {
+                "secret": {
+                    "description": "Key of the HMAC-SHA256 signatures; generated if empty.",
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookCreated": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "events": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "next": {
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Receives a POST of the models.SongEvent for every subscribed event.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.WebhookCreated"
+                },
+                "ok": {
                     "type": "boolean"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastStatusCode": {
+                    "type": "integer"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhookId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Webhook"
                 },
                 "ok": {
                     "type": "boolean"
+                }
+            }
+        },
+        "models.WebhookUpdate": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
                 },
-                "page": {
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WordCount": {
+            "type": "object",
+            "properties": {
+                "count": {
                     "type": "integer"
+                },
+                "word": {
+                    "type": "string"
                 }
             }
         }
@@ -445,6 +5097,12 @@ const docTemplate = `{
     "securityDefinitions": {
         "BasicAuth": {
             "type": "basic"
+        },
+        "BearerAuth": {
+            "description": "\"Bearer \" followed by a token from POST /auth/token.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,64 @@
+// Package auth issues and verifies the access tokens of the API.
+//
+// A token is "<user id>.<unix expiry>.<signature>", the signature being the
+// base64url HMAC-SHA256 of the first two parts under the configured secret.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokens returns nil if secret is empty, which disables token auth.
+func NewTokens(secret string, ttl time.Duration) *Tokens {
+	if secret == "" {
+		return nil
+	}
+	return &Tokens{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue returns a token for userId and the time it expires.
+func (t *Tokens) Issue(userId int, now time.Time) (string, time.Time) {
+	expires := now.Add(t.ttl).Truncate(time.Second)
+	payload := strconv.Itoa(userId) + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + t.sign(payload), expires
+}
+
+// Verify returns the user id of a valid, unexpired token.
+func (t *Tokens) Verify(token string, now time.Time) (int, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(t.sign(token[:i]))) {
+		return 0, ErrInvalidToken
+	}
+	userPart, expiresPart, ok := strings.Cut(token[:i], ".")
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	userId, err := strconv.Atoi(userPart)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(expiresPart, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return 0, ErrInvalidToken
+	}
+	return userId, nil
+}
+
+func (t *Tokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+// Package cache is a small in-process TTL cache.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// Cache holds up to a fixed number of entries, evicting the least recently
+// used one to make room.
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	max   int
+	items map[K]*list.Element
+	// Most recently used first.
+	order *list.List
+}
+
+// New returns a cache of at most max entries, each kept for ttl.
+func New[K comparable, V any](ttl time.Duration, max int) *Cache[K, V] {
+	return &Cache[K, V]{ttl: ttl, max: max, items: make(map[K]*list.Element), order: list.New()}
+}
+
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return v, false
+	}
+	e := el.Value.(*entry[K, V])
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return v, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *Cache[K, V]) Set(key K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		el.Value = &entry[K, V]{key: key, value: v, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= c.max {
+		c.remove(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: v, expires: expires})
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
+
+// Len returns the number of entries, expired ones included.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+	c.order.Init()
+}
@@ -0,0 +1,87 @@
+// Package enrichment fills in missing song details from the music info
+// service in background jobs.
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+)
+
+// ErrNotFound is returned for songs the music info service doesn't know.
+var ErrNotFound = errors.New("song not found in the music info service")
+
+// releaseDateLayout is the date format of the music info service.
+const releaseDateLayout = "02.01.2006"
+
+// Client looks songs up with GET <url>/info?group=...&song=..., answered
+// with {"releaseDate": "16.07.2006", "text": "...", "link": "..."}.
+type Client struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewClient(cfg config.EnrichmentConfig) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(cfg.URL, "/"),
+		apiKey: cfg.APIKey,
+		client: tracing.HTTPClient(&http.Client{Timeout: cfg.Timeout}),
+	}
+}
+
+type songInfo struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+func (c *Client) Lookup(ctx context.Context, group, song string) (info models.SongEnrichment, err error) {
+	query := url.Values{"group": {group}, "song": {song}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/info?"+query.Encode(), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return info, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return info, fmt.Errorf("music info service: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var si songInfo
+	if err = json.NewDecoder(resp.Body).Decode(&si); err != nil {
+		return info, fmt.Errorf("music info service: %w", err)
+	}
+	info.Text, info.Link = si.Text, si.Link
+	if si.ReleaseDate != "" {
+		t, err := time.Parse(releaseDateLayout, si.ReleaseDate)
+		if err != nil {
+			return info, fmt.Errorf("music info service: release date: %w", err)
+		}
+		date := models.DateFormat(t)
+		info.ReleaseDate = &date
+	}
+	return info, nil
+}
@@ -0,0 +1,83 @@
+package enrichment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/jobs"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+// Store is the part of the repository the enricher works with.
+type Store interface {
+	ApplyEnrichment(ctx context.Context, ej *models.EnrichSongJob, info *models.SongEnrichment) error
+	SetEnrichmentStatus(ctx context.Context, songId int, status string) error
+	Begin() (*postgresql.Transaction, error)
+}
+
+// Enricher runs the models.JobEnrichSong jobs.
+type Enricher struct {
+	client *Client
+	// A repository per job, as jobs run concurrently in transactions.
+	store func() Store
+	// Called with the id of every song enriched, once committed.
+	applied func(songId int)
+}
+
+func NewEnricher(client *Client, store func() Store) *Enricher {
+	return &Enricher{client: client, store: store, applied: func(int) {}}
+}
+
+// OnApplied makes fn run for every song enriched, after the commit, so
+// that caches of its text and date can be dropped.
+func (e *Enricher) OnApplied(fn func(songId int)) *Enricher {
+	e.applied = fn
+	return e
+}
+
+// Run is the jobs.Handler of models.JobEnrichSong. Songs unknown upstream,
+// and those still failing on the last attempt, are marked failed.
+func (e *Enricher) Run(ctx context.Context, payload json.RawMessage) error {
+	var ej models.EnrichSongJob
+	if err := json.Unmarshal(payload, &ej); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	info, err := e.client.Lookup(ctx, ej.Group, ej.Song)
+	if err != nil {
+		notFound := errors.Is(err, ErrNotFound)
+		if notFound || jobs.LastAttempt(ctx) {
+			// The lookup may have failed by running out of time.
+			if err := e.store().SetEnrichmentStatus(context.WithoutCancel(ctx), ej.SongId, models.EnrichmentFailed); err != nil {
+				return err
+			}
+		}
+		if notFound {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+
+	store := e.store()
+	tr, err := store.Begin()
+	if err != nil {
+		return err
+	}
+	err = store.ApplyEnrichment(ctx, &ej, &info)
+	if err != nil {
+		tr.Rollback()
+		// Deleted meanwhile.
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if err := tr.Commit(); err != nil {
+		return err
+	}
+	e.applied(ej.SongId)
+	return nil
+}
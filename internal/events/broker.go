@@ -0,0 +1,103 @@
+// Package events wakes the readers of the song event log, and of the job
+// queue, when Postgres notifies that new rows were committed.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// Broker fans notifications out to subscribers. A notification only says
+// that there may be new events; subscribers read the log to find out, so a
+// wakeup that is coalesced with another or arrives late loses nothing.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[chan struct{}]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel that receives a value after every Notify and
+// is closed by Close, and a function that unsubscribes.
+func (b *Broker) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Notify wakes every subscriber without blocking.
+func (b *Broker) Notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription, so that streams don't hold up a server
+// shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+	b.closed = true
+}
+
+// Listen notifies b for every notification on channel until ctx is
+// cancelled. Notifications missed while the connection was down are made up
+// for by one after it is back.
+func Listen(ctx context.Context, dsn, channel string, b *Broker) error {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			log.WithError(err).Warn("event listener disconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.WithError(err).Warn("event listener failed to reconnect")
+		case pq.ListenerEventReconnected:
+			log.Info("event listener reconnected")
+		}
+	})
+	defer l.Close()
+	if err := l.Listen(channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		// pq sends nil after reconnecting.
+		case <-l.Notify:
+			b.Notify()
+		// Detects dead connections that pq wouldn't notice while idle.
+		case <-time.After(90 * time.Second):
+			go l.Ping()
+		}
+	}
+}
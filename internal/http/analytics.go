@@ -0,0 +1,165 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// bindAnalyticsQuery binds the SongsQuery filters of an analytics request.
+func bindAnalyticsQuery(c *gin.Context) (models.SongsQuery, bool) {
+	sq := models.NewSongsQuery()
+	c.Bind(&sq)
+	if sq.Page < 0 || sq.Max < 1 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "invalid page or max"))
+		return sq, false
+	}
+	if !sq.ValidTagMatch() {
+		c.JSON(http.StatusBadRequest, errMessage(c, "tagMatch must be any or all"))
+		return sq, false
+	}
+	return sq, true
+}
+
+// SongsPerGroup godoc
+//
+//	@Summary		Songs per group
+//	@Description	Count the songs of each group, ordered by group name. Takes the same filters as /songs.
+//	@Tags			Analytics
+//	@Produce		json
+//	@Param			page		query		int						false	"Page (starts with 0)"
+//	@Param			max			query		int						false	"Maximum elements (default 10)"
+//	@Param			group		query		string					false	"Group name"
+//	@Param			song		query		string					false	"Song name"
+//	@Param			link		query		string					false	"Link"
+//	@Param			releaseDate	query		string					false	"Release date"
+//	@Success		200			{object}	models.ListGroupCounts	"Songs per group with pagination details"
+//	@Failure		400			{object}	models.Message			"Invalid parameters"
+//	@Failure		502			{object}	models.Message			"Internal server error"
+//	@Router			/analytics/groups [get]
+func (h *Handler) SongsPerGroup(c *gin.Context) {
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	groups, amount, err := h.songsRepo.CountSongsByGroup(c.Request.Context(), &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if groups == nil {
+		groups = []models.GroupCount{}
+	}
+	c.JSON(http.StatusOK, models.ListGroupCounts{
+		Ok:     true,
+		Data:   groups,
+		Page:   sq.Page,
+		Next:   sq.Max*(sq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// TopGroups godoc
+//
+//	@Summary		Groups with the most songs
+//	@Description	The max groups with the most songs, ties broken by group name. Takes the same filters as /songs.
+//	@Tags			Analytics
+//	@Produce		json
+//	@Param			max			query		int					false	"Number of groups (default 10)"
+//	@Param			group		query		string				false	"Group name"
+//	@Param			song		query		string				false	"Song name"
+//	@Param			link		query		string				false	"Link"
+//	@Param			releaseDate	query		string				false	"Release date"
+//	@Success		200			{object}	models.GroupCounts	"Groups ordered by song count"
+//	@Failure		400			{object}	models.Message		"Invalid parameters"
+//	@Failure		502			{object}	models.Message		"Internal server error"
+//	@Router			/analytics/top-groups [get]
+func (h *Handler) TopGroups(c *gin.Context) {
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	groups, err := h.songsRepo.GetTopGroups(c.Request.Context(), &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if groups == nil {
+		groups = []models.GroupCount{}
+	}
+	c.JSON(http.StatusOK, models.GroupCounts{Ok: true, Data: groups})
+}
+
+// Releases godoc
+//
+//	@Summary		Releases over time
+//	@Description	Count the songs released per year or month. Periods without releases between the first and the last one are included with zero songs. Takes the same filters as /songs.
+//	@Tags			Analytics
+//	@Produce		json
+//	@Param			interval	query		string					false	"Bucket size"	Enums(year, month)	default(month)
+//	@Param			group		query		string					false	"Group name"
+//	@Param			song		query		string					false	"Song name"
+//	@Param			link		query		string					false	"Link"
+//	@Param			releaseDate	query		string					false	"Release date"
+//	@Success		200			{object}	models.ReleaseBuckets	"Releases per period in chronological order"
+//	@Failure		400			{object}	models.Message			"Invalid parameters"
+//	@Failure		502			{object}	models.Message			"Internal server error"
+//	@Router			/analytics/releases [get]
+func (h *Handler) Releases(c *gin.Context) {
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", models.IntervalMonth)
+	if interval != models.IntervalYear && interval != models.IntervalMonth {
+		c.JSON(http.StatusBadRequest, errMessage(c, "interval must be year or month"))
+		return
+	}
+	buckets, err := h.songsRepo.CountReleases(c.Request.Context(), &sq, interval)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if buckets == nil {
+		buckets = []models.ReleaseBucket{}
+	}
+	c.JSON(http.StatusOK, models.ReleaseBuckets{Ok: true, Data: buckets})
+}
+
+// RecentSongs godoc
+//
+//	@Summary		Most recently added songs
+//	@Description	The max songs added last, newest first. Takes the same filters as /songs.
+//	@Tags			Analytics
+//	@Produce		json
+//	@Param			max			query		int					false	"Number of songs (default 10)"
+//	@Param			group		query		string				false	"Group name"
+//	@Param			song		query		string				false	"Song name"
+//	@Param			link		query		string				false	"Link"
+//	@Param			releaseDate	query		string				false	"Release date"
+//	@Success		200			{object}	models.RecentSongs	"Songs with the time they were added"
+//	@Failure		400			{object}	models.Message		"Invalid parameters"
+//	@Failure		502			{object}	models.Message		"Internal server error"
+//	@Router			/analytics/recent [get]
+func (h *Handler) RecentSongs(c *gin.Context) {
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	songs, err := h.songsRepo.GetRecentSongs(c.Request.Context(), &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if songs == nil {
+		songs = []models.RecentSong{}
+	}
+	c.JSON(http.StatusOK, models.RecentSongs{Ok: true, Data: songs})
+}
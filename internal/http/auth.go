@@ -0,0 +1,135 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/auth"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+const userKey = "user"
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="songs"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, errMessage(c, "unauthorized"))
+}
+
+// Authenticate identifies the user from Basic credentials or a Bearer token.
+// Requests without an Authorization header go on anonymously; requests with
+// invalid credentials are rejected.
+func (h *Handler) Authenticate(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		c.Next()
+		return
+	}
+	if h.usersRepo == nil {
+		unauthorized(c)
+		return
+	}
+
+	var (
+		user models.User
+		err  error
+	)
+	if username, password, ok := c.Request.BasicAuth(); ok {
+		user, err = h.usersRepo.Authenticate(c.Request.Context(), username, password)
+	} else if token, ok := strings.CutPrefix(header, "Bearer "); ok && h.tokens != nil {
+		var userId int
+		if userId, err = h.tokens.Verify(token, time.Now()); err == nil {
+			user, err = h.usersRepo.GetUser(c.Request.Context(), userId)
+		}
+	} else {
+		err = auth.ErrInvalidToken
+	}
+	if errors.Is(err, postgresql.ErrInvalidCredentials) || errors.Is(err, auth.ErrInvalidToken) || err == sql.ErrNoRows {
+		unauthorized(c)
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.Set(userKey, user)
+	utils.Log(c.Request.Context()).WithField("userId", user.Id).Debug("authenticated")
+	c.Next()
+}
+
+// RequireUser rejects anonymous requests.
+func (h *Handler) RequireUser(c *gin.Context) {
+	if _, ok := currentUser(c); !ok {
+		unauthorized(c)
+		return
+	}
+	c.Next()
+}
+
+// RequireAdmin rejects anonymous requests and those of users who aren't
+// administrators.
+func (h *Handler) RequireAdmin(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		unauthorized(c)
+		return
+	}
+	if !user.IsAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, errMessage(c, "forbidden"))
+		return
+	}
+	c.Next()
+}
+
+func currentUser(c *gin.Context) (models.User, bool) {
+	user, ok := c.Get(userKey)
+	if !ok {
+		return models.User{}, false
+	}
+	return user.(models.User), true
+}
+
+// CreateToken godoc
+//
+//	@Summary		Get an access token
+//	@Description	Exchange a username and password for a bearer token.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.Credentials		true	"Credentials"
+//	@Success		200		{object}	models.TokenResponse	"Token and its expiry"
+//	@Failure		400		{object}	models.Message			"Invalid body"
+//	@Failure		401		{object}	models.Message			"Invalid credentials"
+//	@Failure		501		{object}	models.Message			"Token authentication is not configured"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/auth/token [post]
+func (h *Handler) CreateToken(c *gin.Context) {
+	if h.tokens == nil || h.usersRepo == nil {
+		c.JSON(http.StatusNotImplemented, errMessage(c, "token authentication is not configured"))
+		return
+	}
+	var cr models.Credentials
+	if err := c.ShouldBind(&cr); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	user, err := h.usersRepo.Authenticate(c.Request.Context(), cr.Username, cr.Password)
+	if errors.Is(err, postgresql.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	token, expires := h.tokens.Issue(user.Id, time.Now())
+	c.JSON(http.StatusOK, models.TokenResponse{Ok: true, Data: models.Token{Token: token, ExpiresAt: expires}})
+}
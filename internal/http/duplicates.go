@@ -0,0 +1,104 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// ListDuplicates godoc
+//
+//	@Summary		Find duplicate songs
+//	@Description	Paginate pairs of songs of the same group, compared ignoring case, whitespace and punctuation, that
+//	@Description	have the same title or lyrics at least minLyrics similar (Jaccard index of their word sets). Pairs
+//	@Description	with the same title come first, then the most similar lyrics.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			minLyrics	query		number					false	"Minimum lyrics similarity of differently titled songs, 0 to 1 (default 0.8)"
+//	@Param			page		query		int						false	"Page (starts with 0)"
+//	@Param			max			query		int						false	"Maximum elements (default 10)"
+//	@Success		200			{object}	models.ListDuplicates	"Duplicate pairs with pagination details"
+//	@Failure		400			{object}	models.Message			"Invalid minLyrics"
+//	@Failure		401			{object}	models.Message			"Not authenticated"
+//	@Failure		403			{object}	models.Message			"Not an administrator"
+//	@Failure		502			{object}	models.Message			"Internal server error"
+//	@Router			/admin/duplicates [get]
+func (h *Handler) ListDuplicates(c *gin.Context) {
+	dq := models.NewDuplicatesQuery()
+	if err := c.ShouldBindQuery(&dq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	pairs, amount, err := h.songsRepo.ListDuplicates(c.Request.Context(), &dq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if pairs == nil {
+		pairs = []models.DuplicatePair{}
+	}
+	c.JSON(http.StatusOK, models.ListDuplicates{
+		Ok:     true,
+		Data:   pairs,
+		Page:   dq.Page,
+		Next:   dq.Max*(dq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// MergeSongs godoc
+//
+//	@Summary		Merge duplicate songs
+//	@Description	Fold the source songs into the target in one transaction. Empty text, link and release date of the
+//	@Description	target are taken from the first source that has them; tags, translations, favourites, ratings, plays
+//	@Description	and playlist entries move to the target. The sources are then deleted.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			body	body		models.SongMerge			true	"Target and sources"
+//	@Success		200		{object}	models.SongMergedResponse	"Merged"
+//	@Failure		400		{object}	models.Message				"Invalid body, or the target among the sources"
+//	@Failure		401		{object}	models.Message				"Not authenticated"
+//	@Failure		403		{object}	models.Message				"Not an administrator"
+//	@Failure		404		{object}	models.Message				"Unknown song"
+//	@Failure		502		{object}	models.Message				"Internal server error"
+//	@Router			/admin/songs/merge [post]
+func (h *Handler) MergeSongs(c *gin.Context) {
+	var sm models.SongMerge
+	if err := c.ShouldBindJSON(&sm); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	ids := append([]int{sm.TargetId}, sm.SourceIds...)
+	slices.Sort(ids)
+	if len(slices.Compact(ids)) != len(sm.SourceIds)+1 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "songs must be given once"))
+		return
+	}
+
+	merged, err := h.songsRepo.MergeSongs(c.Request.Context(), &sm)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	for _, songId := range append([]int{sm.TargetId}, sm.SourceIds...) {
+		h.invalidateStats(c, songId)
+	}
+	c.JSON(http.StatusOK, models.SongMergedResponse{Ok: true, Data: merged})
+}
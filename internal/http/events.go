@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+const (
+	// songEventsBatch bounds the events read from the log at once.
+	songEventsBatch = 100
+	// sseHeartbeat keeps idle streams from being closed by proxies.
+	sseHeartbeat = 15 * time.Second
+)
+
+// lastEventId reads the id to resume after from the Last-Event-ID header
+// browsers send on reconnect, or the lastEventId parameter for the first
+// connection. It reports false if there is none.
+func lastEventId(c *gin.Context) (int64, bool, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("lastEventId")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	return id, true, err
+}
+
+// StreamSongEvents godoc
+//
+//	@Summary		Song change feed
+//	@Description	Streams song create, update and delete events as Server-Sent Events, each with its id, type and song id.
+//	@Description	Events are sent once their transaction is committed. A reconnecting EventSource resumes after the
+//	@Description	Last-Event-ID it sends; without one the stream starts with the next change.
+//	@Tags			Songs
+//	@Produce		text/event-stream
+//	@Param			Last-Event-ID	header		int				false	"Resume after this event"
+//	@Param			lastEventId		query		int				false	"Resume after this event, for clients that can't set headers"
+//	@Success		200				{string}	string			"Event stream of models.SongEvent"
+//	@Failure		400				{object}	models.Message	"Invalid event id"
+//	@Failure		502				{object}	models.Message	"Internal server error"
+//	@Failure		503				{object}	models.Message	"Change feed disabled"
+//	@Router			/songs/events [get]
+func (h *Handler) StreamSongEvents(c *gin.Context) {
+	if h.events == nil {
+		c.JSON(http.StatusServiceUnavailable, errMessage(c, "change feed disabled"))
+		return
+	}
+	lastId, resume, err := lastEventId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	// A stream outlives any transaction, so it reads the log through its own
+	// repository rather than TransactionMiddleware's.
+	repo := h.songsRepoGetter()
+	ctx := c.Request.Context()
+	wake, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+	if !resume {
+		if lastId, err = repo.LastSongEventId(ctx); err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(ctx).Panic(err.Error())
+			return
+		}
+	}
+
+	// Without a write deadline the server's WriteTimeout would cut the stream;
+	// if the writer doesn't support it, clients reconnect and resume.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		events, err := repo.ListSongEvents(ctx, lastId, songEventsBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				utils.Log(ctx).WithError(err).Error("reading song events")
+			}
+			return
+		}
+		for _, e := range events {
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(e.Id, 10), Event: e.Type, Data: e})
+			lastId = e.Id
+		}
+		c.Writer.Flush()
+		if len(events) == songEventsBatch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				return
+			}
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
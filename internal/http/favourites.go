@@ -0,0 +1,161 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// markFavourites sets IsFavourite on songs for an authenticated request. It
+// reports false after responding with an error.
+func (h *Handler) markFavourites(c *gin.Context, songs []models.Song) bool {
+	user, ok := currentUser(c)
+	if !ok || len(songs) == 0 {
+		return true
+	}
+	ids := make([]int, len(songs))
+	for i, s := range songs {
+		ids[i] = s.Id
+	}
+	favourites, err := h.songsRepo.GetFavouriteIds(c.Request.Context(), user.Id, ids)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return false
+	}
+	for i := range songs {
+		songs[i].IsFavourite = utils.Ptr(favourites[songs[i].Id])
+	}
+	return true
+}
+
+// existingSong parses the song id and checks that the song exists. It
+// reports false after responding with an error.
+func (h *Handler) existingSong(c *gin.Context) (int, bool) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return 0, false
+	}
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return 0, false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return 0, false
+	}
+	return songId, true
+}
+
+// AddFavourite godoc
+//
+//	@Summary		Favourite a song
+//	@Description	Add a song to the favourites of the authenticated user. Adding it twice is not an error.
+//	@Tags			Favourites
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Song ID"
+//	@Success		200	{object}	models.Message	"Added"
+//	@Failure		400	{object}	models.Message	"Invalid song ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		404	{object}	models.Message	"Song not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id}/favourite [put]
+func (h *Handler) AddFavourite(c *gin.Context) {
+	user, _ := currentUser(c)
+	songId, ok := h.existingSong(c)
+	if !ok {
+		return
+	}
+	if err := h.songsRepo.AddFavourite(c.Request.Context(), user.Id, songId); err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "added"})
+}
+
+// RemoveFavourite godoc
+//
+//	@Summary		Unfavourite a song
+//	@Description	Remove a song from the favourites of the authenticated user.
+//	@Tags			Favourites
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Song ID"
+//	@Success		200	{object}	models.Message	"Removed"
+//	@Failure		400	{object}	models.Message	"Invalid song ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		404	{object}	models.Message	"Song not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id}/favourite [delete]
+func (h *Handler) RemoveFavourite(c *gin.Context) {
+	user, _ := currentUser(c)
+	songId, ok := h.existingSong(c)
+	if !ok {
+		return
+	}
+	if err := h.songsRepo.RemoveFavourite(c.Request.Context(), user.Id, songId); err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "removed"})
+}
+
+// ListFavourites godoc
+//
+//	@Summary		List favourite songs
+//	@Description	Paginate the favourites of the authenticated user, most recently added first unless sort is given, with
+//	@Description	the filters of /songs.
+//	@Tags			Favourites
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			page	query		int					false	"Page (starts with 0)"
+//	@Param			max		query		int					false	"Maximum elements (default 10)"
+//	@Param			group	query		string				false	"Group name"
+//	@Param			song	query		string				false	"Song name"
+//	@Param			tag		query		[]string			false	"Tag names"	collectionFormat(multi)
+//	@Param			sort	query		string				false	"Order by play count or average rating, highest first"	Enums(popularity, rating)
+//	@Success		200		{object}	models.ListAllSongs	"Favourite songs with pagination details"
+//	@Failure		400		{object}	models.Message		"Invalid parameters"
+//	@Failure		401		{object}	models.Message		"Not authenticated"
+//	@Failure		502		{object}	models.Message		"Internal server error"
+//	@Router			/me/favourites [get]
+func (h *Handler) ListFavourites(c *gin.Context) {
+	user, _ := currentUser(c)
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	if !sq.ValidSort() {
+		c.JSON(http.StatusBadRequest, errMessage(c, "sort must be popularity or rating"))
+		return
+	}
+	songs, amount, err := h.songsRepo.GetFavourites(c.Request.Context(), user.Id, &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if songs == nil {
+		songs = []models.Song{}
+	}
+	c.JSON(http.StatusOK, models.ListAllSongs{
+		Ok:     true,
+		Data:   songs,
+		Page:   sq.Page,
+		Next:   sq.Max*(sq.Page+1) < amount,
+		Amount: amount,
+	})
+}
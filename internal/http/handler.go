@@ -2,33 +2,121 @@ package http
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/auth"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/cache"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/events"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/metrics"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
 	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// statsTTL bounds how stale cached statistics can get when the text is
+// changed outside of this API.
+const statsTTL = 5 * time.Minute
+
+// Bounds of the statistics caches, whose keys come from the client.
+const (
+	maxCachedSongStats  = 10000
+	maxCachedGroupStats = 1000
 )
 
 type Handler struct {
 	songsRepo       postgresql.SongsRepositoryI
 	songsRepoGetter func() postgresql.SongsRepositoryI
+
+	usersRepo postgresql.UsersRepositoryI
+	tokens    *auth.Tokens
+
+	events *events.Broker
+	// Whether the songs.enrich jobs run.
+	enrichment bool
+
+	songStats  *cache.Cache[int, models.SongStats]
+	groupStats *cache.Cache[groupStatsKey, models.ListGroupStats]
 }
 
 func New(songsRepoGetter func() postgresql.SongsRepositoryI) Handler {
-	return Handler{songsRepoGetter: songsRepoGetter}
+	return Handler{
+		songsRepoGetter: songsRepoGetter,
+		songStats:       cache.New[int, models.SongStats](statsTTL, maxCachedSongStats),
+		groupStats:      cache.New[groupStatsKey, models.ListGroupStats](statsTTL, maxCachedGroupStats),
+	}
 }
 
 func NewTest(songsRepo postgresql.SongsRepositoryI) Handler {
-	return Handler{songsRepo: songsRepo, songsRepoGetter: func() postgresql.SongsRepositoryI { return songsRepo }}
+	h := New(func() postgresql.SongsRepositoryI { return songsRepo })
+	h.songsRepo = songsRepo
+	return h
+}
+
+// WithAuth enables authentication against users; a nil tokens disables
+// bearer tokens.
+func (h Handler) WithAuth(users postgresql.UsersRepositoryI, tokens *auth.Tokens) Handler {
+	h.usersRepo = users
+	h.tokens = tokens
+	return h
+}
+
+// WithEvents enables GET /songs/events, woken by b.
+func (h Handler) WithEvents(b *events.Broker) Handler {
+	h.events = b
+	return h
+}
+
+// WithEnrichment makes new songs with missing details, and POST
+// /songs/:id/enrich, queue lookups in the music info service.
+func (h Handler) WithEnrichment() Handler {
+	h.enrichment = true
+	return h
+}
+
+// errMessage builds a failed response carrying the request id, so clients can
+// quote it when reporting a problem.
+func errMessage(c *gin.Context, msg string) models.Message {
+	return models.Message{Ok: false, Msg: msg, RequestId: utils.RequestID(c.Request.Context())}
+}
+
+const afterCommitKey = "afterCommit"
+
+// afterCommit registers fn to run once the request's transaction is
+// committed, so that caches aren't invalidated by changes that get rolled
+// back.
+func afterCommit(c *gin.Context, fn func()) {
+	fns, _ := c.Get(afterCommitKey)
+	list, _ := fns.([]func())
+	c.Set(afterCommitKey, append(list, fn))
 }
 
 func (h *Handler) TransactionMiddleware(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "TransactionMiddleware")
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+
 	h.songsRepo = h.songsRepoGetter()
 	tr, _ := h.songsRepo.Begin()
+	c.Set(afterCommitKey, []func(){})
 	c.Next()
 	statusCode := c.Writer.Status()
 	if statusCode >= http.StatusOK && statusCode < http.StatusBadRequest {
-		tr.Commit()
+		err := tr.Commit()
+		metrics.Commit()
+		span.SetAttributes(attribute.String("transaction.outcome", "commit"))
+		if err == nil {
+			for _, fn := range c.MustGet(afterCommitKey).([]func()) {
+				fn()
+			}
+		}
 	}
 	if statusCode >= http.StatusBadRequest {
 		tr.Rollback()
+		metrics.Rollback()
+		span.SetAttributes(attribute.String("transaction.outcome", "rollback"))
 	}
 }
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// ListJobs godoc
+//
+//	@Summary		List background jobs
+//	@Description	Paginate the background jobs, newest first, with their attempts and last error. Failed jobs are
+//	@Description	queued again with exponential backoff until they run out of attempts and die.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			kind	query		string			false	"Job kind"
+//	@Param			status	query		string			false	"Job status"	Enums(queued, running, done, dead)
+//	@Param			page	query		int				false	"Page (starts with 0)"
+//	@Param			max		query		int				false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.ListJobs	"Jobs with pagination details"
+//	@Failure		400		{object}	models.Message	"Invalid status"
+//	@Failure		401		{object}	models.Message	"Not authenticated"
+//	@Failure		403		{object}	models.Message	"Not an administrator"
+//	@Failure		502		{object}	models.Message	"Internal server error"
+//	@Router			/admin/jobs [get]
+func (h *Handler) ListJobs(c *gin.Context) {
+	jq := models.JobsQuery{PageMaxQuery: models.NewPageMaxQuery()}
+	if err := c.ShouldBindQuery(&jq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	jobs, amount, err := h.songsRepo.ListJobs(c.Request.Context(), &jq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+	c.JSON(http.StatusOK, models.ListJobs{
+		Ok:     true,
+		Data:   jobs,
+		Page:   jq.Page,
+		Next:   jq.Max*(jq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// JobsSummary godoc
+//
+//	@Summary		Count background jobs
+//	@Description	Count the jobs of every kind in every status.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.JobsSummary	"Job counts"
+//	@Failure		401	{object}	models.Message		"Not authenticated"
+//	@Failure		403	{object}	models.Message		"Not an administrator"
+//	@Failure		502	{object}	models.Message		"Internal server error"
+//	@Router			/admin/jobs/summary [get]
+func (h *Handler) JobsSummary(c *gin.Context) {
+	counts, err := h.songsRepo.CountJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if counts == nil {
+		counts = []models.JobCount{}
+	}
+	c.JSON(http.StatusOK, models.JobsSummary{Ok: true, Data: counts})
+}
+
+// RetryJob godoc
+//
+//	@Summary		Retry a dead job
+//	@Description	Queue a dead job again with a fresh set of attempts.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Job ID"
+//	@Success		200	{object}	models.Message	"Queued"
+//	@Failure		400	{object}	models.Message	"Invalid job ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		403	{object}	models.Message	"Not an administrator"
+//	@Failure		404	{object}	models.Message	"No dead job with this ID"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/admin/jobs/{id}/retry [post]
+func (h *Handler) RetryJob(c *gin.Context) {
+	jobId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	err = h.songsRepo.RetryJob(c.Request.Context(), jobId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "queued"})
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// LinkReport godoc
+//
+//	@Summary		Report broken song links
+//	@Description	Count the checked, broken and redirected song links and paginate the broken ones, most recently
+//	@Description	checked first. Links are checked by a background job, at most once per configured interval.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			page	query		int					false	"Page (starts with 0)"
+//	@Param			max		query		int					false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.LinkReport	"Link counts and broken links with pagination details"
+//	@Failure		400		{object}	models.Message		"Invalid pagination"
+//	@Failure		401		{object}	models.Message		"Not authenticated"
+//	@Failure		403		{object}	models.Message		"Not an administrator"
+//	@Failure		502		{object}	models.Message		"Internal server error"
+//	@Router			/admin/links/report [get]
+func (h *Handler) LinkReport(c *gin.Context) {
+	pmq := models.NewPageMaxQuery()
+	if err := c.ShouldBindQuery(&pmq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	summary, err := h.songsRepo.GetLinkSummary(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	broken, amount, err := h.songsRepo.ListBrokenLinks(c.Request.Context(), &pmq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if broken == nil {
+		broken = []models.LinkCheck{}
+	}
+	c.JSON(http.StatusOK, models.LinkReport{
+		Ok:      true,
+		Data:    broken,
+		Page:    pmq.Page,
+		Next:    pmq.Max*(pmq.Page+1) < amount,
+		Amount:  amount,
+		Summary: summary,
+	})
+}
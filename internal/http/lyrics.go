@@ -0,0 +1,144 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/lrc"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// maxLRCSize bounds PUT /songs/:id/lyrics.lrc bodies.
+const maxLRCSize = 1 << 20
+
+// getSongLines serves GetSongText?timestamps=true.
+func (h *Handler) getSongLines(c *gin.Context, songId int, pmq *models.PageMaxQuery) {
+	lines, amount, err := h.songsRepo.GetSongLines(c.Request.Context(), songId, pmq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.SongLines{
+		Data:   lines,
+		Page:   pmq.Page,
+		Ok:     true,
+		Amount: amount,
+		Next:   pmq.Max*(pmq.Page+1) < amount,
+	})
+}
+
+// GetLyricsLRC godoc
+//
+//	@Summary		Get synchronized lyrics
+//	@Description	Returns the timed lines of a song as LRC. Lines without a timestamp are left out.
+//	@Tags			Lyrics
+//	@Produce		plain
+//	@Param			id			path		int				true	"Song ID"
+//	@Param			enhanced	query		bool			false	"Include per-word timings (enhanced LRC)"
+//	@Success		200			{string}	string			"LRC document"
+//	@Failure		400			{object}	models.Message	"Invalid song ID"
+//	@Failure		404			{object}	models.Message	"Song not found or not synchronized"
+//	@Failure		502			{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id}/lyrics.lrc [get]
+func (h *Handler) GetLyricsLRC(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	enhanced, _ := strconv.ParseBool(c.Query("enhanced"))
+
+	sl, err := h.songsRepo.GetSyncedLyrics(c.Request.Context(), songId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+
+	synced := false
+	for _, l := range sl.Lines {
+		if l.StartMs != nil {
+			synced = true
+			break
+		}
+	}
+	if !synced {
+		c.JSON(http.StatusNotFound, errMessage(c, "song has no synchronized lyrics"))
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(lrc.Format(sl, enhanced)))
+}
+
+// PutLyricsLRC godoc
+//
+//	@Summary		Set synchronized lyrics
+//	@Description	Stores the timestamps of an LRC or enhanced LRC document against the song text, which is left as
+//	@Description	it is. The timed lines, ordered by time, must be the non-blank lines of the text, in order; timed lines without
+//	@Description	text, such as instrumental breaks, are ignored.
+//	@Tags			Lyrics
+//	@Accept			plain
+//	@Produce		json
+//	@Param			id		path		int				true	"Song ID"
+//	@Param			body	body		string			true	"LRC document"
+//	@Success		200		{object}	models.Message	"Timestamps updated"
+//	@Failure		400		{object}	models.Message	"Invalid song ID or LRC document, or lines not matching the text"
+//	@Failure		404		{object}	models.Message	"Song not found"
+//	@Failure		502		{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id}/lyrics.lrc [put]
+func (h *Handler) PutLyricsLRC(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxLRCSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	sl, err := lrc.Parse(string(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if len(sl.Lines) == 0 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "no timed lines"))
+		return
+	}
+
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+
+	err = h.songsRepo.SetSyncedLyrics(c.Request.Context(), songId, sl.Lines)
+	if errors.Is(err, postgresql.ErrLyricsMismatch) {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "updated"})
+}
@@ -0,0 +1,421 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/playlist"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// playlistFor loads the playlist of the id parameter. Private playlists of
+// others are reported as not found; with write, public playlists of others
+// are forbidden. It reports false after responding with an error.
+func (h *Handler) playlistFor(c *gin.Context, write bool) (models.Playlist, bool) {
+	playlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return models.Playlist{}, false
+	}
+	p, err := h.songsRepo.GetPlaylist(c.Request.Context(), playlistId)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return p, false
+	}
+	user, authenticated := currentUser(c)
+	owner := authenticated && user.Id == p.OwnerId
+	if err == sql.ErrNoRows || (!owner && p.Visibility != models.VisibilityPublic) {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return p, false
+	}
+	if write && !owner {
+		c.JSON(http.StatusForbidden, errMessage(c, "only the owner can change a playlist"))
+		return p, false
+	}
+	return p, true
+}
+
+// CreatePlaylist godoc
+//
+//	@Summary		Create a playlist
+//	@Description	Create an empty playlist owned by the authenticated user. Playlists are private unless made public.
+//	@Tags			Playlists
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			body	body		models.PlaylistCreate		true	"Playlist"
+//	@Success		201		{object}	models.PlaylistResponse		"Created playlist"
+//	@Failure		400		{object}	models.Message				"Invalid playlist"
+//	@Failure		401		{object}	models.Message				"Not authenticated"
+//	@Failure		502		{object}	models.Message				"Internal server error"
+//	@Router			/playlists [post]
+func (h *Handler) CreatePlaylist(c *gin.Context) {
+	user, _ := currentUser(c)
+	var pc models.PlaylistCreate
+	if err := c.ShouldBind(&pc); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	p, err := h.songsRepo.CreatePlaylist(c.Request.Context(), user.Id, &pc)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, models.PlaylistResponse{Ok: true, Data: p})
+}
+
+// ListMyPlaylists godoc
+//
+//	@Summary		List my playlists
+//	@Description	Paginate the playlists of the authenticated user, most recently changed first.
+//	@Tags			Playlists
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			page	query		int						false	"Page (starts with 0)"
+//	@Param			max		query		int						false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.ListPlaylists	"Playlists with pagination details"
+//	@Failure		401		{object}	models.Message			"Not authenticated"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/me/playlists [get]
+func (h *Handler) ListMyPlaylists(c *gin.Context) {
+	user, _ := currentUser(c)
+	pmq := models.NewPageMaxQuery()
+	c.Bind(&pmq)
+	playlists, amount, err := h.songsRepo.ListPlaylists(c.Request.Context(), user.Id, &pmq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if playlists == nil {
+		playlists = []models.Playlist{}
+	}
+	c.JSON(http.StatusOK, models.ListPlaylists{
+		Ok:     true,
+		Data:   playlists,
+		Page:   pmq.Page,
+		Next:   pmq.Max*(pmq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// GetPlaylist godoc
+//
+//	@Summary		Get a playlist
+//	@Description	Public playlists are visible to everyone, private ones only to their owner.
+//	@Tags			Playlists
+//	@Produce		json
+//	@Param			id	path		int							true	"Playlist ID"
+//	@Success		200	{object}	models.PlaylistResponse		"Playlist"
+//	@Failure		400	{object}	models.Message				"Invalid playlist ID"
+//	@Failure		404	{object}	models.Message				"Playlist not found"
+//	@Failure		502	{object}	models.Message				"Internal server error"
+//	@Router			/playlists/{id} [get]
+func (h *Handler) GetPlaylist(c *gin.Context) {
+	p, ok := h.playlistFor(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, models.PlaylistResponse{Ok: true, Data: p})
+}
+
+// UpdatePlaylist godoc
+//
+//	@Summary		Update a playlist
+//	@Description	Rename a playlist or change its visibility.
+//	@Tags			Playlists
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int							true	"Playlist ID"
+//	@Param			body	body		models.PlaylistUpdate		true	"Fields to update"
+//	@Success		200		{object}	models.PlaylistResponse		"Updated playlist"
+//	@Failure		400		{object}	models.Message				"Invalid playlist ID or fields"
+//	@Failure		401		{object}	models.Message				"Not authenticated"
+//	@Failure		403		{object}	models.Message				"Not the owner"
+//	@Failure		404		{object}	models.Message				"Playlist not found"
+//	@Failure		502		{object}	models.Message				"Internal server error"
+//	@Router			/playlists/{id} [patch]
+func (h *Handler) UpdatePlaylist(c *gin.Context) {
+	p, ok := h.playlistFor(c, true)
+	if !ok {
+		return
+	}
+	var pu models.PlaylistUpdate
+	if err := c.ShouldBind(&pu); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	p, err := h.songsRepo.UpdatePlaylist(c.Request.Context(), p.Id, &pu)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.PlaylistResponse{Ok: true, Data: p})
+}
+
+// DeletePlaylist godoc
+//
+//	@Summary		Delete a playlist
+//	@Tags			Playlists
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Playlist ID"
+//	@Success		200	{object}	models.Message	"Playlist deleted"
+//	@Failure		400	{object}	models.Message	"Invalid playlist ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		403	{object}	models.Message	"Not the owner"
+//	@Failure		404	{object}	models.Message	"Playlist not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/playlists/{id} [delete]
+func (h *Handler) DeletePlaylist(c *gin.Context) {
+	p, ok := h.playlistFor(c, true)
+	if !ok {
+		return
+	}
+	if err := h.songsRepo.DeletePlaylist(c.Request.Context(), p.Id); err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "deleted"})
+}
+
+// ListPlaylistEntries godoc
+//
+//	@Summary		Playlist contents
+//	@Description	Paginate the entries of a playlist in order.
+//	@Tags			Playlists
+//	@Produce		json
+//	@Param			id		path		int							true	"Playlist ID"
+//	@Param			page	query		int							false	"Page (starts with 0)"
+//	@Param			max		query		int							false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.ListPlaylistEntries	"Entries with pagination details"
+//	@Failure		400		{object}	models.Message				"Invalid playlist ID"
+//	@Failure		404		{object}	models.Message				"Playlist not found"
+//	@Failure		502		{object}	models.Message				"Internal server error"
+//	@Router			/playlists/{id}/entries [get]
+func (h *Handler) ListPlaylistEntries(c *gin.Context) {
+	p, ok := h.playlistFor(c, false)
+	if !ok {
+		return
+	}
+	pmq := models.NewPageMaxQuery()
+	c.Bind(&pmq)
+	entries, amount, err := h.songsRepo.GetPlaylistEntries(c.Request.Context(), p.Id, &pmq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []models.PlaylistEntry{}
+	}
+	c.JSON(http.StatusOK, models.ListPlaylistEntries{
+		Ok:     true,
+		Data:   entries,
+		Page:   pmq.Page,
+		Next:   pmq.Max*(pmq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// AddPlaylistEntry godoc
+//
+//	@Summary		Add a song to a playlist
+//	@Description	Insert a song at a position, moving later entries down, or append it. A song can be added several times.
+//	@Tags			Playlists
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int								true	"Playlist ID"
+//	@Param			body	body		models.PlaylistEntryAdd			true	"Song and position"
+//	@Success		201		{object}	models.PlaylistEntryResponse	"Added entry"
+//	@Failure		400		{object}	models.Message					"Invalid playlist ID or body"
+//	@Failure		401		{object}	models.Message					"Not authenticated"
+//	@Failure		403		{object}	models.Message					"Not the owner"
+//	@Failure		404		{object}	models.Message					"Playlist or song not found"
+//	@Failure		502		{object}	models.Message					"Internal server error"
+//	@Router			/playlists/{id}/entries [post]
+func (h *Handler) AddPlaylistEntry(c *gin.Context) {
+	p, ok := h.playlistFor(c, true)
+	if !ok {
+		return
+	}
+	var pea models.PlaylistEntryAdd
+	if err := c.ShouldBind(&pea); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), pea.SongId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "song not found"))
+		return
+	}
+	entry, err := h.songsRepo.AddPlaylistEntry(c.Request.Context(), p.Id, &pea)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, models.PlaylistEntryResponse{Ok: true, Data: entry})
+}
+
+// RemovePlaylistEntry godoc
+//
+//	@Summary		Remove an entry from a playlist
+//	@Description	Later entries move up to close the gap.
+//	@Tags			Playlists
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int				true	"Playlist ID"
+//	@Param			entryId	path		int				true	"Entry ID"
+//	@Success		200		{object}	models.Message	"Entry removed"
+//	@Failure		400		{object}	models.Message	"Invalid ID"
+//	@Failure		401		{object}	models.Message	"Not authenticated"
+//	@Failure		403		{object}	models.Message	"Not the owner"
+//	@Failure		404		{object}	models.Message	"Playlist or entry not found"
+//	@Failure		502		{object}	models.Message	"Internal server error"
+//	@Router			/playlists/{id}/entries/{entryId} [delete]
+func (h *Handler) RemovePlaylistEntry(c *gin.Context) {
+	p, ok := h.playlistFor(c, true)
+	if !ok {
+		return
+	}
+	entryId, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	err = h.songsRepo.RemovePlaylistEntry(c.Request.Context(), p.Id, entryId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "removed"})
+}
+
+// ReorderPlaylist godoc
+//
+//	@Summary		Reorder a playlist
+//	@Description	Set the order of the entries; the body must list every entry id of the playlist exactly once.
+//	@Tags			Playlists
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int						true	"Playlist ID"
+//	@Param			body	body		models.PlaylistOrder	true	"Entry ids in the new order"
+//	@Success		200		{object}	models.Message			"Playlist reordered"
+//	@Failure		400		{object}	models.Message			"Invalid playlist ID or order"
+//	@Failure		401		{object}	models.Message			"Not authenticated"
+//	@Failure		403		{object}	models.Message			"Not the owner"
+//	@Failure		404		{object}	models.Message			"Playlist not found"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/playlists/{id}/order [put]
+func (h *Handler) ReorderPlaylist(c *gin.Context) {
+	p, ok := h.playlistFor(c, true)
+	if !ok {
+		return
+	}
+	var po models.PlaylistOrder
+	if err := c.ShouldBind(&po); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	err := h.songsRepo.ReorderPlaylist(c.Request.Context(), p.Id, po.EntryIds)
+	if errors.Is(err, postgresql.ErrInvalidOrder) {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "reordered"})
+}
+
+// exportPlaylist serves every entry of the playlist in a file format.
+func (h *Handler) exportPlaylist(c *gin.Context, ext, contentType string, format func(title string, entries []models.PlaylistEntry) ([]byte, error)) {
+	p, ok := h.playlistFor(c, false)
+	if !ok {
+		return
+	}
+	entries, err := h.songsRepo.ExportPlaylist(c.Request.Context(), p.Id)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	body, err := format(p.Title, entries)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "playlist-" + strconv.Itoa(p.Id) + ext,
+	}))
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// ExportPlaylistM3U8 godoc
+//
+//	@Summary		Export a playlist as M3U8
+//	@Description	Extended M3U in UTF-8 with the link of each song; songs without a link are left out.
+//	@Tags			Playlists
+//	@Produce		audio/x-mpegurl
+//	@Param			id	path		int				true	"Playlist ID"
+//	@Success		200	{string}	string			"M3U8 document"
+//	@Failure		400	{object}	models.Message	"Invalid playlist ID"
+//	@Failure		404	{object}	models.Message	"Playlist not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/playlists/{id}/export.m3u8 [get]
+func (h *Handler) ExportPlaylistM3U8(c *gin.Context) {
+	h.exportPlaylist(c, ".m3u8", "audio/x-mpegurl; charset=utf-8", func(title string, entries []models.PlaylistEntry) ([]byte, error) {
+		return []byte(playlist.M3U8(title, entries)), nil
+	})
+}
+
+// ExportPlaylistXSPF godoc
+//
+//	@Summary		Export a playlist as XSPF
+//	@Description	XML Shareable Playlist Format with the link of each song as its location.
+//	@Tags			Playlists
+//	@Produce		application/xspf+xml
+//	@Param			id	path		int				true	"Playlist ID"
+//	@Success		200	{string}	string			"XSPF document"
+//	@Failure		400	{object}	models.Message	"Invalid playlist ID"
+//	@Failure		404	{object}	models.Message	"Playlist not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/playlists/{id}/export.xspf [get]
+func (h *Handler) ExportPlaylistXSPF(c *gin.Context) {
+	h.exportPlaylist(c, ".xspf", "application/xspf+xml; charset=utf-8", playlist.XSPF)
+}
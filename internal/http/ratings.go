@@ -0,0 +1,161 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// RecordPlays godoc
+//
+//	@Summary		Record plays
+//	@Description	Record a batch of plays and add them to the play counts. Every play carries an event id chosen by the client;
+//	@Description	plays whose event id was already recorded are ignored, so a failed batch can be sent again.
+//	@Description	Plays of unknown songs are ignored too. Authenticated plays are attributed to the user.
+//	@Tags			Ratings
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.PlaysBatch		true	"Plays, at most 500"
+//	@Success		200		{object}	models.PlaysResponse	"Recorded and ignored plays"
+//	@Failure		400		{object}	models.Message			"Invalid body"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/plays [post]
+func (h *Handler) RecordPlays(c *gin.Context) {
+	var batch models.PlaysBatch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	var userId *int
+	if user, ok := currentUser(c); ok {
+		userId = &user.Id
+	}
+	res, err := h.songsRepo.RecordPlays(c.Request.Context(), userId, batch.Plays)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.PlaysResponse{Ok: true, Data: res})
+}
+
+// GetSongRating godoc
+//
+//	@Summary		Get song rating
+//	@Description	Returns the play count and average rating of a song. Authenticated requests also get their own stars.
+//	@Tags			Ratings
+//	@Produce		json
+//	@Param			id	path		int							true	"Song ID"
+//	@Success		200	{object}	models.SongRatingResponse	"Rating"
+//	@Failure		400	{object}	models.Message				"Invalid song ID"
+//	@Failure		404	{object}	models.Message				"Song not found"
+//	@Failure		502	{object}	models.Message				"Internal server error"
+//	@Router			/songs/{id}/rating [get]
+func (h *Handler) GetSongRating(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	var userId *int
+	if user, ok := currentUser(c); ok {
+		userId = &user.Id
+	}
+
+	rating, err := h.songsRepo.GetSongRating(c.Request.Context(), songId, userId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.SongRatingResponse{Ok: true, Data: rating})
+}
+
+// RateSong godoc
+//
+//	@Summary		Rate a song
+//	@Description	Give a song 1 to 5 stars, replacing an earlier rating by the authenticated user.
+//	@Tags			Ratings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int							true	"Song ID"
+//	@Param			body	body		models.RatingSet			true	"Stars"
+//	@Success		200		{object}	models.SongRatingResponse	"Updated rating"
+//	@Failure		400		{object}	models.Message				"Invalid song ID or body"
+//	@Failure		401		{object}	models.Message				"Not authenticated"
+//	@Failure		404		{object}	models.Message				"Song not found"
+//	@Failure		502		{object}	models.Message				"Internal server error"
+//	@Router			/songs/{id}/rating [put]
+func (h *Handler) RateSong(c *gin.Context) {
+	user, _ := currentUser(c)
+	var rs models.RatingSet
+	if err := c.ShouldBindJSON(&rs); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	songId, ok := h.existingSong(c)
+	if !ok {
+		return
+	}
+
+	if err := h.songsRepo.RateSong(c.Request.Context(), user.Id, songId, rs.Stars); err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	rating, err := h.songsRepo.GetSongRating(c.Request.Context(), songId, &user.Id)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.SongRatingResponse{Ok: true, Data: rating})
+}
+
+// DeleteRating godoc
+//
+//	@Summary		Remove a rating
+//	@Description	Remove the rating the authenticated user gave a song.
+//	@Tags			Ratings
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Song ID"
+//	@Success		200	{object}	models.Message	"Removed"
+//	@Failure		400	{object}	models.Message	"Invalid song ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		404	{object}	models.Message	"Song not found or not rated"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id}/rating [delete]
+func (h *Handler) DeleteRating(c *gin.Context) {
+	user, _ := currentUser(c)
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	err = h.songsRepo.DeleteRating(c.Request.Context(), user.Id, songId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "removed"})
+}
@@ -5,6 +5,12 @@ import (
 )
 
 func (h *Handler) Routes(group *gin.RouterGroup) {
+	group.Use(h.Authenticate)
+	group.POST("/auth/token", h.CreateToken)
+	group.POST("/plays", h.TransactionMiddleware, h.RecordPlays)
+	// Outside of the songs group: a stream must not hold a transaction open.
+	group.GET("/songs/events", h.StreamSongEvents)
+
 	songs := group.Group("/songs")
 	songs.Use(h.TransactionMiddleware)
 	{
@@ -12,6 +18,91 @@ func (h *Handler) Routes(group *gin.RouterGroup) {
 		songs.POST("", h.CreateSong)
 		songs.GET("/:id/text", h.GetSongText)
 		songs.PATCH("/:id", h.UpdateSong)
+		songs.DELETE("/:id", h.DeleteSong)
+		songs.POST("/:id/enrich", h.EnrichSong)
 		songs.GET("/info", h.GetSongDetail)
+		songs.GET("/:id/lyrics.lrc", h.GetLyricsLRC)
+		songs.PUT("/:id/lyrics.lrc", h.PutLyricsLRC)
+		songs.GET("/:id/texts", h.ListSongTexts)
+		songs.PUT("/:id/texts/:lang", h.PutSongText)
+		songs.GET("/:id/stats", h.GetSongStats)
+		songs.GET("/:id/similar", h.ListSimilarSongs)
+		songs.PUT("/:id/favourite", h.RequireUser, h.AddFavourite)
+		songs.DELETE("/:id/favourite", h.RequireUser, h.RemoveFavourite)
+		songs.GET("/:id/rating", h.GetSongRating)
+		songs.PUT("/:id/rating", h.RequireUser, h.RateSong)
+		songs.DELETE("/:id/rating", h.RequireUser, h.DeleteRating)
+	}
+
+	me := group.Group("/me")
+	me.Use(h.RequireUser, h.TransactionMiddleware)
+	{
+		me.GET("/favourites", h.ListFavourites)
+		me.GET("/playlists", h.ListMyPlaylists)
+	}
+
+	playlists := group.Group("/playlists")
+	playlists.Use(h.TransactionMiddleware)
+	{
+		playlists.POST("", h.RequireUser, h.CreatePlaylist)
+		playlists.GET("/:id", h.GetPlaylist)
+		playlists.PATCH("/:id", h.RequireUser, h.UpdatePlaylist)
+		playlists.DELETE("/:id", h.RequireUser, h.DeletePlaylist)
+		playlists.GET("/:id/entries", h.ListPlaylistEntries)
+		playlists.POST("/:id/entries", h.RequireUser, h.AddPlaylistEntry)
+		playlists.DELETE("/:id/entries/:entryId", h.RequireUser, h.RemovePlaylistEntry)
+		playlists.PUT("/:id/order", h.RequireUser, h.ReorderPlaylist)
+		playlists.GET("/:id/export.m3u8", h.ExportPlaylistM3U8)
+		playlists.GET("/:id/export.xspf", h.ExportPlaylistXSPF)
+	}
+
+	webhooks := group.Group("/webhooks")
+	webhooks.Use(h.RequireAdmin, h.TransactionMiddleware)
+	{
+		webhooks.POST("", h.CreateWebhook)
+		webhooks.GET("", h.ListWebhooks)
+		webhooks.GET("/dead-letters", h.ListDeadLetters)
+		webhooks.POST("/deliveries/:deliveryId/retry", h.RetryDelivery)
+		webhooks.GET("/:id", h.GetWebhook)
+		webhooks.PATCH("/:id", h.UpdateWebhook)
+		webhooks.DELETE("/:id", h.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+	}
+
+	admin := group.Group("/admin")
+	admin.Use(h.RequireAdmin, h.TransactionMiddleware)
+	{
+		admin.GET("/jobs", h.ListJobs)
+		admin.GET("/jobs/summary", h.JobsSummary)
+		admin.POST("/jobs/:id/retry", h.RetryJob)
+		admin.GET("/links/report", h.LinkReport)
+		admin.GET("/duplicates", h.ListDuplicates)
+		admin.POST("/songs/merge", h.MergeSongs)
+	}
+
+	groups := group.Group("/groups")
+	groups.Use(h.TransactionMiddleware)
+	{
+		groups.GET("/stats", h.ListGroupStats)
+	}
+
+	tags := group.Group("/tags")
+	tags.Use(h.TransactionMiddleware)
+	{
+		tags.GET("", h.ListTags)
+		tags.POST("", h.CreateTag)
+		tags.GET("/counts", h.CountTags)
+		tags.GET("/:id", h.GetTag)
+		tags.PATCH("/:id", h.UpdateTag)
+		tags.DELETE("/:id", h.DeleteTag)
+	}
+
+	analytics := group.Group("/analytics")
+	analytics.Use(h.TransactionMiddleware)
+	{
+		analytics.GET("/groups", h.SongsPerGroup)
+		analytics.GET("/top-groups", h.TopGroups)
+		analytics.GET("/releases", h.Releases)
+		analytics.GET("/recent", h.RecentSongs)
 	}
 }
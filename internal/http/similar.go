@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// ListSimilarSongs godoc
+//
+//	@Summary		Similar songs
+//	@Description	Songs ranked by similarity to a song: lyrics compared by tf-idf cosine similarity (60%),
+//	@Description	shared tags by Jaccard index (30%) and the same group (10%). Only precomputed rankings are
+//	@Description	served: a song without one, such as one edited since, has none until the background refresh
+//	@Description	it queues has run.
+//	@Tags			Songs
+//	@Produce		json
+//	@Param			id		path		int					true	"Song ID"
+//	@Param			limit	query		int					false	"Maximum songs (1-50, default 10)"
+//	@Success		200		{object}	models.SimilarSongs	"Similar songs, most similar first"
+//	@Failure		400		{object}	models.Message		"Invalid song ID or limit"
+//	@Failure		404		{object}	models.Message		"Song not found"
+//	@Failure		502		{object}	models.Message		"Internal server error"
+//	@Router			/songs/{id}/similar [get]
+func (h *Handler) ListSimilarSongs(c *gin.Context) {
+	sq := models.NewSimilarQuery()
+	if err := c.ShouldBindQuery(&sq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	songId, ok := h.existingSong(c)
+	if !ok {
+		return
+	}
+
+	songs, err := h.songsRepo.GetSimilarSongs(c.Request.Context(), songId, sq.Limit)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if songs == nil {
+		songs = []models.SimilarSong{}
+	}
+	c.JSON(http.StatusOK, models.SimilarSongs{Ok: true, Data: songs})
+}
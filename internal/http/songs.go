@@ -9,18 +9,26 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
 )
 
 // ListAllSongs godoc
 //
 //	@Summary		Show all songs
 //	@Description	Paginate all songs filtered by song name or/and group name.
+//	@Description	With facets, the response also counts the matching songs per group, release year or tag.
+//	@Description	Authenticated requests get an isFavourite flag on every song.
 //	@Tags			Songs
 //	@Produce		json
 //	@Param			page	query		int					false	"Page (starts with 0)"
 //	@Param			max		query		int					false	"Maximum elements (default 10)"
 //	@Param			group	query		string				false	"Group name"
 //	@Param			song	query		string				false	"Song name"
+//	@Param			tag		query		[]string			false	"Tag names"	collectionFormat(multi)
+//	@Param			tagMatch	query	string				false	"Whether songs need any or all of the tags"	Enums(any, all)	default(any)
+//	@Param			facets	query		string				false	"Comma separated facets to count over all matching songs: group, year, tag"
+//	@Param			sort	query		string				false	"Order by play count or average rating, highest first"	Enums(popularity, rating)
+//	@Param			broken	query		bool				false	"Only songs whose link was found broken (true) or not (false) by the last check"
 //	@Success		200		{object}	models.ListAllSongs	"List of songs with pagination details"
 //	@Failure		400		{object}	models.Message		"Bad request, invalid parameters"
 //	@Failure		404		{object}	models.Message		"Not found, no songs match the criteria or page is empty"
@@ -29,57 +37,135 @@ import (
 func (h *Handler) ListAllSongs(c *gin.Context) {
 	sq := models.NewSongsQuery()
 	c.Bind(&sq)
+	if !sq.ValidTagMatch() {
+		c.JSON(http.StatusBadRequest, errMessage(c, "tagMatch must be any or all"))
+		return
+	}
+	if !sq.ValidSort() {
+		c.JSON(http.StatusBadRequest, errMessage(c, "sort must be popularity or rating"))
+		return
+	}
+	facets, err := models.ParseFacets(sq.Facets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
 	songs, amount, err := h.songsRepo.GetSongs(c.Request.Context(), &sq)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{
-			Ok:  false,
-			Msg: "something went wrong",
-		})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !h.markFavourites(c, songs) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ListAllSongs{
+	res := models.ListAllSongs{
 		Ok:     true,
 		Data:   songs,
 		Page:   sq.Page,
 		Next:   sq.Max*(sq.Page+1) < amount,
 		Amount: amount,
-	})
+	}
+	if len(facets) > 0 {
+		// Same transaction as GetSongs, see TransactionMiddleware.
+		res.Facets, err = h.songsRepo.GetSongFacets(c.Request.Context(), &sq, facets)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(c.Request.Context()).Panic(err.Error())
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
 }
 
 // CreateSong godoc
 //
 //	@Summary		Create a new song
-//	@Description	Creates a new song in the database with the provided details.
+//	@Description	Creates a new song in the database with the provided details. Text and link are required unless
+//	@Description	enrichment is enabled: missing text, link or release date are then looked up in the music info service
+//	@Description	in the background, the response is 202 and the song's enrichmentStatus stays pending until the lookup
+//	@Description	is over.
 //	@Tags			Songs
 //	@Accept			json
 //	@Produce		json
-//	@Param			body	body		models.SongCreateQuery	true	"Song details"
-//	@Success		200		{object}	models.Message			"OK response with success message"
-//	@Failure		400		{object}	models.Message			"Bad request, invalid data"
-//	@Failure		500		{object}	models.Message			"Internal server error"
+//	@Param			body	body		models.SongCreateQuery		true	"Song details"
+//	@Success		201		{object}	models.SongCreatedResponse	"Song created"
+//	@Success		202		{object}	models.SongCreatedResponse	"Song created, details being looked up"
+//	@Failure		400		{object}	models.Message				"Bad request, invalid data"
+//	@Failure		500		{object}	models.Message				"Internal server error"
 //	@Router			/songs [post]
 func (h *Handler) CreateSong(c *gin.Context) {
 	var scq models.SongCreateQuery
 	if err := c.ShouldBind(&scq); err != nil {
-		c.JSON(http.StatusBadRequest, models.Message{
-			Ok:  false,
-			Msg: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if !h.enrichment && (scq.Text == "" || scq.Link == "") {
+		c.JSON(http.StatusBadRequest, errMessage(c, "text and link are required"))
+		return
+	}
+	songId, err := h.songsRepo.CreateSong(c.Request.Context(), &scq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	created := models.SongCreated{Id: songId, EnrichmentStatus: models.EnrichmentNone}
+	if fields := scq.MissingFields(); h.enrichment && len(fields) > 0 {
+		if err := h.songsRepo.EnqueueEnrichment(c.Request.Context(), songId, fields); err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(c.Request.Context()).Panic(err.Error())
+			return
+		}
+		created.EnrichmentStatus = models.EnrichmentPending
+	}
+	h.invalidateStats(c, 0)
+	status := http.StatusCreated
+	if created.EnrichmentStatus == models.EnrichmentPending {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, models.SongCreatedResponse{Ok: true, Data: created})
+}
+
+// EnrichSong godoc
+//
+//	@Summary		Look a song up again
+//	@Description	Queue a lookup of the song in the music info service, replacing its text, link and release date
+//	@Description	with the ones found. Poll the song's enrichmentStatus for the outcome.
+//	@Tags			Songs
+//	@Produce		json
+//	@Param			id	path		int							true	"Song ID"
+//	@Success		202	{object}	models.SongCreatedResponse	"Lookup queued"
+//	@Failure		400	{object}	models.Message				"Invalid song ID"
+//	@Failure		404	{object}	models.Message				"Song not found"
+//	@Failure		502	{object}	models.Message				"Internal server error"
+//	@Failure		503	{object}	models.Message				"Enrichment is disabled"
+//	@Router			/songs/{id}/enrich [post]
+func (h *Handler) EnrichSong(c *gin.Context) {
+	if !h.enrichment {
+		c.JSON(http.StatusServiceUnavailable, errMessage(c, "enrichment is disabled"))
+		return
+	}
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	err = h.songsRepo.EnqueueEnrichment(c.Request.Context(), songId, models.EnrichFields)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
 		return
 	}
-	if err := h.songsRepo.CreateSong(c.Request.Context(), &scq); err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{
-			Ok:  false,
-			Msg: "something went wrong",
-		})
-		log.Panic(err.Error())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
 		return
 	}
-	c.JSON(http.StatusCreated, models.Message{
-		Ok:  true,
-		Msg: "created",
+	c.JSON(http.StatusAccepted, models.SongCreatedResponse{
+		Ok:   true,
+		Data: models.SongCreated{Id: songId, EnrichmentStatus: models.EnrichmentPending},
 	})
 }
 
@@ -91,6 +177,7 @@ func (h *Handler) CreateSong(c *gin.Context) {
 //	@Produce		json
 //	@Param			group	query		string				true	"Group name"
 //	@Param			song	query		string				true	"Song name"
+//	@Param			lang	query		string				false	"Language of the text; defaults to the best Accept-Language match, then the original"
 //	@Success		200		{object}	models.SongDetail	"Song details"
 //	@Failure		404		{object}	models.Message		"Song not found"
 //	@Failure		500		{object}	models.Message		"Internal server error"
@@ -100,14 +187,32 @@ func (h *Handler) GetSongDetail(c *gin.Context) {
 	c.Bind(&sdq)
 	sd, err := h.songsRepo.GetSong(c.Request.Context(), &sdq)
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.Message{Ok: false, Msg: "not found"})
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: "something went wrong"})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+
+	lang, translated, err := h.resolveLang(c, sd.Id)
+	if langError(c, err) {
 		return
 	}
+	if translated {
+		text, err := h.songsRepo.GetTranslation(c.Request.Context(), sd.Id, lang)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(c.Request.Context()).Panic(err.Error())
+			return
+		}
+		sd.Text = truncateText(text)
+	}
+	sd.Lang = lang
+	if lang != "" {
+		c.Header("Content-Language", lang)
+	}
 	c.JSON(http.StatusOK, sd)
 }
 
@@ -130,28 +235,29 @@ func (h *Handler) UpdateSong(c *gin.Context) {
 
 	songId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: err.Error()})
+		c.JSON(http.StatusBadGateway, errMessage(c, err.Error()))
 		return
 	}
 	c.Bind(&su)
 
 	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: "something went wrong"})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
 		return
 	}
 	if !exists {
-		c.JSON(http.StatusNotFound, models.Message{Ok: false, Msg: "not found"})
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
 		return
 	}
 
 	err = h.songsRepo.UpdateSong(c.Request.Context(), &su, songId)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: "something went wrong"})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
 		return
 	}
+	h.invalidateStats(c, songId)
 	c.JSON(
 		http.StatusOK,
 		models.Message{
@@ -159,7 +265,40 @@ func (h *Handler) UpdateSong(c *gin.Context) {
 			Msg: "updated",
 		},
 	)
-	log.Debug("Songs updated", &su)
+	utils.Log(c.Request.Context()).WithFields(log.Fields{"songId": songId, "fields": su.Fields()}).Debug("Songs updated")
+}
+
+// DeleteSong godoc
+//
+//	@Summary		Delete a song
+//	@Description	Delete a song with its texts, tags, ratings and playlist entries.
+//	@Tags			Songs
+//	@Produce		json
+//	@Param			id	path		int				true	"Song ID"
+//	@Success		200	{object}	models.Message	"Song deleted"
+//	@Failure		400	{object}	models.Message	"Invalid song ID"
+//	@Failure		404	{object}	models.Message	"Song not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/songs/{id} [delete]
+func (h *Handler) DeleteSong(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	err = h.songsRepo.DeleteSong(c.Request.Context(), songId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	h.invalidateStats(c, songId)
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "deleted"})
 }
 
 // GetSongText godoc
@@ -170,15 +309,19 @@ func (h *Handler) UpdateSong(c *gin.Context) {
 //	@Produce		json
 //	@Param			id		path		int					true	"Song ID"
 //	@Param			page	query		int					false	"Page number for pagination"
-//	@Param			max		query		int					false	"Maximum number of items per page"
-//	@Success		200		{object}	models.SongsText	"Successful response containing song text"
-//	@Failure		404		{object}	models.Message		"Song not found"
-//	@Failure		502		{object}	models.Message		"Internal error or invalid input"
+//	@Param			max			query		int					false	"Maximum number of items per page"
+//	@Param			timestamps	query		bool				false	"Return lines as objects with their LRC timestamps (models.SongLines)"
+//	@Param			lang		query		string				false	"Language of the text; defaults to the best Accept-Language match, then the original"
+//	@Param			parallel	query		bool				false	"With a translation, return original and translated lines side by side (models.ParallelText)"
+//	@Success		200			{object}	models.SongsText	"Successful response containing song text"
+//	@Failure		400			{object}	models.Message		"Invalid language"
+//	@Failure		404			{object}	models.Message		"Song or translation not found"
+//	@Failure		502			{object}	models.Message		"Internal error or invalid input"
 //	@Router			/songs/{id}/text [get]
 func (h *Handler) GetSongText(c *gin.Context) {
 	songId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: err.Error()})
+		c.JSON(http.StatusBadGateway, errMessage(c, err.Error()))
 		return
 	}
 
@@ -187,24 +330,41 @@ func (h *Handler) GetSongText(c *gin.Context) {
 
 	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: "something went wrong"})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
 		return
 	}
 	if !exists {
-		c.JSON(http.StatusNotFound, models.Message{Ok: false, Msg: "not found"})
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+
+	lang, translated, err := h.resolveLang(c, songId)
+	if langError(c, err) {
+		return
+	}
+	if translated {
+		h.getTranslatedText(c, songId, lang, &pmq)
+		return
+	}
+	if lang != "" {
+		c.Header("Content-Language", lang)
+	}
+
+	if withTimestamps, _ := strconv.ParseBool(c.Query("timestamps")); withTimestamps {
+		h.getSongLines(c, songId, &pmq)
 		return
 	}
 
 	songText, amount, err := h.songsRepo.GetSongText(c.Request.Context(), songId, &pmq)
 
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.Message{Ok: false, Msg: "not found"})
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.Message{Ok: false, Msg: "something went wrong"})
-		log.Panic(err.Error())
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, models.SongsText{
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// maxTopWords caps the top query parameter of the stats endpoints.
+const maxTopWords = 100
+
+type groupStatsKey struct {
+	page, max, top int
+	group          string
+	filtered       bool
+}
+
+// invalidateStats drops cached statistics that depend on the text of
+// songId once the current transaction commits. A zero songId only drops
+// the group aggregates.
+func (h *Handler) invalidateStats(c *gin.Context, songId int) {
+	afterCommit(c, func() { h.InvalidateStats(songId) })
+}
+
+// InvalidateStats drops cached statistics that depend on the text of
+// songId, for changes made outside of requests, such as by jobs, once
+// they are committed. A zero songId only drops the group aggregates.
+func (h Handler) InvalidateStats(songId int) {
+	if songId != 0 {
+		h.songStats.Delete(songId)
+	}
+	h.groupStats.Clear()
+}
+
+func withTop(st models.SongStats, top int) models.SongStats {
+	if len(st.TopWords) > top {
+		st.TopWords = st.TopWords[:top]
+	}
+	return st
+}
+
+func topWords(c *gin.Context, top int) (int, bool) {
+	if top < 0 || top > maxTopWords {
+		c.JSON(http.StatusBadRequest, errMessage(c, "top must be between 0 and "+strconv.Itoa(maxTopWords)))
+		return 0, false
+	}
+	return top, true
+}
+
+// GetSongStats godoc
+//
+//	@Summary		Lyrics statistics of a song
+//	@Description	Line, verse and word counts of the original text, with its most frequent words. Verses are separated by empty lines.
+//	@Tags			Stats
+//	@Produce		json
+//	@Param			id	path		int							true	"Song ID"
+//	@Param			top	query		int							false	"Number of most frequent words (default 10, max 100)"
+//	@Success		200	{object}	models.SongStatsResponse	"Song statistics"
+//	@Failure		400	{object}	models.Message				"Invalid song ID or top"
+//	@Failure		404	{object}	models.Message				"Song not found"
+//	@Failure		502	{object}	models.Message				"Internal server error"
+//	@Router			/songs/{id}/stats [get]
+func (h *Handler) GetSongStats(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	sq := models.NewStatsQuery()
+	c.Bind(&sq)
+	top, ok := topWords(c, sq.Top)
+	if !ok {
+		return
+	}
+
+	// The cache holds the longest word list; shorter ones are its prefixes.
+	if st, ok := h.songStats.Get(songId); ok {
+		c.JSON(http.StatusOK, models.SongStatsResponse{Ok: true, Data: withTop(st, top)})
+		return
+	}
+
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+
+	st, err := h.songsRepo.GetSongStats(c.Request.Context(), songId, maxTopWords)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	h.songStats.Set(songId, st)
+	c.JSON(http.StatusOK, models.SongStatsResponse{Ok: true, Data: withTop(st, top)})
+}
+
+// ListGroupStats godoc
+//
+//	@Summary		Lyrics statistics per group
+//	@Description	Aggregated line, verse and word counts over the songs of each group, ordered by group name. Songs without a group are not counted.
+//	@Tags			Stats
+//	@Produce		json
+//	@Param			page	query		int						false	"Page (starts with 0)"
+//	@Param			max		query		int						false	"Maximum elements (default 10)"
+//	@Param			group	query		string					false	"Group name"
+//	@Param			top		query		int						false	"Number of most frequent words per group (default 10, max 100)"
+//	@Success		200		{object}	models.ListGroupStats	"Group statistics with pagination details"
+//	@Failure		400		{object}	models.Message			"Invalid parameters"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/groups/stats [get]
+func (h *Handler) ListGroupStats(c *gin.Context) {
+	sq := models.NewStatsQuery()
+	c.Bind(&sq)
+	if _, ok := topWords(c, sq.Top); !ok {
+		return
+	}
+	if sq.Page < 0 || sq.Max < 1 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "invalid page or max"))
+		return
+	}
+
+	key := groupStatsKey{page: sq.Page, max: sq.Max, top: sq.Top}
+	if sq.Group != nil {
+		key.group, key.filtered = *sq.Group, true
+	}
+	if res, ok := h.groupStats.Get(key); ok {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	stats, amount, err := h.songsRepo.GetGroupStats(c.Request.Context(), &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if stats == nil {
+		stats = []models.GroupStats{}
+	}
+	res := models.ListGroupStats{
+		Ok:     true,
+		Data:   stats,
+		Page:   sq.Page,
+		Next:   sq.Max*(sq.Page+1) < amount,
+		Amount: amount,
+	}
+	h.groupStats.Set(key, res)
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// ListTags godoc
+//
+//	@Summary		List tags
+//	@Description	All tags ordered by name, optionally only those of one kind.
+//	@Tags			Tags
+//	@Produce		json
+//	@Param			kind	query		string			false	"Tag kind"	Enums(tag, genre)
+//	@Success		200		{object}	models.ListTags	"Tags"
+//	@Failure		400		{object}	models.Message	"Invalid kind"
+//	@Failure		502		{object}	models.Message	"Internal server error"
+//	@Router			/tags [get]
+func (h *Handler) ListTags(c *gin.Context) {
+	var tq models.TagsQuery
+	if err := c.ShouldBindQuery(&tq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	tags, err := h.songsRepo.ListTags(c.Request.Context(), &tq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	c.JSON(http.StatusOK, models.ListTags{Ok: true, Data: tags})
+}
+
+// CountTags godoc
+//
+//	@Summary		Tag counts
+//	@Description	Number of songs per tag among the songs matching the /songs filters, most used first, for faceted navigation.
+//	@Tags			Tags
+//	@Produce		json
+//	@Param			group		query		string				false	"Group name"
+//	@Param			song		query		string				false	"Song name"
+//	@Param			tag			query		[]string			false	"Tag names"	collectionFormat(multi)
+//	@Param			tagMatch	query		string				false	"Whether songs need any or all of the tags"	Enums(any, all)	default(any)
+//	@Success		200			{object}	models.TagCounts	"Tags with song counts"
+//	@Failure		400			{object}	models.Message		"Invalid parameters"
+//	@Failure		502			{object}	models.Message		"Internal server error"
+//	@Router			/tags/counts [get]
+func (h *Handler) CountTags(c *gin.Context) {
+	sq, ok := bindAnalyticsQuery(c)
+	if !ok {
+		return
+	}
+	counts, err := h.songsRepo.CountTags(c.Request.Context(), &sq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if counts == nil {
+		counts = []models.TagCount{}
+	}
+	c.JSON(http.StatusOK, models.TagCounts{Ok: true, Data: counts})
+}
+
+// GetTag godoc
+//
+//	@Summary		Get a tag
+//	@Tags			Tags
+//	@Produce		json
+//	@Param			id	path		int					true	"Tag ID"
+//	@Success		200	{object}	models.TagResponse	"Tag"
+//	@Failure		400	{object}	models.Message		"Invalid tag ID"
+//	@Failure		404	{object}	models.Message		"Tag not found"
+//	@Failure		502	{object}	models.Message		"Internal server error"
+//	@Router			/tags/{id} [get]
+func (h *Handler) GetTag(c *gin.Context) {
+	tagId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	tag, err := h.songsRepo.GetTag(c.Request.Context(), tagId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.TagResponse{Ok: true, Data: tag})
+}
+
+// CreateTag godoc
+//
+//	@Summary		Create a tag
+//	@Description	Names are stored trimmed and lower-cased. The kind defaults to tag.
+//	@Tags			Tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.TagCreate	true	"Tag"
+//	@Success		201		{object}	models.TagResponse	"Created tag"
+//	@Failure		400		{object}	models.Message		"Invalid tag"
+//	@Failure		409		{object}	models.Message		"Tag already exists"
+//	@Failure		502		{object}	models.Message		"Internal server error"
+//	@Router			/tags [post]
+func (h *Handler) CreateTag(c *gin.Context) {
+	var tc models.TagCreate
+	if err := c.ShouldBind(&tc); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if len(models.NormalizeTags([]string{tc.Name})) == 0 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "empty tag name"))
+		return
+	}
+	tag, err := h.songsRepo.CreateTag(c.Request.Context(), &tc)
+	if errors.Is(err, postgresql.ErrTagExists) {
+		c.JSON(http.StatusConflict, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, models.TagResponse{Ok: true, Data: tag})
+}
+
+// UpdateTag godoc
+//
+//	@Summary		Update a tag
+//	@Description	Rename a tag or change its kind; songs keep it, and each gets an update event.
+//	@Tags			Tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Tag ID"
+//	@Param			body	body		models.TagUpdate	true	"Fields to update"
+//	@Success		200		{object}	models.TagResponse	"Updated tag"
+//	@Failure		400		{object}	models.Message		"Invalid tag ID or fields"
+//	@Failure		404		{object}	models.Message		"Tag not found"
+//	@Failure		409		{object}	models.Message		"Tag name already taken"
+//	@Failure		502		{object}	models.Message		"Internal server error"
+//	@Router			/tags/{id} [patch]
+func (h *Handler) UpdateTag(c *gin.Context) {
+	tagId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	var tu models.TagUpdate
+	if err := c.ShouldBind(&tu); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if tu.Name != nil && len(models.NormalizeTags([]string{*tu.Name})) == 0 {
+		c.JSON(http.StatusBadRequest, errMessage(c, "empty tag name"))
+		return
+	}
+	tag, err := h.songsRepo.UpdateTag(c.Request.Context(), tagId, &tu)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if errors.Is(err, postgresql.ErrTagExists) {
+		c.JSON(http.StatusConflict, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.TagResponse{Ok: true, Data: tag})
+}
+
+// DeleteTag godoc
+//
+//	@Summary		Delete a tag
+//	@Description	Delete a tag and remove it from every song, each getting an update event.
+//	@Tags			Tags
+//	@Produce		json
+//	@Param			id	path		int				true	"Tag ID"
+//	@Success		200	{object}	models.Message	"Tag deleted"
+//	@Failure		400	{object}	models.Message	"Invalid tag ID"
+//	@Failure		404	{object}	models.Message	"Tag not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/tags/{id} [delete]
+func (h *Handler) DeleteTag(c *gin.Context) {
+	tagId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	err = h.songsRepo.DeleteTag(c.Request.Context(), tagId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "deleted"})
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+var (
+	errNoTranslation = errors.New("no text in the requested language")
+	errInvalidLang   = errors.New("invalid language tag")
+)
+
+// resolveLang picks the language to serve a song text in: the lang query
+// parameter if given, otherwise the best Accept-Language match among the
+// stored texts. translated is false when the original (songs.text) should
+// be served; lang is then the original language, if known. Without either
+// preference no query is made and lang is empty.
+func (h *Handler) resolveLang(c *gin.Context, songId int) (lang string, translated bool, err error) {
+	var wanted []language.Tag
+	explicit := c.Query("lang")
+	if explicit != "" {
+		tag, err := language.Parse(explicit)
+		if err != nil {
+			return "", false, fmt.Errorf("%w %q", errInvalidLang, explicit)
+		}
+		wanted = []language.Tag{tag}
+	} else if header := c.GetHeader("Accept-Language"); header != "" {
+		// Malformed headers are ignored rather than rejected.
+		wanted, _, _ = language.ParseAcceptLanguage(header)
+	}
+	if len(wanted) == 0 {
+		return "", false, nil
+	}
+
+	texts, err := h.songsRepo.ListSongTexts(c.Request.Context(), songId)
+	if err != nil {
+		return "", false, err
+	}
+	var original string
+	for _, t := range texts {
+		if t.Original {
+			original = t.Lang
+		}
+	}
+
+	for _, tag := range wanted {
+		if match := matchLang(tag, texts); match != nil {
+			return match.Lang, !match.Original, nil
+		}
+	}
+	if explicit != "" {
+		return "", false, errNoTranslation
+	}
+	return original, false, nil
+}
+
+// matchLang finds the stored text for tag, falling back from a regional tag
+// (pt-BR) to its base language (pt).
+func matchLang(tag language.Tag, texts []models.SongTextInfo) *models.SongTextInfo {
+	for i := range texts {
+		if strings.EqualFold(texts[i].Lang, tag.String()) {
+			return &texts[i]
+		}
+	}
+	base, _ := tag.Base()
+	for i := range texts {
+		stored, err := language.Parse(texts[i].Lang)
+		if err != nil {
+			continue
+		}
+		if b, _ := stored.Base(); b == base {
+			return &texts[i]
+		}
+	}
+	return nil
+}
+
+// detailTextLen matches the preview length of postgresql.GetSong.
+const detailTextLen = 1024
+
+func truncateText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= detailTextLen {
+		return text
+	}
+	return string(runes[:detailTextLen]) + "..."
+}
+
+// langError answers a resolveLang error and reports whether there was one.
+func langError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, errNoTranslation):
+		c.JSON(http.StatusNotFound, errMessage(c, err.Error()))
+	case errors.Is(err, errInvalidLang):
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+	default:
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+	}
+	return true
+}
+
+// ListSongTexts godoc
+//
+//	@Summary		List the languages of a song text
+//	@Tags			Translations
+//	@Produce		json
+//	@Param			id	path		int					true	"Song ID"
+//	@Success		200	{object}	models.SongTexts	"Stored languages, original first"
+//	@Failure		400	{object}	models.Message		"Invalid song ID"
+//	@Failure		404	{object}	models.Message		"Song not found"
+//	@Failure		502	{object}	models.Message		"Internal server error"
+//	@Router			/songs/{id}/texts [get]
+func (h *Handler) ListSongTexts(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	texts, err := h.songsRepo.ListSongTexts(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if texts == nil {
+		texts = []models.SongTextInfo{}
+	}
+	c.JSON(http.StatusOK, models.SongTexts{Ok: true, Data: texts})
+}
+
+// PutSongText godoc
+//
+//	@Summary		Add or update a song text in a language
+//	@Description	Stores a translation. With original set, the language becomes the original one and the text replaces the song text.
+//	@Description	The original can't be unmarked; mark another language original instead.
+//	@Tags			Translations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Song ID"
+//	@Param			lang	path		string					true	"BCP 47 language tag, e.g. en or pt-BR"
+//	@Param			body	body		models.SongTextUpsert	true	"Text"
+//	@Success		200		{object}	models.Message			"Text stored"
+//	@Failure		400		{object}	models.Message			"Invalid song ID, language or body"
+//	@Failure		404		{object}	models.Message			"Song not found"
+//	@Failure		409		{object}	models.Message			"Unmarking the original"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/songs/{id}/texts/{lang} [put]
+func (h *Handler) PutSongText(c *gin.Context) {
+	songId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	tag, err := language.Parse(c.Param("lang"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, fmt.Sprintf("%s %q", errInvalidLang, c.Param("lang"))))
+		return
+	}
+	var stu models.SongTextUpsert
+	if err := c.ShouldBindJSON(&stu); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+
+	exists, err := h.songsRepo.CheckIfExists(c.Request.Context(), songId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+
+	err = h.songsRepo.UpsertSongText(c.Request.Context(), songId, tag.String(), &stu)
+	if errors.Is(err, postgresql.ErrOriginalText) {
+		c.JSON(http.StatusConflict, errMessage(c, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	// The original text may have changed with it.
+	h.invalidateStats(c, songId)
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "updated"})
+}
+
+// getTranslatedText serves GetSongText for a translation, optionally side by
+// side with the original.
+func (h *Handler) getTranslatedText(c *gin.Context, songId int, lang string, pmq *models.PageMaxQuery) {
+	c.Header("Content-Language", lang)
+	if parallel, _ := strconv.ParseBool(c.Query("parallel")); parallel {
+		lines, amount, err := h.songsRepo.GetParallelLines(c.Request.Context(), songId, lang, pmq)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(c.Request.Context()).Panic(err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, models.ParallelText{
+			Data:   lines,
+			Page:   pmq.Page,
+			Ok:     true,
+			Amount: amount,
+			Next:   pmq.Max*(pmq.Page+1) < amount,
+		})
+		return
+	}
+
+	lines, amount, err := h.songsRepo.GetTranslationLines(c.Request.Context(), songId, lang, pmq)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.SongsText{
+		Data:   lines,
+		Page:   pmq.Page,
+		Ok:     true,
+		Amount: amount,
+		Next:   pmq.Max*(pmq.Page+1) < amount,
+	})
+}
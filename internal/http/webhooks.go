@@ -0,0 +1,319 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/webhooks"
+)
+
+// CreateWebhook godoc
+//
+//	@Summary		Subscribe a webhook
+//	@Description	Song events of the given types are POSTed to the URL once their transaction commits, signed in the
+//	@Description	X-Webhook-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">.
+//	@Description	Failed deliveries are retried with exponential backoff and dead-lettered after the last attempt.
+//	@Description	The secret is generated unless given and is only returned here.
+//	@Tags			Webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			body	body		models.WebhookCreate			true	"Subscription"
+//	@Success		201		{object}	models.WebhookCreatedResponse	"Created webhook with its secret"
+//	@Failure		400		{object}	models.Message					"Invalid body"
+//	@Failure		401		{object}	models.Message					"Not authenticated"
+//	@Failure		403		{object}	models.Message					"Not an administrator"
+//	@Failure		502		{object}	models.Message					"Internal server error"
+//	@Router			/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var wc models.WebhookCreate
+	if err := c.ShouldBindJSON(&wc); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if wc.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+			utils.Log(c.Request.Context()).Panic(err.Error())
+			return
+		}
+		wc.Secret = secret
+	}
+
+	w, err := h.songsRepo.CreateWebhook(c.Request.Context(), &wc)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, models.WebhookCreatedResponse{Ok: true, Data: models.WebhookCreated{Webhook: w, Secret: wc.Secret}})
+}
+
+// ListWebhooks godoc
+//
+//	@Summary		List webhooks
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.ListWebhooks	"Webhooks"
+//	@Failure		401	{object}	models.Message		"Not authenticated"
+//	@Failure		403	{object}	models.Message		"Not an administrator"
+//	@Failure		502	{object}	models.Message		"Internal server error"
+//	@Router			/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	hooks, err := h.songsRepo.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if hooks == nil {
+		hooks = []models.Webhook{}
+	}
+	c.JSON(http.StatusOK, models.ListWebhooks{Ok: true, Data: hooks})
+}
+
+// webhookId parses the id parameter. It reports false after responding
+// with an error.
+func webhookId(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return 0, false
+	}
+	return id, true
+}
+
+// GetWebhook godoc
+//
+//	@Summary		Get a webhook
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int						true	"Webhook ID"
+//	@Success		200	{object}	models.WebhookResponse	"Webhook"
+//	@Failure		400	{object}	models.Message			"Invalid webhook ID"
+//	@Failure		401	{object}	models.Message			"Not authenticated"
+//	@Failure		403	{object}	models.Message			"Not an administrator"
+//	@Failure		404	{object}	models.Message			"Webhook not found"
+//	@Failure		502	{object}	models.Message			"Internal server error"
+//	@Router			/webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	id, ok := webhookId(c)
+	if !ok {
+		return
+	}
+	w, err := h.songsRepo.GetWebhook(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.WebhookResponse{Ok: true, Data: w})
+}
+
+// UpdateWebhook godoc
+//
+//	@Summary		Update a webhook
+//	@Description	Change the URL or event types, or pause the webhook by deactivating it. Deliveries of an inactive
+//	@Description	webhook wait until it is activated again.
+//	@Tags			Webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int						true	"Webhook ID"
+//	@Param			body	body		models.WebhookUpdate	true	"Fields to change"
+//	@Success		200		{object}	models.WebhookResponse	"Updated webhook"
+//	@Failure		400		{object}	models.Message			"Invalid webhook ID or body"
+//	@Failure		401		{object}	models.Message			"Not authenticated"
+//	@Failure		403		{object}	models.Message			"Not an administrator"
+//	@Failure		404		{object}	models.Message			"Webhook not found"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/webhooks/{id} [patch]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookId(c)
+	if !ok {
+		return
+	}
+	var wu models.WebhookUpdate
+	if err := c.ShouldBindJSON(&wu); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	w, err := h.songsRepo.UpdateWebhook(c.Request.Context(), id, &wu)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.WebhookResponse{Ok: true, Data: w})
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary		Delete a webhook
+//	@Description	Delete a webhook with its deliveries.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Webhook ID"
+//	@Success		200	{object}	models.Message	"Deleted"
+//	@Failure		400	{object}	models.Message	"Invalid webhook ID"
+//	@Failure		401	{object}	models.Message	"Not authenticated"
+//	@Failure		403	{object}	models.Message	"Not an administrator"
+//	@Failure		404	{object}	models.Message	"Webhook not found"
+//	@Failure		502	{object}	models.Message	"Internal server error"
+//	@Router			/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookId(c)
+	if !ok {
+		return
+	}
+	err := h.songsRepo.DeleteWebhook(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "deleted"})
+}
+
+// listDeliveries serves ListWebhookDeliveries and ListDeadLetters.
+func (h *Handler) listDeliveries(c *gin.Context, dq *models.DeliveriesQuery) {
+	deliveries, amount, err := h.songsRepo.ListDeliveries(c.Request.Context(), dq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, models.ListDeliveries{
+		Ok:     true,
+		Data:   deliveries,
+		Page:   dq.Page,
+		Next:   dq.Max*(dq.Page+1) < amount,
+		Amount: amount,
+	})
+}
+
+// ListWebhookDeliveries godoc
+//
+//	@Summary		List deliveries of a webhook
+//	@Description	Paginate the deliveries of a webhook, newest first, with their attempts and last error.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			id		path		int						true	"Webhook ID"
+//	@Param			status	query		string					false	"Delivery status"	Enums(pending, delivered, dead)
+//	@Param			page	query		int						false	"Page (starts with 0)"
+//	@Param			max		query		int						false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.ListDeliveries	"Deliveries with pagination details"
+//	@Failure		400		{object}	models.Message			"Invalid webhook ID or status"
+//	@Failure		401		{object}	models.Message			"Not authenticated"
+//	@Failure		403		{object}	models.Message			"Not an administrator"
+//	@Failure		404		{object}	models.Message			"Webhook not found"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookId(c)
+	if !ok {
+		return
+	}
+	dq := models.DeliveriesQuery{PageMaxQuery: models.NewPageMaxQuery(), WebhookId: &id}
+	if err := c.ShouldBindQuery(&dq); err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	if _, err := h.songsRepo.GetWebhook(c.Request.Context(), id); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	h.listDeliveries(c, &dq)
+}
+
+// ListDeadLetters godoc
+//
+//	@Summary		List dead-lettered deliveries
+//	@Description	Paginate the deliveries of all webhooks that ran out of attempts, newest first.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			page	query		int						false	"Page (starts with 0)"
+//	@Param			max		query		int						false	"Maximum elements (default 10)"
+//	@Success		200		{object}	models.ListDeliveries	"Deliveries with pagination details"
+//	@Failure		401		{object}	models.Message			"Not authenticated"
+//	@Failure		403		{object}	models.Message			"Not an administrator"
+//	@Failure		502		{object}	models.Message			"Internal server error"
+//	@Router			/webhooks/dead-letters [get]
+func (h *Handler) ListDeadLetters(c *gin.Context) {
+	dq := models.DeliveriesQuery{PageMaxQuery: models.NewPageMaxQuery()}
+	c.Bind(&dq.PageMaxQuery)
+	dq.Status = models.DeliveryDead
+	h.listDeliveries(c, &dq)
+}
+
+// RetryDelivery godoc
+//
+//	@Summary		Retry a dead delivery
+//	@Description	Queue a dead-lettered delivery again with a fresh set of attempts.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Security		BasicAuth
+//	@Security		BearerAuth
+//	@Param			deliveryId	path		int				true	"Delivery ID"
+//	@Success		200			{object}	models.Message	"Queued"
+//	@Failure		400			{object}	models.Message	"Invalid delivery ID"
+//	@Failure		401			{object}	models.Message	"Not authenticated"
+//	@Failure		403			{object}	models.Message	"Not an administrator"
+//	@Failure		404			{object}	models.Message	"No dead delivery with this ID"
+//	@Failure		502			{object}	models.Message	"Internal server error"
+//	@Router			/webhooks/deliveries/{deliveryId}/retry [post]
+func (h *Handler) RetryDelivery(c *gin.Context) {
+	deliveryId, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errMessage(c, err.Error()))
+		return
+	}
+	err = h.songsRepo.RetryDelivery(c.Request.Context(), deliveryId)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, errMessage(c, "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, errMessage(c, "something went wrong"))
+		utils.Log(c.Request.Context()).Panic(err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, models.Message{Ok: true, Msg: "queued"})
+}
@@ -0,0 +1,265 @@
+// Package jobs runs background work queued in Postgres: jobs enqueued by
+// requests, in their transaction, and jobs scheduled to run periodically.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// KindPurge deletes finished jobs older than the configured retention.
+const KindPurge = "jobs.purge"
+
+// Handler runs a job. An error queues it again, unless it is Permanent or
+// the job ran out of attempts.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Store is the part of the repository the pool works with.
+type Store interface {
+	EnqueueJob(ctx context.Context, je *models.JobEnqueue) (int64, error)
+	ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]models.Job, error)
+	CompleteJob(ctx context.Context, jobId int64) error
+	FailJob(ctx context.Context, jobId int64, msg string, retryAt *time.Time) error
+	PurgeJobs(ctx context.Context, t time.Time) (int64, error)
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying won't fix, such as a malformed
+// payload; the job dies at once.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type lastAttemptKey struct{}
+
+// LastAttempt reports whether the job run with ctx dies if it fails, so that
+// its handler can record the failure.
+func LastAttempt(ctx context.Context) bool {
+	last, _ := ctx.Value(lastAttemptKey{}).(bool)
+	return last
+}
+
+type schedule struct {
+	kind     string
+	interval time.Duration
+}
+
+type Pool struct {
+	store     Store
+	cfg       config.JobsConfig
+	handlers  map[string]Handler
+	schedules []schedule
+	// Wakes Run after a scheduled job was enqueued.
+	poke chan struct{}
+}
+
+// NewPool returns a pool that purges old jobs if cfg.Retention is set.
+// Register the other kinds before Run.
+func NewPool(store Store, cfg config.JobsConfig) *Pool {
+	p := &Pool{store: store, cfg: cfg, handlers: make(map[string]Handler), poke: make(chan struct{}, 1)}
+	if cfg.Retention > 0 {
+		p.Register(KindPurge, p.purge)
+		p.Every(KindPurge, time.Hour)
+	}
+	return p
+}
+
+// Register makes the pool run jobs of kind with h.
+func (p *Pool) Register(kind string, h Handler) {
+	p.handlers[kind] = h
+}
+
+// Every enqueues a job of kind at every multiple of interval. Each run is
+// keyed by its time, so that servers sharing the database enqueue it once.
+func (p *Pool) Every(kind string, interval time.Duration) {
+	p.schedules = append(p.schedules, schedule{kind: kind, interval: interval})
+}
+
+// lease is how long a running job is hidden from other workers; longer than
+// a run, so that it is only taken over if its worker died.
+func (p *Pool) lease() time.Duration {
+	return p.cfg.Timeout + time.Minute
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func (p *Pool) Backoff(failures int) time.Duration {
+	wait := p.cfg.Backoff
+	for i := 1; i < failures && wait < p.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, p.cfg.BackoffMax)
+}
+
+func (p *Pool) kinds() []string {
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// Run runs due jobs on up to cfg.Concurrency workers until ctx is
+// cancelled, looking for them after every value from wake, whenever a
+// worker is free and at least every PollInterval. It returns once the
+// running jobs have stopped.
+func (p *Pool) Run(ctx context.Context, wake <-chan struct{}) {
+	for _, s := range p.schedules {
+		go p.schedule(ctx, s)
+	}
+
+	kinds := p.kinds()
+	slots := make(chan struct{}, p.cfg.Concurrency)
+	finished := make(chan struct{}, p.cfg.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		free := cap(slots) - len(slots)
+		claimed := 0
+		if free > 0 {
+			jobs, err := p.store.ClaimJobs(ctx, kinds, free, p.lease())
+			if err != nil && ctx.Err() == nil {
+				log.WithError(err).Error("claiming jobs")
+			}
+			for _, j := range jobs {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p.run(ctx, j)
+					<-slots
+					select {
+					case finished <- struct{}{}:
+					default:
+					}
+				}()
+			}
+			claimed = len(jobs)
+		}
+		// More may be due.
+		if free > 0 && claimed == free {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				wake = nil
+			}
+		case <-finished:
+		case <-p.poke:
+		case <-time.After(p.cfg.PollInterval):
+		}
+	}
+}
+
+// RunDue runs the jobs due now, one at a time, and returns how many ran. It
+// is meant for tests and commands that don't keep a pool running.
+func (p *Pool) RunDue(ctx context.Context) (int, error) {
+	n := 0
+	for {
+		jobs, err := p.store.ClaimJobs(ctx, p.kinds(), 1, p.lease())
+		if err != nil || len(jobs) == 0 {
+			return n, err
+		}
+		p.run(ctx, jobs[0])
+		n++
+	}
+}
+
+// run runs a claimed job and records its outcome. Outcomes are recorded
+// even if ctx was cancelled meanwhile, so that a job interrupted by a
+// shutdown is retried as soon as a server is back.
+func (p *Pool) run(ctx context.Context, j models.Job) {
+	logger := log.WithFields(log.Fields{"jobId": j.Id, "kind": j.Kind, "attempt": j.Attempts})
+	err := p.call(ctx, j)
+
+	record, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err == nil {
+		if err := p.store.CompleteJob(record, j.Id); err != nil {
+			logger.WithError(err).Error("recording job completion")
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	var permanent permanentError
+	switch {
+	case ctx.Err() != nil:
+		now := time.Now()
+		retryAt = &now
+	case errors.As(err, &permanent), j.Attempts >= p.cfg.MaxAttempts:
+	default:
+		at := time.Now().Add(p.Backoff(j.Attempts))
+		retryAt = &at
+	}
+	logger.WithError(err).WithField("dead", retryAt == nil).Warn("job failed")
+	if err := p.store.FailJob(record, j.Id, err.Error(), retryAt); err != nil {
+		logger.WithError(err).Error("recording job failure")
+	}
+}
+
+// call runs the handler of j with the configured timeout, turning a panic
+// into an error.
+func (p *Pool) call(ctx context.Context, j models.Job) (err error) {
+	h, ok := p.handlers[j.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for %q", j.Kind))
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, lastAttemptKey{}, j.Attempts >= p.cfg.MaxAttempts)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, j.Payload)
+}
+
+// schedule enqueues s at every multiple of its interval until ctx is
+// cancelled, starting with the current one.
+func (p *Pool) schedule(ctx context.Context, s schedule) {
+	for {
+		slot := time.Now().Truncate(s.interval)
+		je := models.JobEnqueue{Kind: s.kind, Key: s.kind + "@" + strconv.FormatInt(slot.Unix(), 10)}
+		id, err := p.store.EnqueueJob(ctx, &je)
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).WithField("kind", s.kind).Error("scheduling job")
+		}
+		if id != 0 {
+			select {
+			case p.poke <- struct{}{}:
+			default:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(slot.Add(s.interval))):
+		}
+	}
+}
+
+func (p *Pool) purge(ctx context.Context, _ json.RawMessage) error {
+	n, err := p.store.PurgeJobs(ctx, time.Now().Add(-p.cfg.Retention))
+	if err == nil && n > 0 {
+		log.WithField("jobs", n).Info("purged finished jobs")
+	}
+	return err
+}
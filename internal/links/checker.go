@@ -0,0 +1,174 @@
+// Package links finds broken song links in background jobs.
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+)
+
+const (
+	// batchSize bounds the links checked between two saves.
+	batchSize = 100
+	// maxRedirects bounds the redirects followed for one link.
+	maxRedirects = 5
+)
+
+var (
+	errScheme  = errors.New("only http and https links are checked")
+	errPrivate = errors.New("refusing a loopback, link-local or private address")
+)
+
+// Store is the part of the repository the checker works with.
+type Store interface {
+	LinksToCheck(ctx context.Context, t time.Time, limit int) ([]models.LinkTarget, error)
+	SaveLinkChecks(ctx context.Context, checks []models.LinkCheck) error
+}
+
+type Checker struct {
+	store  Store
+	cfg    config.LinksConfig
+	client *http.Client
+}
+
+func NewChecker(store Store, cfg config.LinksConfig) *Checker {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivate {
+		// Checked on the address actually dialed, which covers redirects and
+		// names resolving to private addresses. A proxy would dial for us.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{Control: refusePrivate}).DialContext
+	}
+	client := &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+	return &Checker{store: store, cfg: cfg, client: tracing.HTTPClient(client)}
+}
+
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := ap.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified() {
+		return errPrivate
+	}
+	return nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return errScheme
+	}
+	return nil
+}
+
+// Run is the jobs.Handler of models.JobCheckLinks. It checks the due links
+// batch by batch, saving every batch, and leaves the rest to the next run
+// when its deadline gets close.
+func (c *Checker) Run(ctx context.Context, _ json.RawMessage) error {
+	checked := 0
+	defer func() {
+		if checked > 0 {
+			log.WithField("links", checked).Info("checked song links")
+		}
+	}()
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < 2*c.cfg.Timeout {
+			return nil
+		}
+		targets, err := c.store.LinksToCheck(ctx, time.Now().Add(-c.cfg.Interval), batchSize)
+		if err != nil || len(targets) == 0 {
+			return err
+		}
+		checks := c.CheckAll(ctx, targets)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := c.store.SaveLinkChecks(ctx, checks); err != nil {
+			return err
+		}
+		checked += len(checks)
+	}
+}
+
+// CheckAll checks targets, cfg.Concurrency at a time.
+func (c *Checker) CheckAll(ctx context.Context, targets []models.LinkTarget) []models.LinkCheck {
+	checks := make([]models.LinkCheck, len(targets))
+	sem := make(chan struct{}, c.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, lt := range targets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checks[i] = c.Check(ctx, lt)
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+// Check requests the link with HEAD, or with GET if the server doesn't
+// allow HEAD, following up to maxRedirects redirects. Links without a
+// response or answered with an error status are broken, and so are links
+// that aren't http or https or lead to a private address.
+func (c *Checker) Check(ctx context.Context, lt models.LinkTarget) models.LinkCheck {
+	lc := models.LinkCheck{SongId: lt.SongId, URL: lt.URL}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	resp, err := c.request(ctx, http.MethodHead, lt.URL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = c.request(ctx, http.MethodGet, lt.URL)
+	}
+	if err != nil {
+		msg := err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			msg = "timed out"
+		}
+		lc.Error, lc.Broken = &msg, true
+		return lc
+	}
+	lc.StatusCode = &resp.StatusCode
+	lc.Broken = resp.StatusCode >= 400
+	if final := resp.Request.URL.String(); final != lt.URL {
+		lc.RedirectURL = &final
+	}
+	return lc
+}
+
+// request sends a request and closes the response, as only its status and
+// final URL matter.
+func (c *Checker) request(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, errScheme
+	}
+	req.Header.Set("User-Agent", "songs-api-link-checker")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
@@ -0,0 +1,189 @@
+// Package lrc reads and writes LRC lyrics, including the enhanced variant
+// with per-word <mm:ss.xx> tags.
+package lrc
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+var (
+	timeTag = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	wordTag = regexp.MustCompile(`<(\d+):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+	metaTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// Parse reads LRC text. A line with several time tags is repeated at each
+// time, and lines are ordered by time and numbered from 1. Timed lines
+// without text, which mark instrumental breaks, are left out, as the song
+// text has no line for them. Lines without a time tag, other than metadata
+// and blank lines, are an error.
+func Parse(src string) (models.SyncedLyrics, error) {
+	var res models.SyncedLyrics
+	offset := 0
+
+	sc := bufio.NewScanner(strings.NewReader(src))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var times []int
+		for {
+			m := timeTag.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			times = append(times, toMs(m[1], m[2], m[3]))
+			line = line[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			m := metaTag.FindStringSubmatch(line)
+			if m == nil {
+				return res, fmt.Errorf("line %d: missing time tag", n)
+			}
+			value := strings.TrimSpace(m[2])
+			switch strings.ToLower(m[1]) {
+			case "ti":
+				res.Title = value
+			case "ar":
+				res.Artist = value
+			case "offset":
+				o, err := strconv.Atoi(value)
+				if err != nil {
+					return res, fmt.Errorf("line %d: invalid offset %q", n, value)
+				}
+				offset = o
+			}
+			continue
+		}
+
+		text, words := parseWords(line)
+		if text == "" {
+			continue
+		}
+		for _, t := range times {
+			res.Lines = append(res.Lines, models.LyricLine{
+				Text:    text,
+				StartMs: &t,
+				Words:   words,
+			})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return res, err
+	}
+
+	sort.SliceStable(res.Lines, func(i, j int) bool {
+		return *res.Lines[i].StartMs < *res.Lines[j].StartMs
+	})
+	// A positive offset makes lyrics appear sooner.
+	for i := range res.Lines {
+		l := &res.Lines[i]
+		l.Number = i + 1
+		*l.StartMs = max(*l.StartMs-offset, 0)
+		if l.Words != nil {
+			words := make([]models.LyricWord, len(l.Words))
+			for j, w := range l.Words {
+				words[j] = models.LyricWord{StartMs: max(w.StartMs-offset, 0), Text: w.Text}
+			}
+			l.Words = words
+		}
+	}
+	return res, nil
+}
+
+// parseWords splits an enhanced line into words. Plain lines return their
+// text and nil.
+func parseWords(line string) (string, []models.LyricWord) {
+	tags := wordTag.FindAllStringSubmatchIndex(line, -1)
+	if tags == nil {
+		return line, nil
+	}
+	var (
+		words []models.LyricWord
+		text  []string
+	)
+	if lead := strings.TrimSpace(line[:tags[0][0]]); lead != "" {
+		text = append(text, lead)
+	}
+	for i, tag := range tags {
+		end := len(line)
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+		}
+		word := strings.TrimSpace(line[tag[1]:end])
+		if word == "" {
+			continue
+		}
+		ms := toMs(line[tag[2]:tag[3]], line[tag[4]:tag[5]], submatch(line, tag, 3))
+		words = append(words, models.LyricWord{StartMs: ms, Text: word})
+		text = append(text, word)
+	}
+	return strings.Join(text, " "), words
+}
+
+func submatch(s string, idx []int, n int) string {
+	if idx[2*n] < 0 {
+		return ""
+	}
+	return s[idx[2*n]:idx[2*n+1]]
+}
+
+func toMs(min, sec, frac string) int {
+	m, _ := strconv.Atoi(min)
+	s, _ := strconv.Atoi(sec)
+	ms := 0
+	if frac != "" {
+		f, _ := strconv.Atoi(frac)
+		switch len(frac) {
+		case 1:
+			ms = f * 100
+		case 2:
+			ms = f * 10
+		default:
+			ms = f
+		}
+	}
+	return (m*60+s)*1000 + ms
+}
+
+func stamp(ms int) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// Format writes the timed lines of l as LRC. With enhanced set, lines that
+// have word timings get <mm:ss.xx> tags. Untimed lines are left out.
+func Format(l models.SyncedLyrics, enhanced bool) string {
+	var b strings.Builder
+	if l.Title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", l.Title)
+	}
+	if l.Artist != "" {
+		fmt.Fprintf(&b, "[ar:%s]\n", l.Artist)
+	}
+	for _, line := range l.Lines {
+		if line.StartMs == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "[%s]", stamp(*line.StartMs))
+		if enhanced && len(line.Words) > 0 {
+			for _, w := range line.Words {
+				fmt.Fprintf(&b, " <%s> %s", stamp(w.StartMs), w.Text)
+			}
+		} else {
+			b.WriteString(line.Text)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
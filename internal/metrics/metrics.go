@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "songs"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Duration of repository methods.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "error"})
+
+	transactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_total",
+		Help:      "Finished transactions by outcome (commit or rollback).",
+	}, []string{"outcome"})
+)
+
+// Middleware records request count and latency. Requests that did not match
+// any route are labelled with an empty route to keep cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := strconv.Itoa(c.Writer.Status())
+		route := c.FullPath()
+		httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler exposes the registered metrics in Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// ObserveQuery is meant to be deferred at the top of a repository method:
+//
+//	defer metrics.ObserveQuery("GetSongs", time.Now(), &err)
+func ObserveQuery(method string, start time.Time, err *error) {
+	failed := "false"
+	if err != nil && *err != nil {
+		failed = "true"
+	}
+	queryDuration.WithLabelValues(method, failed).Observe(time.Since(start).Seconds())
+}
+
+func Commit() {
+	transactions.WithLabelValues("commit").Inc()
+}
+
+func Rollback() {
+	transactions.WithLabelValues("rollback").Inc()
+}
+
+// RegisterDB exports sql.DB pool statistics.
+func RegisterDB(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
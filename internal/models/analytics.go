@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type GroupCount struct {
+	Group string `json:"group"`
+	Songs int    `json:"songs"`
+}
+
+// ReleaseBucket counts the songs released in the year or month starting at
+// Start. Period is "2006" or "2006-01" depending on the interval.
+type ReleaseBucket struct {
+	Period string    `json:"period"`
+	Start  time.Time `json:"start"`
+	Songs  int       `json:"songs"`
+}
+
+type RecentSong struct {
+	Song
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// Release intervals accepted by the releases analytics.
+const (
+	IntervalYear  = "year"
+	IntervalMonth = "month"
+)
+
+type ListGroupCounts = Paginator[[]GroupCount]
+type GroupCounts = Data[[]GroupCount]
+type ReleaseBuckets = Data[[]ReleaseBucket]
+type RecentSongs = Data[[]RecentSong]
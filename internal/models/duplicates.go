@@ -0,0 +1,41 @@
+package models
+
+type DuplicatesQuery struct {
+	PageMaxQuery
+	// Songs of the same group with different titles are duplicates if their
+	// lyrics are at least this similar.
+	MinLyrics float64 `form:"minLyrics" binding:"min=0,max=1"`
+}
+
+func NewDuplicatesQuery() DuplicatesQuery {
+	return DuplicatesQuery{PageMaxQuery: NewPageMaxQuery(), MinLyrics: 0.8}
+}
+
+// DuplicatePair is two songs of the same group, compared ignoring case,
+// whitespace and punctuation, with the same title or similar lyrics. Song
+// is the older one.
+type DuplicatePair struct {
+	Song      Song `json:"song"`
+	Duplicate Song `json:"duplicate"`
+	SameTitle bool `json:"sameTitle"`
+	// Jaccard index of the lyrics word sets, 0 to 1.
+	Lyrics float64 `json:"lyrics"`
+}
+
+type ListDuplicates = Paginator[[]DuplicatePair]
+
+// SongMerge folds the sources into the target, which keeps its id.
+type SongMerge struct {
+	TargetId  int   `json:"targetId" binding:"required"`
+	SourceIds []int `json:"sourceIds" binding:"required,min=1,dive,required"`
+}
+
+// SongMerged tells what a merge moved to the target.
+type SongMerged struct {
+	Id     int   `json:"id"`
+	Merged []int `json:"merged"`
+	// Fields of the target that were empty and taken from a source.
+	Fields []string `json:"fields"`
+}
+
+type SongMergedResponse = Data[SongMerged]
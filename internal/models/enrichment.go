@@ -0,0 +1,46 @@
+package models
+
+// Enrichment statuses of a Song. Songs are looked up in the music info
+// service after creation when fields are missing, and on request.
+const (
+	EnrichmentNone    = "none"
+	EnrichmentPending = "pending"
+	EnrichmentDone    = "done"
+	EnrichmentFailed  = "failed"
+)
+
+// JobEnrichSong is the kind of the jobs looking songs up, with an
+// EnrichSongJob payload.
+const JobEnrichSong = "songs.enrich"
+
+// Song fields the music info service provides.
+const (
+	EnrichText        = "text"
+	EnrichLink        = "link"
+	EnrichReleaseDate = "releaseDate"
+)
+
+var EnrichFields = []string{EnrichText, EnrichLink, EnrichReleaseDate}
+
+type EnrichSongJob struct {
+	SongId int    `json:"songId"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	// Fields set from the lookup; the others are left alone.
+	Fields []string `json:"fields"`
+}
+
+// SongEnrichment is what the music info service knows about a song. Empty
+// fields are unknown.
+type SongEnrichment struct {
+	Text        string
+	Link        string
+	ReleaseDate *DateFormat
+}
+
+type SongCreated struct {
+	Id               int    `json:"id"`
+	EnrichmentStatus string `json:"enrichmentStatus"`
+}
+
+type SongCreatedResponse = Data[SongCreated]
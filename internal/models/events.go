@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Types of SongEvent.
+const (
+	SongEventCreate = "create"
+	SongEventUpdate = "update"
+	SongEventDelete = "delete"
+)
+
+// SongEvent records a change to a song. Clients fetch the song again to see
+// what changed.
+type SongEvent struct {
+	Id     int64     `json:"id"`
+	Type   string    `json:"type"`
+	SongId int       `json:"songId"`
+	At     time.Time `json:"at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Facets of GET /songs.
+const (
+	FacetGroup = "group"
+	FacetYear  = "year"
+	FacetTag   = "tag"
+)
+
+var facets = []string{FacetGroup, FacetYear, FacetTag}
+
+// FacetBucket is the number of matching songs with one value of a facet.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SongFacets maps each requested facet to its buckets, most frequent value
+// first.
+type SongFacets map[string][]FacetBucket
+
+// ParseFacets splits a comma separated facets parameter, rejecting unknown
+// names.
+func ParseFacets(s string) ([]string, error) {
+	var res []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || slices.Contains(res, f) {
+			continue
+		}
+		if !slices.Contains(facets, f) {
+			return nil, fmt.Errorf("unknown facet %q, expected one of %s", f, strings.Join(facets, ", "))
+		}
+		res = append(res, f)
+	}
+	return res, nil
+}
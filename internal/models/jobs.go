@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Statuses of a Job. Failed jobs are queued again until they run out of
+// attempts and die.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+type Job struct {
+	Id      int64           `json:"id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	Key     *string         `json:"key"`
+	Status  string          `json:"status"`
+	// Attempts started, including a running one.
+	Attempts int `json:"attempts"`
+	// When a queued job is due, or the lease of a running one expires.
+	RunAt      time.Time  `json:"runAt"`
+	LastError  *string    `json:"lastError"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+type JobEnqueue struct {
+	Kind string
+	// Marshalled to JSON; nil is an empty object.
+	Payload any
+	// Not before; nil is now.
+	RunAt *time.Time
+	// Skips the job if one with the same key was ever enqueued.
+	Key string
+}
+
+type JobsQuery struct {
+	PageMaxQuery
+	Kind   string `form:"kind"`
+	Status string `form:"status" binding:"omitempty,oneof=queued running done dead"`
+}
+
+type JobCount struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+type ListJobs = Paginator[[]Job]
+type JobsSummary = Data[[]JobCount]
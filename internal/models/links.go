@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// JobCheckLinks is the kind of the jobs checking song links.
+const JobCheckLinks = "links.check"
+
+// LinkTarget is a song link due for a check.
+type LinkTarget struct {
+	SongId int
+	URL    string
+}
+
+type LinkCheck struct {
+	SongId int    `json:"songId"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	URL    string `json:"url"`
+	// Of the last response; nil if there was none.
+	StatusCode *int `json:"statusCode"`
+	// Where redirects led, if anywhere else.
+	RedirectURL *string   `json:"redirectUrl"`
+	Error       *string   `json:"error"`
+	Broken      bool      `json:"broken"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+type LinkSummary struct {
+	// Songs with a link.
+	Links      int `json:"links"`
+	Checked    int `json:"checked"`
+	Broken     int `json:"broken"`
+	Redirected int `json:"redirected"`
+}
+
+// LinkReport pages through the broken links.
+type LinkReport struct {
+	Data    []LinkCheck `json:"data"`
+	Page    int         `json:"page"`
+	Amount  int         `json:"amount"`
+	Next    bool        `json:"next"`
+	Ok      bool        `json:"ok"`
+	Summary LinkSummary `json:"summary"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// LyricWord is a word timing of enhanced LRC, relative to the song start.
+type LyricWord struct {
+	StartMs int    `json:"startMs"`
+	Text    string `json:"text"`
+}
+
+// LyricLine is a line of songs.text. Number is 1-based, as in GetSongText
+// pagination; StartMs is nil for lines without a timestamp.
+type LyricLine struct {
+	Number  int         `json:"number"`
+	Text    string      `json:"text"`
+	StartMs *int        `json:"startMs,omitempty"`
+	Words   []LyricWord `json:"words,omitempty"`
+}
+
+type SyncedLyrics struct {
+	Title  string
+	Artist string
+	Lines  []LyricLine
+}
+
+type SongLines = Paginator[[]LyricLine]
+
+// SongTextInfo describes one language version of a song text.
+type SongTextInfo struct {
+	Lang      string    `json:"lang"`
+	Original  bool      `json:"original"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type SongTextUpsert struct {
+	Text string `json:"text" binding:"required"`
+	// Mark this language as the one the song was written in. Its text then
+	// replaces the song text.
+	Original bool `json:"original"`
+}
+
+// ParallelLine pairs an original line with its translation. Either side is
+// nil when one text has fewer lines than the other.
+type ParallelLine struct {
+	Number      int     `json:"number"`
+	Original    *string `json:"original"`
+	Translation *string `json:"translation"`
+}
+
+type ParallelText = Paginator[[]ParallelLine]
+type SongTexts = Data[[]SongTextInfo]
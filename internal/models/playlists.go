@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Playlist visibilities. Private playlists are only visible to their owner.
+const (
+	VisibilityPrivate = "private"
+	VisibilityPublic  = "public"
+)
+
+type Playlist struct {
+	Id         int       `json:"id"`
+	OwnerId    int       `json:"ownerId"`
+	Title      string    `json:"title"`
+	Visibility string    `json:"visibility"`
+	Entries    int       `json:"entries"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type PlaylistCreate struct {
+	Title      string `json:"title" binding:"required"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+type PlaylistUpdate struct {
+	Title      *string `json:"title" binding:"omitempty,min=1"`
+	Visibility *string `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+// PlaylistEntry is a song at a 1-based position of a playlist. A song can be
+// in a playlist several times, so entries have their own id.
+type PlaylistEntry struct {
+	Id       int       `json:"id"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"addedAt"`
+	Song     Song      `json:"song"`
+}
+
+type PlaylistEntryAdd struct {
+	SongId int `json:"songId" binding:"required"`
+	// Position to insert at, moving later entries down; appended if empty.
+	Position *int `json:"position" binding:"omitempty,min=1"`
+}
+
+// PlaylistOrder lists every entry id of a playlist in the new order.
+type PlaylistOrder struct {
+	EntryIds []int `json:"entryIds" binding:"required"`
+}
+
+type PlaylistResponse = Data[Playlist]
+type PlaylistEntryResponse = Data[PlaylistEntry]
+type ListPlaylists = Paginator[[]Playlist]
+type ListPlaylistEntries = Paginator[[]PlaylistEntry]
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Sort orders of SongsQuery.
+const (
+	SortPopularity = "popularity"
+	SortRating     = "rating"
+)
+
+// MaxPlaysBatch bounds the plays recorded by one request.
+const MaxPlaysBatch = 500
+
+// Popularity aggregates the plays and ratings of a song. Rating is the
+// average number of stars, null until the song is rated.
+type Popularity struct {
+	Plays   int64    `json:"plays"`
+	Rating  *float64 `json:"rating"`
+	Ratings int      `json:"ratings"`
+}
+
+type PlayEvent struct {
+	// Chosen by the client, so that a retried batch isn't counted twice.
+	EventId  string     `json:"eventId" binding:"required,max=128"`
+	SongId   int        `json:"songId" binding:"required"`
+	PlayedAt *time.Time `json:"playedAt"`
+}
+
+type PlaysBatch struct {
+	Plays []PlayEvent `json:"plays" binding:"required,min=1,max=500,dive"`
+}
+
+// PlaysResult counts the recorded plays; the others were already recorded
+// or refer to unknown songs.
+type PlaysResult struct {
+	Recorded int `json:"recorded"`
+	Ignored  int `json:"ignored"`
+}
+
+type RatingSet struct {
+	Stars int `json:"stars" binding:"required,min=1,max=5"`
+}
+
+type SongRating struct {
+	SongId int `json:"songId"`
+	Popularity
+	// Stars given by the authenticated user, if any.
+	Mine *int `json:"mine,omitempty"`
+}
+
+type PlaysResponse = Data[PlaysResult]
+type SongRatingResponse = Data[SongRating]
@@ -14,9 +14,18 @@ type Paginator[D any] struct {
 }
 
 type Message struct {
-	Ok  bool   `json:"ok"`
-	Msg string `json:"msg"`
+	Ok        bool   `json:"ok"`
+	Msg       string `json:"msg"`
+	RequestId string `json:"requestId,omitempty"`
 }
 
-type ListAllSongs = Paginator[[]Song]
+// ListAllSongs is Paginator[[]Song] with the facets asked for, if any.
+type ListAllSongs struct {
+	Data   []Song     `json:"data"`
+	Page   int        `json:"page"`
+	Amount int        `json:"amount"`
+	Next   bool       `json:"next"`
+	Ok     bool       `json:"ok"`
+	Facets SongFacets `json:"facets,omitempty"`
+}
 type SongsText = Paginator[[]string]
@@ -0,0 +1,34 @@
+package models
+
+// JobRefreshSimilar is the kind of the jobs recomputing the stored similar
+// songs of every song.
+const JobRefreshSimilar = "similar.refresh"
+
+// MaxSimilar bounds the songs returned by /songs/:id/similar and stored per
+// song by the precomputation.
+const MaxSimilar = 50
+
+type SimilarQuery struct {
+	Limit int `form:"limit" binding:"min=1,max=50"`
+}
+
+func NewSimilarQuery() SimilarQuery {
+	return SimilarQuery{Limit: 10}
+}
+
+// SimilarSong is a song ranked by Score, which weighs the parts of Reasons.
+type SimilarSong struct {
+	Song
+	Score   float64           `json:"score"`
+	Reasons SimilarityReasons `json:"reasons"`
+}
+
+type SimilarityReasons struct {
+	// Cosine similarity of the tf-idf weighted lyrics words, 0 to 1.
+	Lyrics float64 `json:"lyrics"`
+	// Jaccard index of the tag sets, 0 to 1.
+	Tags      float64 `json:"tags"`
+	SameGroup bool    `json:"sameGroup"`
+}
+
+type SimilarSongs = Data[[]SimilarSong]
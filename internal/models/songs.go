@@ -76,6 +76,17 @@ type SongsQuery struct {
 	Song        *string     `form:"song"`
 	Link        *string     `form:"link"`
 	ReleaseDate *DateFormat `form:"releaseDate" validate:"datetime"`
+	// Tag names; songs match if they carry any (or with TagMatch "all",
+	// every) one of them.
+	Tags     []string `form:"tag"`
+	TagMatch string   `form:"tagMatch"`
+	// Comma separated facets to count over the matching songs.
+	Facets string `form:"facets"`
+	// Empty, "popularity" (most played first) or "rating" (best rated
+	// first, unrated last).
+	Sort string `form:"sort"`
+	// Whether the last check of the current link found it broken.
+	Broken *bool `form:"broken"`
 }
 
 type SongDetailQuery struct {
@@ -83,6 +94,16 @@ type SongDetailQuery struct {
 	Song  string `form:"song" binding:"required"`
 }
 
+// ValidTagMatch reports whether TagMatch is empty, "any" or "all".
+func (sq *SongsQuery) ValidTagMatch() bool {
+	return sq.TagMatch == "" || sq.TagMatch == TagMatchAny || sq.TagMatch == TagMatchAll
+}
+
+// ValidSort reports whether Sort is a known order.
+func (sq *SongsQuery) ValidSort() bool {
+	return sq.Sort == "" || sq.Sort == SortPopularity || sq.Sort == SortRating
+}
+
 func NewSongsQuery() SongsQuery {
 	return SongsQuery{
 		Page: 0,
@@ -91,11 +112,30 @@ func NewSongsQuery() SongsQuery {
 }
 
 type SongCreateQuery struct {
-	Group       string      `json:"group" binding:"required"`
-	Song        string      `json:"song" binding:"required"`
-	Text        string      `json:"text" binding:"required"`
-	Link        string      `json:"link" binding:"required"`
+	Group string `json:"group" binding:"required"`
+	Song  string `json:"song" binding:"required"`
+	// Text and Link are required unless enrichment is enabled, which looks
+	// them and ReleaseDate up when missing.
+	Text        string      `json:"text"`
+	Link        string      `json:"link"`
 	ReleaseDate *DateFormat `json:"releaseDate" validate:"required,datetime"`
+	// Tag names; unknown ones are created.
+	Tags []string `json:"tags"`
+}
+
+// MissingFields returns the EnrichFields not given.
+func (scq *SongCreateQuery) MissingFields() []string {
+	var fields []string
+	if scq.Text == "" {
+		fields = append(fields, EnrichText)
+	}
+	if scq.Link == "" {
+		fields = append(fields, EnrichLink)
+	}
+	if scq.ReleaseDate == nil {
+		fields = append(fields, EnrichReleaseDate)
+	}
+	return fields
 }
 
 type Song struct {
@@ -104,6 +144,12 @@ type Song struct {
 	Name        string     `json:"song"`
 	ReleaseDate DateFormat `json:"releaseDate"`
 	Link        string     `json:"link"`
+	Tags        []string   `json:"tags"`
+	// Only set for authenticated requests.
+	IsFavourite *bool `json:"isFavourite,omitempty"`
+	// Only set in song listings.
+	Popularity       *Popularity `json:"popularity,omitempty"`
+	EnrichmentStatus string      `json:"enrichmentStatus,omitempty"`
 }
 
 type SongDetail struct {
@@ -113,6 +159,10 @@ type SongDetail struct {
 	Text        string     `json:"text"`
 	ReleaseDate DateFormat `json:"releaseDate"`
 	Link        string     `json:"link"`
+	Tags        []string   `json:"tags"`
+	// Language of Text, when known.
+	Lang             string `json:"lang,omitempty"`
+	EnrichmentStatus string `json:"enrichmentStatus,omitempty"`
 }
 
 type SongUpdate struct {
@@ -121,4 +171,28 @@ type SongUpdate struct {
 	Text        *string     `json:"text"`
 	ReleaseDate *DateFormat `json:"releaseDate"`
 	Link        *string     `json:"link"`
+	// Replaces all tags of the song; unknown ones are created.
+	Tags *[]string `json:"tags"`
+}
+
+// Fields returns the JSON names of the fields given, so that an update can
+// be logged without its content.
+func (su *SongUpdate) Fields() []string {
+	fields := []string{}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"group", su.GroupName != nil},
+		{"song", su.Name != nil},
+		{"text", su.Text != nil},
+		{"releaseDate", su.ReleaseDate != nil},
+		{"link", su.Link != nil},
+		{"tags", su.Tags != nil},
+	} {
+		if f.set {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
 }
@@ -0,0 +1,46 @@
+package models
+
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// LyricsStats counts non-empty lines; a verse is a run of non-empty lines.
+// Words are lower-cased runs of letters, digits and apostrophes.
+type LyricsStats struct {
+	Lines       int         `json:"lines"`
+	Verses      int         `json:"verses"`
+	Words       int         `json:"words"`
+	UniqueWords int         `json:"uniqueWords"`
+	TopWords    []WordCount `json:"topWords"`
+}
+
+type SongStats struct {
+	SongId int `json:"songId"`
+	LyricsStats
+}
+
+type GroupStats struct {
+	Group string `json:"group"`
+	Songs int    `json:"songs"`
+	LyricsStats
+}
+
+type StatsQuery struct {
+	Page  int     `form:"page" validate:"gte=0"`
+	Max   int     `form:"max" validate:"gte=1"`
+	Group *string `form:"group"`
+	// Number of most frequent words returned.
+	Top int `form:"top"`
+}
+
+func NewStatsQuery() StatsQuery {
+	return StatsQuery{
+		Page: 0,
+		Max:  10,
+		Top:  10,
+	}
+}
+
+type SongStatsResponse = Data[SongStats]
+type ListGroupStats = Paginator[[]GroupStats]
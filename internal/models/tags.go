@@ -0,0 +1,64 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// Tag kinds. Genres are tags too; the kind only helps clients group them.
+const (
+	TagKindTag   = "tag"
+	TagKindGenre = "genre"
+)
+
+// Tag match modes of SongsQuery.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+type Tag struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type TagCreate struct {
+	Name string `json:"name" binding:"required"`
+	Kind string `json:"kind" binding:"omitempty,oneof=tag genre"`
+}
+
+type TagUpdate struct {
+	Name *string `json:"name" binding:"omitempty,min=1"`
+	Kind *string `json:"kind" binding:"omitempty,oneof=tag genre"`
+}
+
+type TagsQuery struct {
+	Kind *string `form:"kind" binding:"omitempty,oneof=tag genre"`
+}
+
+// TagCount is the number of songs carrying a tag.
+type TagCount struct {
+	Tag
+	Songs int `json:"songs"`
+}
+
+// NormalizeTags trims and lower-cases tag names, dropping empty and
+// duplicate ones.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	res := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !slices.Contains(res, t) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+type TagResponse = Data[Tag]
+type ListTags = Data[[]Tag]
+type TagCounts = Data[[]TagCount]
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type User struct {
+	Id        int       `json:"id"`
+	Username  string    `json:"username"`
+	IsAdmin   bool      `json:"isAdmin"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserCreate struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	IsAdmin  bool   `json:"isAdmin"`
+}
+
+type Credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type TokenResponse = Data[Token]
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Statuses of a WebhookDelivery. Pending deliveries are retried until they
+// succeed or run out of attempts and are dead-lettered.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	Id int `json:"id"`
+	// Receives a POST of the models.SongEvent for every subscribed event.
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WebhookCreate struct {
+	URL    string   `json:"url" binding:"required,http_url"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=create update delete"`
+	// Key of the HMAC-SHA256 signatures; generated if empty.
+	Secret string `json:"secret" binding:"omitempty,min=16"`
+}
+
+type WebhookUpdate struct {
+	URL    *string   `json:"url" binding:"omitempty,http_url"`
+	Events *[]string `json:"events" binding:"omitempty,min=1,dive,oneof=create update delete"`
+	Active *bool     `json:"active"`
+}
+
+// WebhookCreated is the only response carrying the secret.
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookDelivery struct {
+	Id             int64           `json:"id"`
+	WebhookId      int             `json:"webhookId"`
+	EventId        int64           `json:"eventId"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt"`
+	LastStatusCode *int            `json:"lastStatusCode"`
+	LastError      *string         `json:"lastError"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+}
+
+type DeliveriesQuery struct {
+	PageMaxQuery
+	WebhookId *int   `form:"-"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+}
+
+// OutgoingDelivery is a due delivery claimed by the dispatcher.
+type OutgoingDelivery struct {
+	Id        int64
+	WebhookId int
+	URL       string
+	Secret    string
+	EventType string
+	Payload   []byte
+	// Attempts made before this one.
+	Attempts int
+}
+
+type WebhookResponse = Data[Webhook]
+type WebhookCreatedResponse = Data[WebhookCreated]
+type ListWebhooks = Data[[]Webhook]
+type ListDeliveries = Paginator[[]WebhookDelivery]
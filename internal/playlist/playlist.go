@@ -0,0 +1,69 @@
+// Package playlist writes playlists as extended M3U (UTF-8, .m3u8) and XSPF.
+package playlist
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// M3U8 lists the links of the entries. Entries without a link can't be
+// played and are left out.
+func M3U8(title string, entries []models.PlaylistEntry) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#PLAYLIST:" + oneLine(title) + "\n")
+	for _, e := range entries {
+		if e.Song.Link == "" {
+			continue
+		}
+		// The duration is unknown.
+		b.WriteString("#EXTINF:-1," + oneLine(e.Song.GroupName+" - "+e.Song.Name) + "\n")
+		b.WriteString(oneLine(e.Song.Link) + "\n")
+	}
+	return b.String()
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"playlist"`
+	Version   string      `xml:"version,attr"`
+	Namespace string      `xml:"xmlns,attr"`
+	Title     string      `xml:"title"`
+	Tracks    []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+	TrackNum int    `xml:"trackNum"`
+}
+
+// XSPF keeps entries without a link as tracks without a location, which
+// players may resolve from the title and creator.
+func XSPF(title string, entries []models.PlaylistEntry) ([]byte, error) {
+	p := xspfPlaylist{
+		Version:   "1",
+		Namespace: "http://xspf.org/ns/0/",
+		Title:     title,
+		Tracks:    make([]xspfTrack, len(entries)),
+	}
+	for i, e := range entries {
+		p.Tracks[i] = xspfTrack{
+			Location: e.Song.Link,
+			Title:    e.Song.Name,
+			Creator:  e.Song.GroupName,
+			TrackNum: e.Position,
+		}
+	}
+	b, err := xml.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
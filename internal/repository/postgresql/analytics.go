@@ -0,0 +1,159 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func scanGroupCounts(rows *sql.Rows) (res []models.GroupCount, err error) {
+	for rows.Next() {
+		var gc models.GroupCount
+		if err = rows.Scan(&gc.Group, &gc.Songs); err != nil {
+			return
+		}
+		res = append(res, gc)
+	}
+	err = rows.Err()
+	return
+}
+
+// CountSongsByGroup counts the songs matching sq per group, ordered by group
+// name and paginated by sq.
+func (sr *SongsRepository) CountSongsByGroup(ctx context.Context, sq *models.SongsQuery) (res []models.GroupCount, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CountSongsByGroup")
+	defer done(&err)
+
+	args := songsFilterArgs(sq)
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		SELECT count(DISTINCT s.group_name) FROM songs s
+		WHERE s.group_name IS NOT NULL AND `+songsFilter+`
+		`,
+		args...,
+	)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.group_name, count(*) FROM songs s
+		WHERE s.group_name IS NOT NULL AND `+songsFilter+`
+		GROUP BY s.group_name
+		ORDER BY s.group_name
+		LIMIT `+param(1)+`
+		OFFSET `+param(2)+`
+		`,
+		append(args, sq.Max, sq.Max*sq.Page)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	res, err = scanGroupCounts(rows)
+	return
+}
+
+// GetTopGroups returns the sq.Max groups with the most songs matching sq.
+func (sr *SongsRepository) GetTopGroups(ctx context.Context, sq *models.SongsQuery) (res []models.GroupCount, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetTopGroups")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.group_name, count(*) AS songs FROM songs s
+		WHERE s.group_name IS NOT NULL AND `+songsFilter+`
+		GROUP BY s.group_name
+		ORDER BY songs DESC, s.group_name
+		LIMIT `+param(1)+`
+		`,
+		append(songsFilterArgs(sq), sq.Max)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	res, err = scanGroupCounts(rows)
+	return
+}
+
+// CountReleases counts the songs matching sq per year or month of release.
+// Every period between the first and the last release is present, so the
+// result can be plotted as is.
+func (sr *SongsRepository) CountReleases(ctx context.Context, sq *models.SongsQuery, interval string) (res []models.ReleaseBucket, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CountReleases")
+	defer done(&err)
+
+	format := "YYYY"
+	if interval == models.IntervalMonth {
+		format = "YYYY-MM"
+	}
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		WITH counts AS (
+			SELECT date_trunc(`+param(1)+`::text, s.release_date)::date AS start, count(*) AS songs FROM songs s
+			WHERE s.release_date IS NOT NULL AND `+songsFilter+`
+			GROUP BY 1
+		),
+		periods AS (
+			SELECT generate_series(min(start), max(start), ('1 ' || `+param(1)+`::text)::interval)::date AS start FROM counts
+		)
+		SELECT to_char(p.start, `+param(2)+`), p.start, COALESCE(c.songs, 0)
+		FROM periods p
+		LEFT JOIN counts c ON c.start = p.start
+		ORDER BY p.start
+		`,
+		append(songsFilterArgs(sq), interval, format)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b models.ReleaseBucket
+		if err = rows.Scan(&b.Period, &b.Start, &b.Songs); err != nil {
+			return
+		}
+		res = append(res, b)
+	}
+	err = rows.Err()
+	return
+}
+
+// GetRecentSongs returns the sq.Max most recently added songs matching sq.
+func (sr *SongsRepository) GetRecentSongs(ctx context.Context, sq *models.SongsQuery) (res []models.RecentSong, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetRecentSongs")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.id, s.name, s.group_name, s.release_date, COALESCE(s.link, ''), `+songTags+`, s.created_at FROM songs s
+		WHERE `+songsFilter+`
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT `+param(1)+`
+		`,
+		append(songsFilterArgs(sq), sq.Max)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rs models.RecentSong
+		if err = rows.Scan(&rs.Id, &rs.Name, &rs.GroupName, &rs.ReleaseDate, &rs.Link, pq.Array(&rs.Tags), &rs.AddedAt); err != nil {
+			return
+		}
+		res = append(res, rs)
+	}
+	err = rows.Err()
+	return
+}
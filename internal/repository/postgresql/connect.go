@@ -0,0 +1,83 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+)
+
+// Open creates the traced connection pool described by cfg and pings it,
+// retrying with exponential backoff while the database is not reachable.
+func Open(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DSN(), otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	backoff := cfg.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.QueryTimeout)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			db.Close()
+			return nil, fmt.Errorf("database is not reachable after %d attempts: %w", attempt, err)
+		}
+		log.WithError(err).Warnf("database ping failed, retrying in %s", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// Timeouts bounds repository methods. Zero values fall back to defaultTimeout.
+type Timeouts struct {
+	Default   time.Duration
+	PerMethod map[string]time.Duration
+}
+
+const defaultTimeout = 5 * time.Second
+
+func (t Timeouts) For(method string) time.Duration {
+	if d, ok := t.PerMethod[method]; ok && d > 0 {
+		return d
+	}
+	if t.Default > 0 {
+		return t.Default
+	}
+	return defaultTimeout
+}
+
+// With returns a copy of t in which method is bounded by d.
+func (t Timeouts) With(method string, d time.Duration) Timeouts {
+	perMethod := make(map[string]time.Duration, len(t.PerMethod)+1)
+	for m, md := range t.PerMethod {
+		perMethod[m] = md
+	}
+	perMethod[method] = d
+	t.PerMethod = perMethod
+	return t
+}
+
+func TimeoutsFromConfig(cfg config.DatabaseConfig) Timeouts {
+	return Timeouts{Default: cfg.QueryTimeout, PerMethod: cfg.OperationTimeouts}
+}
@@ -0,0 +1,273 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// normalized lowercases col and strips whitespace and punctuation, so that
+// near-identical names compare equal.
+func normalized(col string) string {
+	return `regexp_replace(lower(COALESCE(` + col + `, '')), '[^[:alnum:]]+', '', 'g')`
+}
+
+// duplicatePairs builds CTEs ending in duplicates, which pairs every song
+// with the later songs of the same group, by normalized names, that have
+// the same normalized title or lyrics at least $1 similar. Lyrics are only
+// split for songs sharing their group with another one.
+var duplicatePairs = `
+		WITH keyed AS (
+			SELECT id, ` + normalized("group_name") + ` AS group_key, ` + normalized("name") + ` AS title_key
+			FROM songs
+		),
+		candidates AS (
+			SELECT a.id AS song_id, b.id AS duplicate_id, a.title_key = b.title_key AS same_title
+			FROM keyed a
+			JOIN keyed b ON b.group_key = a.group_key AND b.id > a.id
+			WHERE a.group_key <> ''
+		),
+		` + splitLinesBy("split_text", "id,", `songs
+			WHERE id IN (SELECT song_id FROM candidates UNION SELECT duplicate_id FROM candidates)`) + `,
+		` + wordsFrom("id,") + `,
+		word_sets AS (
+			SELECT DISTINCT id, word FROM words
+		),
+		word_counts AS (
+			SELECT id, count(*) AS n FROM word_sets GROUP BY id
+		),
+		shared AS (
+			SELECT c.song_id, c.duplicate_id, count(*) AS n
+			FROM candidates c
+			JOIN word_sets a ON a.id = c.song_id
+			JOIN word_sets b ON b.id = c.duplicate_id AND b.word = a.word
+			GROUP BY c.song_id, c.duplicate_id
+		),
+		duplicates AS (
+			SELECT c.song_id, c.duplicate_id, c.same_title,
+				COALESCE(sh.n::float8 / (ca.n + cb.n - sh.n), 0) AS lyrics
+			FROM candidates c
+			LEFT JOIN shared sh USING (song_id, duplicate_id)
+			LEFT JOIN word_counts ca ON ca.id = c.song_id
+			LEFT JOIN word_counts cb ON cb.id = c.duplicate_id
+			WHERE c.same_title OR COALESCE(sh.n::float8 / (ca.n + cb.n - sh.n), 0) >= $1
+		)`
+
+// ListDuplicates paginates the likely duplicate songs, same titles first,
+// then by lyrics similarity.
+func (sr *SongsRepository) ListDuplicates(ctx context.Context, dq *models.DuplicatesQuery) (res []models.DuplicatePair, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListDuplicates")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, duplicatePairs+` SELECT count(*) FROM duplicates`, dq.MinLyrics)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		duplicatePairs+`
+		SELECT song_id, duplicate_id, same_title, lyrics FROM duplicates
+		ORDER BY same_title DESC, lyrics DESC, song_id, duplicate_id
+		LIMIT $2
+		OFFSET $3
+		`,
+		dq.MinLyrics, dq.Max, dq.Max*dq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var dp models.DuplicatePair
+		if err = rows.Scan(&dp.Song.Id, &dp.Duplicate.Id, &dp.SameTitle, &dp.Lyrics); err != nil {
+			return
+		}
+		res = append(res, dp)
+		ids = append(ids, dp.Song.Id, dp.Duplicate.Id)
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	songs, err := sr.songsById(ctx, ids)
+	if err != nil {
+		return
+	}
+	for i := range res {
+		res[i].Song, res[i].Duplicate = songs[res[i].Song.Id], songs[res[i].Duplicate.Id]
+	}
+	return
+}
+
+// songsById returns the songs with the given ids as listed by GetSongs.
+func (sr *SongsRepository) songsById(ctx context.Context, ids []int) (map[int]models.Song, error) {
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.id, s.name, s.group_name, s.release_date, `+songTags+`, `+songPopularity+`, s.enrichment_status FROM songs s
+		WHERE s.id = ANY($1)
+		`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	songs := make(map[int]models.Song, len(ids))
+	for rows.Next() {
+		song := models.Song{Popularity: &models.Popularity{}}
+		dest := append([]any{&song.Id, &song.Name, &song.GroupName, &song.ReleaseDate, pq.Array(&song.Tags)}, scanPopularity(song.Popularity)...)
+		if err := rows.Scan(append(dest, &song.EnrichmentStatus)...); err != nil {
+			return nil, err
+		}
+		songs[song.Id] = song
+	}
+	return songs, rows.Err()
+}
+
+// mergeStatements move what is attached to the sources $2 to the target
+// $1, see MergeSongs.
+var mergeStatements = []string{
+	`INSERT INTO song_tags (song_id, tag_id)
+	SELECT DISTINCT $1::int, tag_id FROM song_tags WHERE song_id = ANY($2::int[])
+	ON CONFLICT DO NOTHING`,
+	// Originals are copies of songs.text, see sync_original_song_text.
+	`INSERT INTO song_texts (song_id, lang, text, updated_at)
+	SELECT DISTINCT ON (lang) $1::int, lang, text, updated_at FROM song_texts
+	WHERE song_id = ANY($2::int[]) AND NOT is_original
+	ORDER BY lang, array_position($2::int[], song_id)
+	ON CONFLICT DO NOTHING`,
+	`INSERT INTO favourites (user_id, song_id, created_at)
+	SELECT user_id, $1::int, min(created_at) FROM favourites WHERE song_id = ANY($2::int[])
+	GROUP BY user_id
+	ON CONFLICT DO NOTHING`,
+	`INSERT INTO ratings (user_id, song_id, stars, updated_at)
+	SELECT DISTINCT ON (user_id) user_id, $1::int, stars, updated_at FROM ratings
+	WHERE song_id = ANY($2::int[])
+	ORDER BY user_id, updated_at DESC
+	ON CONFLICT DO NOTHING`,
+	`UPDATE song_plays SET song_id = $1 WHERE song_id = ANY($2::int[])`,
+	`UPDATE playlist_entries SET song_id = $1 WHERE song_id = ANY($2::int[])`,
+	`UPDATE songs SET
+		play_count = play_count + (SELECT COALESCE(sum(play_count), 0) FROM songs WHERE id = ANY($2::int[])),
+		rating_count = r.n,
+		rating_sum = r.stars
+	FROM (SELECT count(*) AS n, COALESCE(sum(stars), 0) AS stars FROM ratings WHERE song_id = $1) r
+	WHERE id = $1`,
+}
+
+// MergeSongs folds the sources into the target: empty fields of the target
+// are taken from the first source that has them, along with the line
+// timestamps of a taken text, and the tags, translations, favourites,
+// ratings, plays and playlist entries of the sources move to the target
+// before the sources are deleted. Where a user rated several of the songs,
+// the target's rating, or else the latest, is kept. It returns
+// sql.ErrNoRows if any of the songs is unknown. Run it in a transaction.
+func (sr *SongsRepository) MergeSongs(ctx context.Context, sm *models.SongMerge) (res models.SongMerged, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "MergeSongs")
+	defer done(&err)
+
+	target, sources := sm.TargetId, pq.Array(sm.SourceIds)
+	res = models.SongMerged{Id: target, Merged: sm.SourceIds, Fields: []string{}}
+
+	type songFields struct {
+		id          int
+		text, link  sql.NullString
+		releaseDate sql.NullTime
+	}
+	// The sources in the order given, then the target.
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT id, text, link, release_date FROM songs
+		WHERE id = $1 OR id = ANY($2::int[])
+		ORDER BY array_position($2::int[], id) NULLS LAST
+		FOR UPDATE
+		`,
+		target, sources,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	var songs []songFields
+	for rows.Next() {
+		var sf songFields
+		if err = rows.Scan(&sf.id, &sf.text, &sf.link, &sf.releaseDate); err != nil {
+			return
+		}
+		songs = append(songs, sf)
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+	if len(songs) != len(sm.SourceIds)+1 {
+		err = sql.ErrNoRows
+		return
+	}
+
+	merged, textSource := songs[len(songs)-1], 0
+	for _, sf := range songs[:len(songs)-1] {
+		if merged.text.String == "" && sf.text.String != "" {
+			merged.text, textSource = sf.text, sf.id
+			res.Fields = append(res.Fields, models.EnrichText)
+		}
+		if merged.link.String == "" && sf.link.String != "" {
+			merged.link = sf.link
+			res.Fields = append(res.Fields, models.EnrichLink)
+		}
+		if !merged.releaseDate.Valid && sf.releaseDate.Valid {
+			merged.releaseDate = sf.releaseDate
+			res.Fields = append(res.Fields, models.EnrichReleaseDate)
+		}
+	}
+	if len(res.Fields) > 0 {
+		if _, err = sr.pool.ExecContext(
+			ctx,
+			`UPDATE songs SET text = $2, link = $3, release_date = $4 WHERE id = $1`,
+			target, merged.text, merged.link, merged.releaseDate,
+		); err != nil {
+			return
+		}
+	}
+	if textSource != 0 {
+		// The timestamps and the original of the taken text come with it.
+		for _, stmt := range []string{
+			`DELETE FROM song_line_timestamps WHERE song_id = $1`,
+			`INSERT INTO song_line_timestamps (song_id, line_number, start_ms, words)
+			SELECT $1, line_number, start_ms, words FROM song_line_timestamps WHERE song_id = $2`,
+			`INSERT INTO song_texts (song_id, lang, text, is_original)
+			SELECT $1, lang, text, TRUE FROM song_texts
+			WHERE song_id = $2 AND is_original AND NOT EXISTS (SELECT 1 FROM song_texts WHERE song_id = $1 AND is_original)
+			ON CONFLICT DO NOTHING`,
+		} {
+			if _, err = sr.pool.ExecContext(ctx, stmt, target, textSource); err != nil {
+				return
+			}
+		}
+	}
+	for _, stmt := range mergeStatements {
+		if _, err = sr.pool.ExecContext(ctx, stmt, target, sources); err != nil {
+			return
+		}
+	}
+
+	if _, err = sr.pool.ExecContext(ctx, `DELETE FROM songs WHERE id = ANY($1::int[])`, sources); err != nil {
+		return
+	}
+	for _, songId := range sm.SourceIds {
+		if err = sr.addSongEvent(ctx, models.SongEventDelete, songId); err != nil {
+			return
+		}
+	}
+	if err = sr.forgetSimilarSongs(ctx, target); err != nil {
+		return
+	}
+	err = sr.addSongEvent(ctx, models.SongEventUpdate, target)
+	return
+}
@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// EnqueueEnrichment marks a song pending and queues a lookup setting fields
+// of it. It returns sql.ErrNoRows for an unknown song.
+func (sr *SongsRepository) EnqueueEnrichment(ctx context.Context, songId int, fields []string) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "EnqueueEnrichment")
+	defer done(&err)
+
+	job := models.EnrichSongJob{SongId: songId, Fields: fields}
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`UPDATE songs SET enrichment_status = 'pending' WHERE id = $1 RETURNING COALESCE(group_name, ''), COALESCE(name, '')`,
+		songId,
+	).Scan(&job.Group, &job.Song); err != nil {
+		return
+	}
+	_, err = sr.EnqueueJob(ctx, &models.JobEnqueue{Kind: models.JobEnrichSong, Payload: job})
+	return
+}
+
+// ApplyEnrichment sets the fields of ej that the lookup found and marks the
+// song enriched. It returns sql.ErrNoRows if the song was deleted meanwhile.
+func (sr *SongsRepository) ApplyEnrichment(ctx context.Context, ej *models.EnrichSongJob, info *models.SongEnrichment) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ApplyEnrichment")
+	defer done(&err)
+
+	// Also locks the song until the update below.
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`UPDATE songs SET enrichment_status = 'done' WHERE id = $1 RETURNING id`,
+		ej.SongId,
+	).Scan(new(int)); err != nil {
+		return
+	}
+
+	var su models.SongUpdate
+	for _, field := range ej.Fields {
+		switch {
+		case field == models.EnrichText && info.Text != "":
+			su.Text = &info.Text
+		case field == models.EnrichLink && info.Link != "":
+			su.Link = &info.Link
+		case field == models.EnrichReleaseDate && info.ReleaseDate != nil:
+			su.ReleaseDate = info.ReleaseDate
+		}
+	}
+	return sr.UpdateSong(ctx, &su, ej.SongId)
+}
+
+func (sr *SongsRepository) SetEnrichmentStatus(ctx context.Context, songId int, status string) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "SetEnrichmentStatus")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(ctx, `UPDATE songs SET enrichment_status = $2 WHERE id = $1`, songId, status)
+	return
+}
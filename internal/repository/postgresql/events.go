@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// SongEventsChannel is the LISTEN channel notified with the id of every new
+// song event.
+const SongEventsChannel = "song_events"
+
+// addSongEvent logs a change to songId, queues it for the subscribed
+// webhooks and notifies SongEventsChannel. Postgres delivers the
+// notification when the transaction commits and drops it on rollback, like
+// the queued deliveries.
+func (sr *SongsRepository) addSongEvent(ctx context.Context, eventType string, songId int) error {
+	_, err := sr.pool.ExecContext(
+		ctx,
+		`
+		WITH event AS (
+			INSERT INTO song_events (type, song_id) VALUES ($1, $2) RETURNING id, type, song_id, created_at
+		),
+		outbox AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id, payload)
+			SELECT w.id, e.id, json_build_object('id', e.id, 'type', e.type, 'songId', e.song_id, 'at', e.created_at)
+			FROM event e
+			JOIN webhooks w ON w.active AND e.type = ANY (w.event_types)
+		)
+		SELECT pg_notify('`+SongEventsChannel+`', id::text) FROM event
+		`,
+		eventType, songId,
+	)
+	return err
+}
+
+// committedEvents are the events whose transaction is over, or is the
+// current one. Any event committed later belongs to a transaction running
+// at the time or started since, with a greater tx_id, so ordering by tx_id
+// then id never puts a late event before one already read.
+const committedEvents = `(tx_id < pg_snapshot_xmin(pg_current_snapshot()) OR tx_id = pg_current_xact_id_if_assigned())`
+
+// ListSongEvents returns up to limit events following afterId, oldest first
+// by commit. Events of a transaction are only returned once every older
+// transaction is over. An unknown afterId resumes after the greatest id
+// below it.
+func (sr *SongsRepository) ListSongEvents(ctx context.Context, afterId int64, limit int) (res []models.SongEvent, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListSongEvents")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		WITH after AS (
+			SELECT tx_id, id FROM song_events WHERE id <= $1 ORDER BY id DESC LIMIT 1
+		)
+		SELECT e.id, e.type, e.song_id, e.created_at FROM song_events e
+		LEFT JOIN after a ON TRUE
+		WHERE `+committedEvents+` AND (a.id IS NULL OR (e.tx_id, e.id) > (a.tx_id, a.id))
+		ORDER BY e.tx_id, e.id
+		LIMIT $2
+		`,
+		afterId, limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.SongEvent
+		if err = rows.Scan(&e.Id, &e.Type, &e.SongId, &e.At); err != nil {
+			return
+		}
+		res = append(res, e)
+	}
+	err = rows.Err()
+	return
+}
+
+// LastSongEventId returns the id of the newest event ListSongEvents returns,
+// 0 if there is none.
+func (sr *SongsRepository) LastSongEventId(ctx context.Context) (id int64, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "LastSongEventId")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(ctx, `
+		SELECT COALESCE((
+			SELECT id FROM song_events WHERE `+committedEvents+` ORDER BY tx_id DESC, id DESC LIMIT 1
+		), 0)
+		`).Scan(&id)
+	return
+}
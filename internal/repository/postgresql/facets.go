@@ -0,0 +1,66 @@
+package postgresql
+
+import (
+	"context"
+	"slices"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// GetSongFacets counts the songs matching sq per value of each of facets in a
+// single statement, so all buckets describe the same snapshot.
+func (sr *SongsRepository) GetSongFacets(ctx context.Context, sq *models.SongsQuery, facets []string) (res models.SongFacets, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSongFacets")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		WITH filtered AS (
+			SELECT s.id, s.group_name, s.release_date FROM songs s
+			WHERE `+songsFilter+`
+		)
+		SELECT 'group', group_name, count(*) FROM filtered
+		WHERE `+param(1)+` AND group_name IS NOT NULL
+		GROUP BY 2
+		UNION ALL
+		SELECT 'year', extract(year FROM release_date)::int::text, count(*) FROM filtered
+		WHERE `+param(2)+` AND release_date IS NOT NULL
+		GROUP BY 2
+		UNION ALL
+		SELECT 'tag', t.name, count(*) FROM filtered f
+		JOIN song_tags st ON st.song_id = f.id
+		JOIN tags t ON t.id = st.tag_id
+		WHERE `+param(3)+`
+		GROUP BY 2
+		ORDER BY 1, 3 DESC, 2
+		`,
+		append(
+			songsFilterArgs(sq),
+			slices.Contains(facets, models.FacetGroup),
+			slices.Contains(facets, models.FacetYear),
+			slices.Contains(facets, models.FacetTag),
+		)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	res = make(models.SongFacets, len(facets))
+	for _, f := range facets {
+		// Requested facets are present even when nothing matches.
+		res[f] = []models.FacetBucket{}
+	}
+	for rows.Next() {
+		var (
+			facet string
+			b     models.FacetBucket
+		)
+		if err = rows.Scan(&facet, &b.Value, &b.Count); err != nil {
+			return
+		}
+		res[facet] = append(res[facet], b)
+	}
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// AddFavourite is a no-op if the song already is a favourite of the user.
+func (sr *SongsRepository) AddFavourite(ctx context.Context, userId, songId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "AddFavourite")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`INSERT INTO favourites (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		userId, songId,
+	)
+	return
+}
+
+func (sr *SongsRepository) RemoveFavourite(ctx context.Context, userId, songId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RemoveFavourite")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(ctx, `DELETE FROM favourites WHERE user_id = $1 AND song_id = $2`, userId, songId)
+	return
+}
+
+// GetFavourites paginates the favourites of userId matching sq, in sq.Sort
+// order or most recently added first.
+func (sr *SongsRepository) GetFavourites(ctx context.Context, userId int, sq *models.SongsQuery) (res []models.Song, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetFavourites")
+	defer done(&err)
+
+	args := append(songsFilterArgs(sq), userId)
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		SELECT count(*) FROM songs s
+		JOIN favourites f ON f.song_id = s.id AND f.user_id = `+param(1)+`
+		WHERE `+songsFilter+`
+		`,
+		args...,
+	)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.id, s.name, s.group_name, s.release_date, `+songTags+`, `+songPopularity+` FROM songs s
+		JOIN favourites f ON f.song_id = s.id AND f.user_id = `+param(1)+`
+		WHERE `+songsFilter+`
+		ORDER BY `+favouritesOrder(sq.Sort)+`
+		LIMIT `+param(2)+`
+		OFFSET `+param(3)+`
+		`,
+		append(args, sq.Max, sq.Max*sq.Page)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	favourite := true
+	for rows.Next() {
+		song := models.Song{IsFavourite: &favourite, Popularity: &models.Popularity{}}
+		dest := append([]any{&song.Id, &song.Name, &song.GroupName, &song.ReleaseDate, pq.Array(&song.Tags)}, scanPopularity(song.Popularity)...)
+		if err = rows.Scan(dest...); err != nil {
+			return
+		}
+		res = append(res, song)
+	}
+	err = rows.Err()
+	return
+}
+
+// favouritesOrder is songsOrder with the most recently added favourites
+// first by default.
+func favouritesOrder(sort string) string {
+	if sort == "" {
+		return "f.created_at DESC, s.id"
+	}
+	return songsOrder[sort]
+}
+
+// GetFavouriteIds returns which of songIds are favourites of userId.
+func (sr *SongsRepository) GetFavouriteIds(ctx context.Context, userId int, songIds []int) (res map[int]bool, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetFavouriteIds")
+	defer done(&err)
+
+	res = make(map[int]bool)
+	if len(songIds) == 0 {
+		return
+	}
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`SELECT song_id FROM favourites WHERE user_id = $1 AND song_id = ANY($2)`,
+		userId, pq.Array(songIds),
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		res[id] = true
+	}
+	err = rows.Err()
+	return
+}
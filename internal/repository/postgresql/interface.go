@@ -9,9 +9,69 @@ import (
 type SongsRepositoryI interface {
 	GetSong(ctx context.Context, sdq *models.SongDetailQuery) (models.SongDetail, error)
 	GetSongs(ctx context.Context, sq *models.SongsQuery) ([]models.Song, int, error)
-	CreateSong(ctx context.Context, scq *models.SongCreateQuery) error
+	GetSongFacets(ctx context.Context, sq *models.SongsQuery, facets []string) (models.SongFacets, error)
+	CreateSong(ctx context.Context, scq *models.SongCreateQuery) (int, error)
 	UpdateSong(ctx context.Context, su *models.SongUpdate, songId int) error
 	CheckIfExists(ctx context.Context, songId int) (bool, error)
 	GetSongText(ctx context.Context, songId int, pmq *models.PageMaxQuery) ([]string, int, error)
+	GetSongLines(ctx context.Context, songId int, pmq *models.PageMaxQuery) ([]models.LyricLine, int, error)
+	GetSyncedLyrics(ctx context.Context, songId int) (models.SyncedLyrics, error)
+	SetSyncedLyrics(ctx context.Context, songId int, lines []models.LyricLine) error
+	ListSongTexts(ctx context.Context, songId int) ([]models.SongTextInfo, error)
+	UpsertSongText(ctx context.Context, songId int, lang string, stu *models.SongTextUpsert) error
+	GetTranslation(ctx context.Context, songId int, lang string) (string, error)
+	GetTranslationLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) ([]string, int, error)
+	GetParallelLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) ([]models.ParallelLine, int, error)
+	GetSongStats(ctx context.Context, songId int, top int) (models.SongStats, error)
+	GetGroupStats(ctx context.Context, sq *models.StatsQuery) ([]models.GroupStats, int, error)
+	CountSongsByGroup(ctx context.Context, sq *models.SongsQuery) ([]models.GroupCount, int, error)
+	GetTopGroups(ctx context.Context, sq *models.SongsQuery) ([]models.GroupCount, error)
+	CountReleases(ctx context.Context, sq *models.SongsQuery, interval string) ([]models.ReleaseBucket, error)
+	GetRecentSongs(ctx context.Context, sq *models.SongsQuery) ([]models.RecentSong, error)
+	ListTags(ctx context.Context, tq *models.TagsQuery) ([]models.Tag, error)
+	GetTag(ctx context.Context, tagId int) (models.Tag, error)
+	CreateTag(ctx context.Context, tc *models.TagCreate) (models.Tag, error)
+	UpdateTag(ctx context.Context, tagId int, tu *models.TagUpdate) (models.Tag, error)
+	DeleteTag(ctx context.Context, tagId int) error
+	CountTags(ctx context.Context, sq *models.SongsQuery) ([]models.TagCount, error)
+	AddFavourite(ctx context.Context, userId, songId int) error
+	RemoveFavourite(ctx context.Context, userId, songId int) error
+	GetFavourites(ctx context.Context, userId int, sq *models.SongsQuery) ([]models.Song, int, error)
+	GetFavouriteIds(ctx context.Context, userId int, songIds []int) (map[int]bool, error)
+	CreatePlaylist(ctx context.Context, ownerId int, pc *models.PlaylistCreate) (models.Playlist, error)
+	GetPlaylist(ctx context.Context, playlistId int) (models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, playlistId int, pu *models.PlaylistUpdate) (models.Playlist, error)
+	DeletePlaylist(ctx context.Context, playlistId int) error
+	ListPlaylists(ctx context.Context, ownerId int, pmq *models.PageMaxQuery) ([]models.Playlist, int, error)
+	GetPlaylistEntries(ctx context.Context, playlistId int, pmq *models.PageMaxQuery) ([]models.PlaylistEntry, int, error)
+	ExportPlaylist(ctx context.Context, playlistId int) ([]models.PlaylistEntry, error)
+	AddPlaylistEntry(ctx context.Context, playlistId int, pea *models.PlaylistEntryAdd) (models.PlaylistEntry, error)
+	RemovePlaylistEntry(ctx context.Context, playlistId, entryId int) error
+	ReorderPlaylist(ctx context.Context, playlistId int, entryIds []int) error
+	RecordPlays(ctx context.Context, userId *int, plays []models.PlayEvent) (models.PlaysResult, error)
+	RateSong(ctx context.Context, userId, songId, stars int) error
+	DeleteRating(ctx context.Context, userId, songId int) error
+	GetSongRating(ctx context.Context, songId int, userId *int) (models.SongRating, error)
+	GetSimilarSongs(ctx context.Context, songId, limit int) ([]models.SimilarSong, error)
+	RefreshSimilarSongs(ctx context.Context, limit int) (int64, error)
+	DeleteSong(ctx context.Context, songId int) error
+	ListSongEvents(ctx context.Context, afterId int64, limit int) ([]models.SongEvent, error)
+	LastSongEventId(ctx context.Context) (int64, error)
+	CreateWebhook(ctx context.Context, wc *models.WebhookCreate) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookId int) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhookId int, wu *models.WebhookUpdate) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId int) error
+	ListDeliveries(ctx context.Context, dq *models.DeliveriesQuery) ([]models.WebhookDelivery, int, error)
+	RetryDelivery(ctx context.Context, deliveryId int64) error
+	EnqueueJob(ctx context.Context, je *models.JobEnqueue) (int64, error)
+	ListJobs(ctx context.Context, jq *models.JobsQuery) ([]models.Job, int, error)
+	CountJobs(ctx context.Context) ([]models.JobCount, error)
+	RetryJob(ctx context.Context, jobId int64) error
+	EnqueueEnrichment(ctx context.Context, songId int, fields []string) error
+	GetLinkSummary(ctx context.Context) (models.LinkSummary, error)
+	ListBrokenLinks(ctx context.Context, pmq *models.PageMaxQuery) ([]models.LinkCheck, int, error)
+	ListDuplicates(ctx context.Context, dq *models.DuplicatesQuery) ([]models.DuplicatePair, int, error)
+	MergeSongs(ctx context.Context, sm *models.SongMerge) (models.SongMerged, error)
 	Begin() (*Transaction, error)
 }
@@ -0,0 +1,224 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// JobsChannel is the LISTEN channel notified with the kind of every
+// enqueued job once its transaction commits.
+const JobsChannel = "jobs"
+
+const jobColumns = `j.id, j.kind, j.payload, j.key, j.status, j.attempts, j.run_at, j.last_error, j.created_at, j.finished_at`
+
+func scanJob(row interface{ Scan(...any) error }, j *models.Job) error {
+	var payload []byte
+	if err := row.Scan(
+		&j.Id, &j.Kind, &payload, &j.Key, &j.Status, &j.Attempts, &j.RunAt, &j.LastError, &j.CreatedAt, &j.FinishedAt,
+	); err != nil {
+		return err
+	}
+	j.Payload = payload
+	return nil
+}
+
+// EnqueueJob queues a job, in the current transaction if there is one, and
+// returns its id, or 0 if a job with the same key exists.
+func (sr *SongsRepository) EnqueueJob(ctx context.Context, je *models.JobEnqueue) (id int64, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "EnqueueJob")
+	defer done(&err)
+
+	payload := []byte(`{}`)
+	if je.Payload != nil {
+		if payload, err = json.Marshal(je.Payload); err != nil {
+			return
+		}
+	}
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		WITH job AS (
+			INSERT INTO jobs (kind, payload, run_at, key) VALUES ($1, $2, COALESCE($3, now()), NULLIF($4, ''))
+			ON CONFLICT (key) DO NOTHING
+			RETURNING id, kind
+		)
+		SELECT id, pg_notify('`+JobsChannel+`', kind) FROM job
+		`,
+		je.Kind, payload, je.RunAt, je.Key,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = rows.Scan(&id, new(any)); err != nil {
+			return
+		}
+	}
+	err = rows.Err()
+	return
+}
+
+// ClaimJobs starts up to limit due jobs of the given kinds: queued ones and
+// running ones whose lease expired because their worker died. They are
+// leased for lease, so that concurrent workers skip them.
+func (sr *SongsRepository) ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) (res []models.Job, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ClaimJobs")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		UPDATE jobs j SET status = 'running', attempts = j.attempts + 1, run_at = now() + make_interval(secs => $3)
+		WHERE j.id IN (
+			SELECT due.id FROM jobs due
+			WHERE due.status IN ('queued', 'running') AND due.run_at <= now() AND due.kind = ANY ($1)
+			ORDER BY due.run_at, due.id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		pq.Array(kinds), limit, lease.Seconds(),
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var j models.Job
+		if err = scanJob(rows, &j); err != nil {
+			return
+		}
+		res = append(res, j)
+	}
+	err = rows.Err()
+	return
+}
+
+// CompleteJob records a successful run.
+func (sr *SongsRepository) CompleteJob(ctx context.Context, jobId int64) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CompleteJob")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`UPDATE jobs SET status = 'done', finished_at = now(), last_error = NULL WHERE id = $1`,
+		jobId,
+	)
+	return
+}
+
+// FailJob records a failed run. The job is queued again for retryAt, or dies
+// if retryAt is nil.
+func (sr *SongsRepository) FailJob(ctx context.Context, jobId int64, msg string, retryAt *time.Time) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "FailJob")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		UPDATE jobs SET
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'dead' ELSE 'queued' END,
+			run_at = COALESCE($3, run_at),
+			finished_at = CASE WHEN $3::timestamptz IS NULL THEN now() END,
+			last_error = $2
+		WHERE id = $1
+		`,
+		jobId, msg, retryAt,
+	)
+	return
+}
+
+// PurgeJobs deletes the jobs done before t and returns how many.
+func (sr *SongsRepository) PurgeJobs(ctx context.Context, t time.Time) (n int64, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "PurgeJobs")
+	defer done(&err)
+
+	res, err := sr.pool.ExecContext(ctx, `DELETE FROM jobs WHERE status = 'done' AND finished_at < $1`, t)
+	if err != nil {
+		return
+	}
+	return res.RowsAffected()
+}
+
+// ListJobs paginates jobs, newest first, optionally of one kind or in one
+// status.
+func (sr *SongsRepository) ListJobs(ctx context.Context, jq *models.JobsQuery) (res []models.Job, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListJobs")
+	defer done(&err)
+
+	filter := `(j.kind = $1 OR $1 = '') AND (j.status = $2 OR $2 = '')`
+	row := sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM jobs j WHERE `+filter, jq.Kind, jq.Status)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT `+jobColumns+` FROM jobs j
+		WHERE `+filter+`
+		ORDER BY j.id DESC
+		LIMIT $3
+		OFFSET $4
+		`,
+		jq.Kind, jq.Status, jq.Max, jq.Max*jq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var j models.Job
+		if err = scanJob(rows, &j); err != nil {
+			return
+		}
+		res = append(res, j)
+	}
+	err = rows.Err()
+	return
+}
+
+// CountJobs counts the jobs of every kind in every status.
+func (sr *SongsRepository) CountJobs(ctx context.Context) (res []models.JobCount, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CountJobs")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(ctx, `SELECT kind, status, count(*) FROM jobs GROUP BY kind, status ORDER BY kind, status`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var jc models.JobCount
+		if err = rows.Scan(&jc.Kind, &jc.Status, &jc.Count); err != nil {
+			return
+		}
+		res = append(res, jc)
+	}
+	err = rows.Err()
+	return
+}
+
+// RetryJob queues a dead job again with a fresh set of attempts. It returns
+// sql.ErrNoRows unless the job is dead.
+func (sr *SongsRepository) RetryJob(ctx context.Context, jobId int64) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RetryJob")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(
+		ctx,
+		`
+		UPDATE jobs SET status = 'queued', attempts = 0, run_at = now(), finished_at = NULL
+		WHERE id = $1 AND status = 'dead'
+		RETURNING pg_notify('`+JobsChannel+`', kind)
+		`,
+		jobId,
+	).Scan(new(any))
+	return
+}
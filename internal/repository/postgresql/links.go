@@ -0,0 +1,146 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// LinksToCheck returns up to limit song links never checked, changed since
+// their check or checked before t, the longest unchecked first.
+func (sr *SongsRepository) LinksToCheck(ctx context.Context, t time.Time, limit int) (res []models.LinkTarget, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "LinksToCheck")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.id, s.link FROM songs s
+		LEFT JOIN song_links sl ON sl.song_id = s.id AND sl.url = s.link
+		WHERE COALESCE(s.link, '') <> '' AND (sl.checked_at IS NULL OR sl.checked_at < $1)
+		ORDER BY sl.checked_at NULLS FIRST, s.id
+		LIMIT $2
+		`,
+		t, limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lt models.LinkTarget
+		if err = rows.Scan(&lt.SongId, &lt.URL); err != nil {
+			return
+		}
+		res = append(res, lt)
+	}
+	err = rows.Err()
+	return
+}
+
+// SaveLinkChecks stores the outcome of checks, replacing the previous ones.
+// Checks of songs deleted meanwhile are dropped.
+func (sr *SongsRepository) SaveLinkChecks(ctx context.Context, checks []models.LinkCheck) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "SaveLinkChecks")
+	defer done(&err)
+
+	songIds := make([]int, len(checks))
+	urls := make([]string, len(checks))
+	statusCodes := make([]sql.NullInt64, len(checks))
+	redirects := make([]sql.NullString, len(checks))
+	errs := make([]sql.NullString, len(checks))
+	broken := make([]bool, len(checks))
+	for i, lc := range checks {
+		songIds[i], urls[i], broken[i] = lc.SongId, lc.URL, lc.Broken
+		if lc.StatusCode != nil {
+			statusCodes[i] = sql.NullInt64{Int64: int64(*lc.StatusCode), Valid: true}
+		}
+		if lc.RedirectURL != nil {
+			redirects[i] = sql.NullString{String: *lc.RedirectURL, Valid: true}
+		}
+		if lc.Error != nil {
+			errs[i] = sql.NullString{String: *lc.Error, Valid: true}
+		}
+	}
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		INSERT INTO song_links (song_id, url, status_code, redirect_url, error, broken)
+		SELECT c.song_id, c.url, c.status_code, c.redirect_url, c.error, c.broken
+		FROM unnest($1::int[], $2::text[], $3::int[], $4::text[], $5::text[], $6::boolean[])
+			AS c(song_id, url, status_code, redirect_url, error, broken)
+		WHERE EXISTS (SELECT 1 FROM songs s WHERE s.id = c.song_id)
+		ON CONFLICT (song_id) DO UPDATE SET
+			url = EXCLUDED.url, status_code = EXCLUDED.status_code, redirect_url = EXCLUDED.redirect_url,
+			error = EXCLUDED.error, broken = EXCLUDED.broken, checked_at = now()
+		`,
+		pq.Array(songIds), pq.Array(urls), pq.Array(statusCodes), pq.Array(redirects), pq.Array(errs), pq.Array(broken),
+	)
+	return
+}
+
+// currentLinks joins the songs s with the checks sl of their current link.
+const currentLinks = `songs s JOIN song_links sl ON sl.song_id = s.id AND sl.url = s.link`
+
+func (sr *SongsRepository) GetLinkSummary(ctx context.Context) (ls models.LinkSummary, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetLinkSummary")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(
+		ctx,
+		`
+		SELECT
+			count(*) FILTER (WHERE COALESCE(s.link, '') <> ''),
+			count(sl.song_id),
+			count(*) FILTER (WHERE sl.broken),
+			count(sl.redirect_url)
+		FROM songs s
+		LEFT JOIN song_links sl ON sl.song_id = s.id AND sl.url = s.link
+		`,
+	).Scan(&ls.Links, &ls.Checked, &ls.Broken, &ls.Redirected)
+	return
+}
+
+// ListBrokenLinks paginates the songs whose current link was found broken,
+// most recently checked first.
+func (sr *SongsRepository) ListBrokenLinks(ctx context.Context, pmq *models.PageMaxQuery) (res []models.LinkCheck, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListBrokenLinks")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM `+currentLinks+` WHERE sl.broken`)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT s.id, COALESCE(s.group_name, ''), COALESCE(s.name, ''), sl.url, sl.status_code, sl.redirect_url, sl.error, sl.broken, sl.checked_at
+		FROM `+currentLinks+`
+		WHERE sl.broken
+		ORDER BY sl.checked_at DESC, s.id
+		LIMIT $1
+		OFFSET $2
+		`,
+		pmq.Max, pmq.Max*pmq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lc models.LinkCheck
+		if err = rows.Scan(
+			&lc.SongId, &lc.Group, &lc.Song, &lc.URL, &lc.StatusCode, &lc.RedirectURL, &lc.Error, &lc.Broken, &lc.CheckedAt,
+		); err != nil {
+			return
+		}
+		res = append(res, lc)
+	}
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,187 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// scanLyricLine reads number, line, start_ms and words columns.
+func scanLyricLine(rows *sql.Rows) (l models.LyricLine, err error) {
+	var (
+		startMs sql.NullInt64
+		words   []byte
+	)
+	if err = rows.Scan(&l.Number, &l.Text, &startMs, &words); err != nil {
+		return
+	}
+	if startMs.Valid {
+		ms := int(startMs.Int64)
+		l.StartMs = &ms
+	}
+	if words != nil {
+		err = json.Unmarshal(words, &l.Words)
+	}
+	return
+}
+
+// GetSongLines is GetSongText with the timestamp of every returned line.
+func (sr *SongsRepository) GetSongLines(ctx context.Context, songId int, pmq *models.PageMaxQuery) (res []models.LyricLine, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSongLines")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		songLinesCTE+`
+		SELECT count(line)
+		FROM split_text
+		`,
+		songId,
+	)
+	if err = row.Scan(&amount); err != nil {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		songLinesCTE+`
+		SELECT st.line_number, st.line, lt.start_ms, lt.words
+		FROM split_text st
+		LEFT JOIN song_line_timestamps lt ON lt.song_id = $1 AND lt.line_number = st.line_number
+		ORDER BY st.line_number
+		OFFSET $2
+		LIMIT $3
+		`,
+		songId,
+		pmq.Page*pmq.Max,
+		pmq.Max,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line models.LyricLine
+		if line, err = scanLyricLine(rows); err != nil {
+			return
+		}
+		res = append(res, line)
+	}
+	err = rows.Err()
+	return
+}
+
+// GetSyncedLyrics returns every line of the song with its timestamp, or
+// sql.ErrNoRows when the song does not exist.
+func (sr *SongsRepository) GetSyncedLyrics(ctx context.Context, songId int) (sl models.SyncedLyrics, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSyncedLyrics")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT s.name, s.group_name FROM songs s WHERE s.id = $1`, songId)
+	if err = row.Scan(&sl.Title, &sl.Artist); err != nil {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		songLinesCTE+`
+		SELECT st.line_number, st.line, lt.start_ms, lt.words
+		FROM split_text st
+		LEFT JOIN song_line_timestamps lt ON lt.song_id = $1 AND lt.line_number = st.line_number
+		ORDER BY st.line_number
+		`,
+		songId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line models.LyricLine
+		if line, err = scanLyricLine(rows); err != nil {
+			return
+		}
+		sl.Lines = append(sl.Lines, line)
+	}
+	err = rows.Err()
+	return
+}
+
+// ErrLyricsMismatch is returned by SetSyncedLyrics when the timed lines
+// aren't the lines of the song text.
+var ErrLyricsMismatch = errors.New("the timed lines must be the non-blank lines of the song text, in order")
+
+// SetSyncedLyrics stores the timestamps of lines, ordered by time, against
+// the non-blank lines of the song text they repeat; the text itself is
+// left as it is. Lines compare ignoring surrounding and repeated
+// whitespace.
+func (sr *SongsRepository) SetSyncedLyrics(ctx context.Context, songId int, lines []models.LyricLine) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "SetSyncedLyrics")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		songLinesCTE+`
+		SELECT line_number, line FROM split_text
+		WHERE btrim(line) <> ''
+		ORDER BY line_number
+		`,
+		songId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	var numbers []int
+	for rows.Next() {
+		var (
+			number int
+			line   string
+		)
+		if err = rows.Scan(&number, &line); err != nil {
+			return
+		}
+		if len(numbers) >= len(lines) || !sameLine(line, lines[len(numbers)].Text) {
+			return ErrLyricsMismatch
+		}
+		numbers = append(numbers, number)
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+	if len(numbers) != len(lines) {
+		return ErrLyricsMismatch
+	}
+
+	if _, err = sr.pool.ExecContext(ctx, `DELETE FROM song_line_timestamps WHERE song_id = $1`, songId); err != nil {
+		return
+	}
+	for i, l := range lines {
+		if l.StartMs == nil {
+			continue
+		}
+		var words []byte
+		if len(l.Words) > 0 {
+			if words, err = json.Marshal(l.Words); err != nil {
+				return
+			}
+		}
+		if _, err = sr.pool.ExecContext(
+			ctx,
+			`INSERT INTO song_line_timestamps (song_id, line_number, start_ms, words) VALUES ($1, $2, $3, $4)`,
+			songId, numbers[i], *l.StartMs, words,
+		); err != nil {
+			return
+		}
+	}
+	err = sr.addSongEvent(ctx, models.SongEventUpdate, songId)
+	return
+}
+
+func sameLine(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
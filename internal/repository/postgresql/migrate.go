@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/migrations"
+)
+
+// migrationLockKey identifies the advisory lock serialising migrations
+// between instances. golang-migrate takes its own lock as well, but gives up
+// after migrate.DefaultLockTimeout; this one waits for as long as ctx allows.
+const migrationLockKey = 7_315_220_041
+
+// Migrate runs fn against the embedded migrations while holding the
+// migration advisory lock. Connections are taken from db and returned
+// afterwards; db itself stays open.
+func Migrate(ctx context.Context, db *sql.DB, fn func(m *migrate.Migrate) error) (err error) {
+	lock, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if _, err = lock.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return err
+	}
+	defer lock.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	// The driver owns conn from here on and closes it with m.
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return err
+	}
+	defer func() {
+		srcErr, dbErr := m.Close()
+		err = errors.Join(err, srcErr, dbErr)
+	}()
+
+	return fn(m)
+}
+
+// MigrateUp applies all pending migrations. Having nothing to apply is not
+// an error.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	return Migrate(ctx, db, func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+		return nil
+	})
+}
+
+// MigrationVersion reads the applied version without taking the migration
+// lock. ok is false when no migration has been applied yet.
+func MigrationVersion(ctx context.Context, db *sql.DB) (version uint, dirty, ok bool, err error) {
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, false, nil
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+		// undefined_table: migrations never ran.
+		return 0, false, false, nil
+	}
+	return version, dirty, err == nil, err
+}
+
+// LatestMigration is the highest version embedded in the binary.
+func LatestMigration() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	v, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		v = next
+	}
+}
@@ -0,0 +1,37 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/metrics"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+// observe wraps a repository method with its configured deadline, a span, a
+// duration metric and a debug line on the request-scoped logger:
+//
+//	ctx, done := observe(ctx, sr.timeouts, "GetSongs")
+//	defer done(&err)
+func observe(ctx context.Context, timeouts Timeouts, method string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeouts.For(method))
+	ctx, span := tracing.Start(ctx, "repository."+method)
+	return ctx, func(err *error) {
+		defer cancel()
+		metrics.ObserveQuery(method, start, err)
+		tracing.End(span, err)
+		entry := utils.Log(ctx).WithFields(log.Fields{
+			"method":   method,
+			"duration": time.Since(start).String(),
+		})
+		if *err != nil {
+			entry.WithError(*err).Debug("query failed")
+		} else {
+			entry.Debug("query done")
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// ErrInvalidOrder is returned by ReorderPlaylist when the entry ids aren't
+// exactly those of the playlist.
+var ErrInvalidOrder = errors.New("entry ids must list every entry of the playlist once")
+
+const playlistColumns = `p.id, p.owner_id, p.title, p.visibility,
+			(SELECT count(*) FROM playlist_entries e WHERE e.playlist_id = p.id),
+			p.created_at, p.updated_at`
+
+func scanPlaylist(row interface{ Scan(...any) error }, p *models.Playlist) error {
+	return row.Scan(&p.Id, &p.OwnerId, &p.Title, &p.Visibility, &p.Entries, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// lockPlaylist serializes changes to the entries of a playlist until the
+// end of the transaction and marks it updated.
+func (sr *SongsRepository) lockPlaylist(ctx context.Context, playlistId int) error {
+	res, err := sr.pool.ExecContext(ctx, `UPDATE playlists SET updated_at = now() WHERE id = $1`, playlistId)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// removeSongEntries drops the entries of songId from every playlist and
+// renumbers the rest, so that positions stay gapless.
+func (sr *SongsRepository) removeSongEntries(ctx context.Context, songId int) error {
+	var playlistIds pq.Int64Array
+	if err := sr.pool.QueryRowContext(
+		ctx,
+		`
+		WITH locked AS (
+			UPDATE playlists SET updated_at = now()
+			WHERE id IN (SELECT playlist_id FROM playlist_entries WHERE song_id = $1)
+			RETURNING id
+		)
+		SELECT COALESCE(array_agg(id), '{}') FROM locked
+		`,
+		songId,
+	).Scan(&playlistIds); err != nil || len(playlistIds) == 0 {
+		return err
+	}
+	if _, err := sr.pool.ExecContext(ctx, `DELETE FROM playlist_entries WHERE song_id = $1`, songId); err != nil {
+		return err
+	}
+	_, err := sr.pool.ExecContext(
+		ctx,
+		`
+		UPDATE playlist_entries e SET position = r.position
+		FROM (
+			SELECT id, row_number() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+			FROM playlist_entries WHERE playlist_id = ANY($1)
+		) r
+		WHERE e.id = r.id AND e.position <> r.position
+		`,
+		playlistIds,
+	)
+	return err
+}
+
+func (sr *SongsRepository) CreatePlaylist(ctx context.Context, ownerId int, pc *models.PlaylistCreate) (p models.Playlist, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CreatePlaylist")
+	defer done(&err)
+
+	visibility := pc.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPrivate
+	}
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		INSERT INTO playlists AS p (owner_id, title, visibility) VALUES ($1, $2, $3)
+		RETURNING `+playlistColumns,
+		ownerId, pc.Title, visibility,
+	)
+	err = scanPlaylist(row, &p)
+	return
+}
+
+// GetPlaylist returns sql.ErrNoRows if there is no playlist playlistId.
+func (sr *SongsRepository) GetPlaylist(ctx context.Context, playlistId int) (p models.Playlist, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetPlaylist")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT `+playlistColumns+` FROM playlists p WHERE p.id = $1`, playlistId)
+	err = scanPlaylist(row, &p)
+	return
+}
+
+func (sr *SongsRepository) UpdatePlaylist(ctx context.Context, playlistId int, pu *models.PlaylistUpdate) (p models.Playlist, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "UpdatePlaylist")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		UPDATE playlists AS p SET
+			title = COALESCE($1, title),
+			visibility = COALESCE($2, visibility),
+			updated_at = now()
+		WHERE p.id = $3
+		RETURNING `+playlistColumns,
+		pu.Title, pu.Visibility, playlistId,
+	)
+	err = scanPlaylist(row, &p)
+	return
+}
+
+func (sr *SongsRepository) DeletePlaylist(ctx context.Context, playlistId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "DeletePlaylist")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(ctx, `DELETE FROM playlists WHERE id = $1`, playlistId)
+	return
+}
+
+// ListPlaylists paginates the playlists of ownerId, most recently updated
+// first.
+func (sr *SongsRepository) ListPlaylists(ctx context.Context, ownerId int, pmq *models.PageMaxQuery) (res []models.Playlist, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListPlaylists")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM playlists WHERE owner_id = $1`, ownerId)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT `+playlistColumns+` FROM playlists p
+		WHERE p.owner_id = $1
+		ORDER BY p.updated_at DESC, p.id DESC
+		LIMIT $2
+		OFFSET $3
+		`,
+		ownerId, pmq.Max, pmq.Max*pmq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.Playlist
+		if err = scanPlaylist(rows, &p); err != nil {
+			return
+		}
+		res = append(res, p)
+	}
+	err = rows.Err()
+	return
+}
+
+const playlistEntriesQuery = `
+		SELECT e.id, e.position, e.added_at, s.id, s.name, s.group_name, s.release_date, COALESCE(s.link, ''), ` + songTags + `
+		FROM playlist_entries e
+		JOIN songs s ON s.id = e.song_id
+		WHERE e.playlist_id = $1`
+
+func scanPlaylistEntries(rows *sql.Rows) (res []models.PlaylistEntry, err error) {
+	for rows.Next() {
+		var e models.PlaylistEntry
+		if err = rows.Scan(
+			&e.Id, &e.Position, &e.AddedAt,
+			&e.Song.Id, &e.Song.Name, &e.Song.GroupName, &e.Song.ReleaseDate, &e.Song.Link, pq.Array(&e.Song.Tags),
+		); err != nil {
+			return
+		}
+		res = append(res, e)
+	}
+	err = rows.Err()
+	return
+}
+
+func (sr *SongsRepository) GetPlaylistEntries(ctx context.Context, playlistId int, pmq *models.PageMaxQuery) (res []models.PlaylistEntry, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetPlaylistEntries")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM playlist_entries WHERE playlist_id = $1`, playlistId)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(ctx, playlistEntriesQuery+`
+		ORDER BY e.position
+		LIMIT $2
+		OFFSET $3
+		`,
+		playlistId, pmq.Max, pmq.Max*pmq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	res, err = scanPlaylistEntries(rows)
+	return
+}
+
+// ExportPlaylist returns every entry of a playlist in order.
+func (sr *SongsRepository) ExportPlaylist(ctx context.Context, playlistId int) (res []models.PlaylistEntry, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ExportPlaylist")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(ctx, playlistEntriesQuery+` ORDER BY e.position`, playlistId)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	res, err = scanPlaylistEntries(rows)
+	return
+}
+
+// AddPlaylistEntry inserts songId at position, or appends it when position
+// is nil or past the end.
+func (sr *SongsRepository) AddPlaylistEntry(ctx context.Context, playlistId int, pea *models.PlaylistEntryAdd) (e models.PlaylistEntry, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "AddPlaylistEntry")
+	defer done(&err)
+
+	if err = sr.lockPlaylist(ctx, playlistId); err != nil {
+		return
+	}
+	var size int
+	if err = sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM playlist_entries WHERE playlist_id = $1`, playlistId).Scan(&size); err != nil {
+		return
+	}
+	position := size + 1
+	if pea.Position != nil && *pea.Position < position {
+		position = *pea.Position
+		if _, err = sr.pool.ExecContext(
+			ctx,
+			`UPDATE playlist_entries SET position = position + 1 WHERE playlist_id = $1 AND position >= $2`,
+			playlistId, position,
+		); err != nil {
+			return
+		}
+	}
+
+	var entryId int
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`INSERT INTO playlist_entries (playlist_id, song_id, position) VALUES ($1, $2, $3) RETURNING id`,
+		playlistId, pea.SongId, position,
+	).Scan(&entryId); err != nil {
+		return
+	}
+	rows, err := sr.pool.QueryContext(ctx, playlistEntriesQuery+` AND e.id = $2`, playlistId, entryId)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	entries, err := scanPlaylistEntries(rows)
+	if err == nil && len(entries) == 1 {
+		e = entries[0]
+	}
+	return
+}
+
+// RemovePlaylistEntry closes the gap left by the entry. It returns
+// sql.ErrNoRows if the playlist has no entry entryId.
+func (sr *SongsRepository) RemovePlaylistEntry(ctx context.Context, playlistId, entryId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RemovePlaylistEntry")
+	defer done(&err)
+
+	if err = sr.lockPlaylist(ctx, playlistId); err != nil {
+		return
+	}
+	var position int
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`DELETE FROM playlist_entries WHERE playlist_id = $1 AND id = $2 RETURNING position`,
+		playlistId, entryId,
+	).Scan(&position); err != nil {
+		return
+	}
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`UPDATE playlist_entries SET position = position - 1 WHERE playlist_id = $1 AND position > $2`,
+		playlistId, position,
+	)
+	return
+}
+
+// ReorderPlaylist gives the entries the positions of their ids in entryIds.
+func (sr *SongsRepository) ReorderPlaylist(ctx context.Context, playlistId int, entryIds []int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ReorderPlaylist")
+	defer done(&err)
+
+	if err = sr.lockPlaylist(ctx, playlistId); err != nil {
+		return
+	}
+	var current pq.Int64Array
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM playlist_entries WHERE playlist_id = $1`,
+		playlistId,
+	).Scan(&current); err != nil {
+		return
+	}
+	wanted := make([]int64, len(entryIds))
+	for i, id := range entryIds {
+		wanted[i] = int64(id)
+	}
+	slices.Sort(wanted)
+	if !slices.Equal(wanted, []int64(current)) {
+		return ErrInvalidOrder
+	}
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		UPDATE playlist_entries e SET position = o.position
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE e.id = o.id AND e.playlist_id = $1
+		`,
+		playlistId, pq.Array(entryIds),
+	)
+	return
+}
@@ -0,0 +1,133 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// RecordPlays stores the plays of a batch and adds them to the play counts
+// in one statement. Plays with a known event id or an unknown song are
+// ignored. userId is nil for anonymous plays.
+func (sr *SongsRepository) RecordPlays(ctx context.Context, userId *int, plays []models.PlayEvent) (res models.PlaysResult, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RecordPlays")
+	defer done(&err)
+
+	eventIds := make([]string, len(plays))
+	songIds := make([]int64, len(plays))
+	playedAt := make([]string, len(plays))
+	now := time.Now()
+	for i, p := range plays {
+		eventIds[i], songIds[i] = p.EventId, int64(p.SongId)
+		at := now
+		if p.PlayedAt != nil {
+			at = *p.PlayedAt
+		}
+		playedAt[i] = at.Format(time.RFC3339Nano)
+	}
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		WITH inserted AS (
+			INSERT INTO song_plays (event_id, song_id, user_id, played_at)
+			SELECT p.event_id, p.song_id, $4, p.played_at
+			FROM unnest($1::text[], $2::int[], $3::timestamptz[]) AS p(event_id, song_id, played_at)
+			WHERE EXISTS (SELECT 1 FROM songs s WHERE s.id = p.song_id)
+			ON CONFLICT (event_id) DO NOTHING
+			RETURNING song_id
+		),
+		counted AS (
+			UPDATE songs s SET play_count = s.play_count + c.plays
+			FROM (SELECT song_id, count(*) AS plays FROM inserted GROUP BY song_id) c
+			WHERE s.id = c.song_id
+		)
+		SELECT count(*) FROM inserted
+		`,
+		pq.Array(eventIds), pq.Array(songIds), pq.Array(playedAt), userId,
+	)
+	if err = row.Scan(&res.Recorded); err != nil {
+		return
+	}
+	res.Ignored = len(plays) - res.Recorded
+	return
+}
+
+// RateSong sets the stars userId gives songId, replacing an earlier rating,
+// and updates the song's aggregate. The song row is locked first so that
+// concurrent ratings see each other's stars.
+func (sr *SongsRepository) RateSong(ctx context.Context, userId, songId, stars int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RateSong")
+	defer done(&err)
+
+	if _, err = sr.pool.ExecContext(ctx, `SELECT 1 FROM songs WHERE id = $1 FOR UPDATE`, songId); err != nil {
+		return
+	}
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		WITH old AS (
+			SELECT stars FROM ratings WHERE user_id = $1 AND song_id = $2
+		),
+		rated AS (
+			INSERT INTO ratings (user_id, song_id, stars) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, song_id) DO UPDATE SET stars = EXCLUDED.stars, updated_at = now()
+			RETURNING stars
+		)
+		UPDATE songs SET
+			rating_sum = rating_sum + (SELECT stars FROM rated) - COALESCE((SELECT stars FROM old), 0),
+			rating_count = rating_count + CASE WHEN EXISTS (SELECT 1 FROM old) THEN 0 ELSE 1 END
+		WHERE id = $2
+		`,
+		userId, songId, stars,
+	)
+	return
+}
+
+// DeleteRating returns sql.ErrNoRows if userId hasn't rated songId. Like
+// RateSong, it locks the song row before touching ratings.
+func (sr *SongsRepository) DeleteRating(ctx context.Context, userId, songId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "DeleteRating")
+	defer done(&err)
+
+	if _, err = sr.pool.ExecContext(ctx, `SELECT 1 FROM songs WHERE id = $1 FOR UPDATE`, songId); err != nil {
+		return
+	}
+	var stars int
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`DELETE FROM ratings WHERE user_id = $1 AND song_id = $2 RETURNING stars`,
+		userId, songId,
+	).Scan(&stars); err != nil {
+		return
+	}
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`UPDATE songs SET rating_sum = rating_sum - $1, rating_count = rating_count - 1 WHERE id = $2`,
+		stars, songId,
+	)
+	return
+}
+
+// GetSongRating returns the aggregates of songId, with the stars of userId
+// when it isn't nil. It returns sql.ErrNoRows for an unknown song.
+func (sr *SongsRepository) GetSongRating(ctx context.Context, songId int, userId *int) (res models.SongRating, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSongRating")
+	defer done(&err)
+
+	res.SongId = songId
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		SELECT `+songPopularity+`,
+			(SELECT r.stars FROM ratings r WHERE r.song_id = s.id AND r.user_id = $2)
+		FROM songs s WHERE s.id = $1
+		`,
+		songId, userId,
+	)
+	err = row.Scan(append(scanPopularity(&res.Popularity), &res.Mine)...)
+	return
+}
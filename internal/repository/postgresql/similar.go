@@ -0,0 +1,194 @@
+package postgresql
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// Weights of the parts of a similarity score; they sum to 1.
+const (
+	similarLyricsWeight = "0.6"
+	similarTagsWeight   = "0.3"
+	similarGroupWeight  = "0.1"
+)
+
+// similarityRanks builds CTEs ending in ranked, which scores every pair of
+// distinct songs that share a lyrics word, a tag or their group and numbers
+// the candidates of each song from 1, best first.
+//
+// Lyrics are compared by cosine similarity of tf-idf weighted words. Words
+// used by more than half of the songs are dropped: they weigh little and
+// would pair up nearly every song.
+var similarityRanks = `
+		WITH ` + splitLinesBy("split_text", "id,", "songs") + `,
+		` + wordsFrom("id,") + `,
+		tf AS (
+			SELECT id, word, count(*) AS n FROM words GROUP BY id, word
+		),
+		corpus AS (
+			SELECT count(*)::float8 AS songs FROM songs
+		),
+		idf AS (
+			SELECT word, ln((SELECT songs FROM corpus) / count(*)) AS idf FROM tf
+			GROUP BY word
+			HAVING count(*) <= (SELECT songs FROM corpus) / 2
+		),
+		weights AS (
+			SELECT tf.id, tf.word, tf.n * idf.idf AS w FROM tf JOIN idf USING (word)
+		),
+		norms AS (
+			SELECT id, sqrt(sum(w * w)) AS norm FROM weights GROUP BY id
+		),
+		lyrics_pairs AS (
+			SELECT a.id AS song_id, b.id AS similar_id, sum(a.w * b.w) / (na.norm * nb.norm) AS score
+			FROM weights a
+			JOIN weights b ON b.word = a.word AND b.id <> a.id
+			JOIN norms na ON na.id = a.id
+			JOIN norms nb ON nb.id = b.id
+			GROUP BY a.id, b.id, na.norm, nb.norm
+		),
+		tag_counts AS (
+			SELECT song_id, count(*) AS n FROM song_tags GROUP BY song_id
+		),
+		tag_pairs AS (
+			SELECT a.song_id, b.song_id AS similar_id, count(*)::float8 / (ca.n + cb.n - count(*)) AS score
+			FROM song_tags a
+			JOIN song_tags b ON b.tag_id = a.tag_id AND b.song_id <> a.song_id
+			JOIN tag_counts ca ON ca.song_id = a.song_id
+			JOIN tag_counts cb ON cb.song_id = b.song_id
+			GROUP BY a.song_id, b.song_id, ca.n, cb.n
+		),
+		group_pairs AS (
+			SELECT a.id AS song_id, b.id AS similar_id FROM songs a
+			JOIN songs b ON b.group_name = a.group_name AND b.id <> a.id
+		),
+		pairs AS (
+			SELECT song_id, similar_id,
+				COALESCE(l.score, 0) AS lyrics_score,
+				COALESCE(t.score, 0) AS tags_score,
+				g.song_id IS NOT NULL AS same_group
+			FROM lyrics_pairs l
+			FULL JOIN tag_pairs t USING (song_id, similar_id)
+			FULL JOIN group_pairs g USING (song_id, similar_id)
+		),
+		scored AS (
+			SELECT *,
+				` + similarLyricsWeight + ` * lyrics_score + ` + similarTagsWeight + ` * tags_score
+				+ CASE WHEN same_group THEN ` + similarGroupWeight + ` ELSE 0 END AS score
+			FROM pairs
+		),
+		ranked AS (
+			SELECT *, row_number() OVER (PARTITION BY song_id ORDER BY score DESC, similar_id) AS rank
+			FROM scored
+		)`
+
+// similarSongColumns selects a models.SimilarSong from ranked rows r joined
+// with songs s, see scanSimilarSong.
+const similarSongColumns = `r.score, r.lyrics_score, r.tags_score, r.same_group,
+		s.id, s.name, s.group_name, s.release_date, ` + songTags + `, ` + songPopularity
+
+func scanSimilarSong(ss *models.SimilarSong) []any {
+	ss.Popularity = &models.Popularity{}
+	return append([]any{
+		&ss.Score, &ss.Reasons.Lyrics, &ss.Reasons.Tags, &ss.Reasons.SameGroup,
+		&ss.Id, &ss.Name, &ss.GroupName, &ss.ReleaseDate, pq.Array(&ss.Tags),
+	}, scanPopularity(ss.Popularity)...)
+}
+
+// similarRefreshInterval bounds how often songs without a stored ranking
+// queue a refresh.
+const similarRefreshInterval = time.Hour
+
+// GetSimilarSongs returns up to limit songs most similar to songId, from
+// the ranking stored by RefreshSimilarSongs. Scoring the catalogue takes
+// too long for a request, so a song without a ranking, such as one changed
+// since the last refresh, has none and queues a models.JobRefreshSimilar
+// job, at most one per similarRefreshInterval.
+func (sr *SongsRepository) GetSimilarSongs(ctx context.Context, songId, limit int) (res []models.SimilarSong, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSimilarSongs")
+	defer done(&err)
+
+	var stored bool
+	if err = sr.pool.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM similar_songs WHERE song_id = $1)`,
+		songId,
+	).Scan(&stored); err != nil {
+		return
+	}
+	if !stored {
+		slot := time.Now().Truncate(similarRefreshInterval).Unix()
+		_, err = sr.EnqueueJob(ctx, &models.JobEnqueue{
+			Kind: models.JobRefreshSimilar,
+			Key:  models.JobRefreshSimilar + "@" + strconv.FormatInt(slot, 10),
+		})
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT `+similarSongColumns+`
+		FROM similar_songs r JOIN songs s ON s.id = r.similar_id
+		WHERE r.song_id = $1 AND r.rank <= $2
+		ORDER BY r.rank
+		`,
+		songId, limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ss models.SimilarSong
+		if err = rows.Scan(scanSimilarSong(&ss)...); err != nil {
+			return
+		}
+		res = append(res, ss)
+	}
+	err = rows.Err()
+	return
+}
+
+// RefreshSimilarSongs replaces the stored rankings with the best limit
+// songs for every song and returns the number of rows stored. Run it in a
+// transaction so that readers keep the old rankings until it commits.
+func (sr *SongsRepository) RefreshSimilarSongs(ctx context.Context, limit int) (stored int64, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RefreshSimilarSongs")
+	defer done(&err)
+
+	// The deadline of ctx bounds the computation rather than the
+	// statement_timeout meant for requests.
+	if _, err = sr.pool.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		return
+	}
+	if _, err = sr.pool.ExecContext(ctx, `DELETE FROM similar_songs`); err != nil {
+		return
+	}
+	res, err := sr.pool.ExecContext(
+		ctx,
+		`
+		INSERT INTO similar_songs (song_id, similar_id, rank, score, lyrics_score, tags_score, same_group)
+		`+similarityRanks+`
+		SELECT song_id, similar_id, rank, score, lyrics_score, tags_score, same_group
+		FROM ranked WHERE rank <= $1
+		`,
+		limit,
+	)
+	if err != nil {
+		return
+	}
+	return res.RowsAffected()
+}
+
+// forgetSimilarSongs drops the stored ranking of songId after its text, tags
+// or group changed, so that it is refreshed the next time it is asked for.
+func (sr *SongsRepository) forgetSimilarSongs(ctx context.Context, songId int) error {
+	_, err := sr.pool.ExecContext(ctx, `DELETE FROM similar_songs WHERE song_id = $1`, songId)
+	return err
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"strconv"
-	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
 )
@@ -15,11 +16,37 @@ type executor interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
+// splitLines builds a CTE named name holding the lines of the text column of
+// source, numbered from 1. Every query addressing lines by number must go
+// through it so that numbers agree.
+func splitLines(name, source string) string {
+	return splitLinesBy(name, "", source)
+}
+
+// splitLinesBy is splitLines that also selects keys, a comma-terminated
+// column list such as "id, group_name,".
+func splitLinesBy(name, keys, source string) string {
+	return name + ` AS (
+			SELECT
+				` + keys + `
+				UNNEST(STRING_TO_ARRAY(text, E'\n')) AS line,
+				generate_subscripts(STRING_TO_ARRAY(text, E'\n'), 1) AS line_number
+			FROM ` + source + `
+		)`
+}
+
+// songLinesCTE splits the text of song $1 into split_text.
+var songLinesCTE = `
+		WITH ` + splitLines("split_text", "songs WHERE id = $1")
+
 type SongsRepository struct {
-	pool executor
-	db   *sql.DB
+	pool     executor
+	db       *sql.DB
+	timeouts Timeouts
 }
 
+// Transaction is returned by Begin. The zero value is a no-op, which lets
+// repository mocks hand one out.
 type Transaction struct {
 	repo *SongsRepository
 }
@@ -31,6 +58,12 @@ func NewSongsRepository(pool *sql.DB) *SongsRepository {
 	}
 }
 
+// WithTimeouts sets per-method deadlines and returns sr for chaining.
+func (sr *SongsRepository) WithTimeouts(t Timeouts) *SongsRepository {
+	sr.timeouts = t
+	return sr
+}
+
 func (sr *SongsRepository) Begin() (tr *Transaction, err error) {
 	tx, err := sr.db.Begin()
 	if err != nil {
@@ -44,60 +77,107 @@ func (sr *SongsRepository) Begin() (tr *Transaction, err error) {
 }
 
 func (tr *Transaction) Commit() error {
+	if tr.repo == nil {
+		return nil
+	}
 	err := tr.repo.pool.(*sql.Tx).Commit()
 	tr.repo.pool = tr.repo.db
 	return err
 }
 
 func (tr *Transaction) Rollback() error {
+	if tr.repo == nil {
+		return nil
+	}
 	err := tr.repo.pool.(*sql.Tx).Rollback()
 	tr.repo.pool = tr.repo.db
 	return err
 }
 
-func (sr *SongsRepository) GetSong(ctx context.Context, sdq *models.SongDetailQuery) (models.SongDetail, error) {
-	var sm models.SongDetail
+func (sr *SongsRepository) GetSong(ctx context.Context, sdq *models.SongDetailQuery) (sm models.SongDetail, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSong")
+	defer done(&err)
 	var textLen int
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
 
 	row := sr.pool.QueryRowContext(
 		ctx,
 		`
-		SELECT s.id, s.name, s.group_name, substring(s.text for 1024), s.release_date, char_length(s.text), s.link FROM songs s
+		SELECT s.id, s.name, s.group_name, substring(s.text for 1024), s.release_date, char_length(s.text), s.link, `+songTags+`, s.enrichment_status FROM songs s
 		WHERE s.name = $1 AND s.group_name = $2
 		`,
 		sdq.Song,
 		sdq.Group,
 	)
-	err := row.Scan(&sm.Id, &sm.Name, &sm.GroupName, &sm.Text, &sm.ReleaseDate, &textLen, &sm.Link)
+	err = row.Scan(&sm.Id, &sm.Name, &sm.GroupName, &sm.Text, &sm.ReleaseDate, &textLen, &sm.Link, pq.Array(&sm.Tags), &sm.EnrichmentStatus)
 	if textLen > len(sm.Text) {
 		sm.Text += "..."
 	}
 	return sm, err
 }
 
-func (sr *SongsRepository) GetSongs(ctx context.Context, sq *models.SongsQuery) (res []models.Song, amount int, err error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// songsFilter is the WHERE condition of SongsQuery over songs s, taking
+// songsFilterArgs as its first songsFilterParams placeholders.
+const songsFilter = `(s.name = $1 OR $1 IS NULL)
+			AND (s.group_name = $2 OR $2 IS NULL)
+			AND (s.release_date = $3 OR $3 IS NULL)
+			AND (s.link = $4 OR $4 IS NULL)
+			AND ($5::text[] IS NULL OR (
+				SELECT CASE WHEN $6 = 'all' THEN count(*) = cardinality($5::text[]) ELSE count(*) > 0 END
+				FROM song_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.song_id = s.id AND t.name = ANY($5::text[])
+			))
+			AND ($7::boolean IS NULL OR $7 = EXISTS(
+				SELECT 1 FROM song_links sl WHERE sl.song_id = s.id AND sl.url = s.link AND sl.broken
+			))`
+
+const songsFilterParams = 7
 
+func songsFilterArgs(sq *models.SongsQuery) []any {
 	var releaseDate any
 	if sq.ReleaseDate != nil {
 		releaseDate = sq.ReleaseDate
 	} else {
 		releaseDate = sql.NullTime{}
 	}
+	var tags any = pq.StringArray(nil)
+	if t := models.NormalizeTags(sq.Tags); len(t) > 0 {
+		tags = pq.Array(t)
+	}
+	return []any{sq.Song, sq.Group, releaseDate, sq.Link, tags, sq.TagMatch, sq.Broken}
+}
 
+// songsOrder maps SongsQuery.Sort to an ORDER BY clause over songs s.
+var songsOrder = map[string]string{
+	"":                    "s.id",
+	models.SortPopularity: "s.play_count DESC, s.id",
+	models.SortRating:     "(s.rating_sum::numeric / NULLIF(s.rating_count, 0)) DESC NULLS LAST, s.rating_count DESC, s.id",
+}
+
+// songPopularity selects the models.Popularity columns of songs s, see
+// scanPopularity.
+const songPopularity = `s.play_count, s.rating_sum::float8 / NULLIF(s.rating_count, 0), s.rating_count`
+
+func scanPopularity(p *models.Popularity) []any {
+	return []any{&p.Plays, &p.Rating, &p.Ratings}
+}
+
+// param returns the n-th placeholder following the songsFilter ones.
+func param(n int) string {
+	return "$" + strconv.Itoa(songsFilterParams+n)
+}
+
+func (sr *SongsRepository) GetSongs(ctx context.Context, sq *models.SongsQuery) (res []models.Song, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSongs")
+	defer done(&err)
+
+	args := songsFilterArgs(sq)
 	row := sr.pool.QueryRowContext(
 		ctx,
 		`
 		SELECT count(*) FROM songs s
-		WHERE (s.name = $1 OR $1 IS NULL)
-		AND (s.group_name = $2 OR $2 IS NULL)
-		AND (s.release_date = $3 OR $3 IS NULL)
-		AND (s.link = $4 OR $4 IS NULL)
+		WHERE `+songsFilter+`
 		`,
-		sq.Song, sq.Group, releaseDate, sq.Link,
+		args...,
 	)
 	if err = row.Scan(&amount); err != nil || amount == 0 {
 		return
@@ -106,23 +186,22 @@ func (sr *SongsRepository) GetSongs(ctx context.Context, sq *models.SongsQuery)
 	rows, err := sr.pool.QueryContext(
 		ctx,
 		`
-		SELECT s.id, s.name, s.group_name, s.release_date FROM songs s
-		WHERE (s.name = $1 OR $1 IS NULL)
-			AND (s.group_name = $2 OR $2 IS NULL)
-			AND (s.release_date = $3 OR $3 IS NULL)
-			AND (s.link = $4 OR $4 IS NULL)
-		LIMIT $5
-		OFFSET $6
+		SELECT s.id, s.name, s.group_name, s.release_date, `+songTags+`, `+songPopularity+`, s.enrichment_status FROM songs s
+		WHERE `+songsFilter+`
+		ORDER BY `+songsOrder[sq.Sort]+`
+		LIMIT `+param(1)+`
+		OFFSET `+param(2)+`
 		`,
-		sq.Song, sq.Group, releaseDate, sq.Link, sq.Max, sq.Max*sq.Page,
+		append(args, sq.Max, sq.Max*sq.Page)...,
 	)
 	if err != nil {
 		return
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var song models.Song
-		if err = rows.Scan(&song.Id, &song.Name, &song.GroupName, &song.ReleaseDate); err != nil {
+		song := models.Song{Popularity: &models.Popularity{}}
+		dest := append([]any{&song.Id, &song.Name, &song.GroupName, &song.ReleaseDate, pq.Array(&song.Tags)}, scanPopularity(song.Popularity)...)
+		if err = rows.Scan(append(dest, &song.EnrichmentStatus)...); err != nil {
 			return
 		}
 		res = append(res, song)
@@ -130,31 +209,36 @@ func (sr *SongsRepository) GetSongs(ctx context.Context, sq *models.SongsQuery)
 	return
 }
 
-func (sr *SongsRepository) CreateSong(ctx context.Context, scq *models.SongCreateQuery) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// CreateSong returns the id of the new song.
+func (sr *SongsRepository) CreateSong(ctx context.Context, scq *models.SongCreateQuery) (songId int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CreateSong")
+	defer done(&err)
 	stmt := `
-	INSERT INTO songs (name, group_name, text, link, release_date) VALUES ($1, $2, $3, $4, $5);
+	INSERT INTO songs (name, group_name, text, link, release_date) VALUES ($1, $2, $3, $4, $5) RETURNING id;
 	`
 	args := []any{scq.Song, scq.Group, scq.Text, scq.Link, scq.ReleaseDate}
 	if scq.ReleaseDate == nil {
 		stmt = `
-		INSERT INTO songs (name, group_name, text, link) VALUES ($1, $2, $3, $4);
+		INSERT INTO songs (name, group_name, text, link) VALUES ($1, $2, $3, $4) RETURNING id;
 		`
 		args = args[:len(args)-1]
 	}
 
-	_, err := sr.pool.ExecContext(
-		ctx,
-		stmt,
-		args...,
-	)
-	return err
+	if err = sr.pool.QueryRowContext(ctx, stmt, args...).Scan(&songId); err != nil {
+		return
+	}
+	if len(scq.Tags) > 0 {
+		if err = sr.setSongTags(ctx, songId, scq.Tags); err != nil {
+			return
+		}
+	}
+	err = sr.addSongEvent(ctx, models.SongEventCreate, songId)
+	return
 }
 
 func (sr *SongsRepository) CheckIfExists(ctx context.Context, songId int) (exists bool, err error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	ctx, done := observe(ctx, sr.timeouts, "CheckIfExists")
+	defer done(&err)
 
 	row := sr.pool.QueryRowContext(
 		ctx, `SELECT EXISTS(SELECT 1 FROM songs s WHERE s.id = $1)`,
@@ -164,9 +248,9 @@ func (sr *SongsRepository) CheckIfExists(ctx context.Context, songId int) (exist
 	return
 }
 
-func (sr *SongsRepository) UpdateSong(ctx context.Context, su *models.SongUpdate, songId int) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+func (sr *SongsRepository) UpdateSong(ctx context.Context, su *models.SongUpdate, songId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "UpdateSong")
+	defer done(&err)
 
 	fields := make(map[string]any)
 	var setStmt string
@@ -186,7 +270,20 @@ func (sr *SongsRepository) UpdateSong(ctx context.Context, su *models.SongUpdate
 	if su.Link != nil {
 		fields["link"] = su.Link
 	}
+	if su.Tags != nil {
+		if err = sr.setSongTags(ctx, songId, *su.Tags); err != nil {
+			return err
+		}
+	}
+	if su.Text != nil || su.GroupName != nil || su.Tags != nil {
+		if err = sr.forgetSimilarSongs(ctx, songId); err != nil {
+			return err
+		}
+	}
 	if len(fields) == 0 {
+		if su.Tags != nil {
+			return sr.addSongEvent(ctx, models.SongEventUpdate, songId)
+		}
 		return nil
 	}
 
@@ -202,27 +299,44 @@ func (sr *SongsRepository) UpdateSong(ctx context.Context, su *models.SongUpdate
 		}
 	}
 	args[len(fields)] = songId
-	_, err := sr.pool.ExecContext(
+	_, err = sr.pool.ExecContext(
 		ctx,
 		`UPDATE songs SET `+setStmt+` WHERE id=$`+strconv.Itoa(len(fields)+1),
 		args...,
 	)
+	if err == nil && su.Text != nil {
+		// Line timestamps refer to the old text.
+		_, err = sr.pool.ExecContext(ctx, `DELETE FROM song_line_timestamps WHERE song_id = $1`, songId)
+	}
+	if err == nil {
+		err = sr.addSongEvent(ctx, models.SongEventUpdate, songId)
+	}
 	return err
 }
 
+// DeleteSong removes a song with everything attached to it. It returns
+// sql.ErrNoRows for an unknown song.
+func (sr *SongsRepository) DeleteSong(ctx context.Context, songId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "DeleteSong")
+	defer done(&err)
+
+	// The rest cascades; playlists need their positions closed up.
+	if err = sr.removeSongEntries(ctx, songId); err != nil {
+		return
+	}
+	if err = sr.pool.QueryRowContext(ctx, `DELETE FROM songs WHERE id = $1 RETURNING id`, songId).Scan(&songId); err != nil {
+		return
+	}
+	return sr.addSongEvent(ctx, models.SongEventDelete, songId)
+}
+
 func (sr *SongsRepository) GetSongText(ctx context.Context, songId int, pmq *models.PageMaxQuery) (res []string, amount int, err error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	ctx, done := observe(ctx, sr.timeouts, "GetSongText")
+	defer done(&err)
 
 	row := sr.pool.QueryRowContext(
 		ctx,
-		`
-		WITH split_text AS (
-			SELECT
-				UNNEST(STRING_TO_ARRAY(text, '\n')) AS line
-			FROM songs
-			WHERE id = $1
-		)
+		songLinesCTE+`
 		SELECT count(line)
 		FROM split_text
 		`,
@@ -234,14 +348,7 @@ func (sr *SongsRepository) GetSongText(ctx context.Context, songId int, pmq *mod
 
 	rows, err := sr.pool.QueryContext(
 		ctx,
-		`
-		WITH split_text AS (
-			SELECT
-				UNNEST(STRING_TO_ARRAY(text, '\n')) AS line,
-				generate_subscripts(STRING_TO_ARRAY(text, '\n'), 1) AS line_number
-			FROM songs
-			WHERE id = $1
-		)
+		songLinesCTE+`
 		SELECT line
 		FROM split_text
 		ORDER BY line_number
@@ -255,6 +362,7 @@ func (sr *SongsRepository) GetSongText(ctx context.Context, songId int, pmq *mod
 	if err != nil {
 		return
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var line string
 		if err = rows.Scan(&line); err != nil {
@@ -264,3 +372,30 @@ func (sr *SongsRepository) GetSongText(ctx context.Context, songId int, pmq *mod
 	}
 	return
 }
+
+// ExportSongs streams every song with its full text to fn, ordered by id.
+func (sr *SongsRepository) ExportSongs(ctx context.Context, fn func(models.SongDetail) error) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ExportSongs")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`SELECT s.id, s.name, s.group_name, s.text, s.release_date, s.link, `+songTags+` FROM songs s ORDER BY s.id`,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sd models.SongDetail
+		var text, link sql.NullString
+		if err = rows.Scan(&sd.Id, &sd.Name, &sd.GroupName, &text, &sd.ReleaseDate, &link, pq.Array(&sd.Tags)); err != nil {
+			return
+		}
+		sd.Text, sd.Link = text.String, link.String
+		if err = fn(sd); err != nil {
+			return
+		}
+	}
+	return rows.Err()
+}
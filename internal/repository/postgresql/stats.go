@@ -0,0 +1,182 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// wordsFrom extracts lower-cased words from the lines of split_text,
+// carrying keys along.
+func wordsFrom(keys string) string {
+	return `words AS (
+			SELECT ` + keys + ` lower(w) AS word
+			FROM split_text, regexp_split_to_table(line, '[^[:alnum:]'']+') AS w
+			WHERE w <> ''
+		)`
+}
+
+// verseStarts marks non-empty lines that follow an empty line or start a
+// text; their count is the number of verses.
+const verseStarts = `btrim(line) <> '' AND btrim(COALESCE(prev, '')) = ''`
+
+func (sr *SongsRepository) GetSongStats(ctx context.Context, songId int, top int) (st models.SongStats, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetSongStats")
+	defer done(&err)
+
+	cte := `
+		WITH ` + splitLines("split_text", "songs WHERE id = $1") + `,
+		` + wordsFrom("") + `,
+		lines AS (
+			SELECT line, lag(line) OVER (ORDER BY line_number) AS prev FROM split_text
+		)`
+
+	st.SongId = songId
+	row := sr.pool.QueryRowContext(
+		ctx,
+		cte+`
+		SELECT
+			(SELECT count(*) FILTER (WHERE btrim(line) <> '') FROM lines),
+			(SELECT count(*) FILTER (WHERE `+verseStarts+`) FROM lines),
+			(SELECT count(*) FROM words),
+			(SELECT count(DISTINCT word) FROM words)
+		`,
+		songId,
+	)
+	if err = row.Scan(&st.Lines, &st.Verses, &st.Words, &st.UniqueWords); err != nil {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		cte+`
+		SELECT word, count(*) AS n FROM words
+		GROUP BY word
+		ORDER BY n DESC, word
+		LIMIT $2
+		`,
+		songId, top,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	st.TopWords = []models.WordCount{}
+	for rows.Next() {
+		var wc models.WordCount
+		if err = rows.Scan(&wc.Word, &wc.Count); err != nil {
+			return
+		}
+		st.TopWords = append(st.TopWords, wc)
+	}
+	err = rows.Err()
+	return
+}
+
+// GetGroupStats aggregates lyrics statistics per group, ordered by group
+// name. Songs without a group are not counted.
+func (sr *SongsRepository) GetGroupStats(ctx context.Context, sq *models.StatsQuery) (res []models.GroupStats, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetGroupStats")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		SELECT count(DISTINCT s.group_name) FROM songs s
+		WHERE s.group_name IS NOT NULL AND (s.group_name = $1 OR $1 IS NULL)
+		`,
+		sq.Group,
+	)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		WITH page AS (
+			SELECT s.group_name, count(*) AS songs FROM songs s
+			WHERE s.group_name IS NOT NULL AND (s.group_name = $1 OR $1 IS NULL)
+			GROUP BY s.group_name
+			ORDER BY s.group_name
+			OFFSET $2
+			LIMIT $3
+		),
+		`+splitLinesBy("split_text", "id, group_name,", "songs WHERE group_name IN (SELECT group_name FROM page)")+`,
+		`+wordsFrom("group_name,")+`,
+		lines AS (
+			SELECT group_name, line, lag(line) OVER (PARTITION BY id ORDER BY line_number) AS prev FROM split_text
+		),
+		line_stats AS (
+			SELECT group_name,
+				count(*) FILTER (WHERE btrim(line) <> '') AS lines,
+				count(*) FILTER (WHERE `+verseStarts+`) AS verses
+			FROM lines GROUP BY group_name
+		),
+		word_stats AS (
+			SELECT group_name, count(*) AS words, count(DISTINCT word) AS unique_words
+			FROM words GROUP BY group_name
+		)
+		SELECT p.group_name, p.songs,
+			COALESCE(l.lines, 0), COALESCE(l.verses, 0), COALESCE(w.words, 0), COALESCE(w.unique_words, 0)
+		FROM page p
+		LEFT JOIN line_stats l ON l.group_name = p.group_name
+		LEFT JOIN word_stats w ON w.group_name = p.group_name
+		ORDER BY p.group_name
+		`,
+		sq.Group, sq.Max*sq.Page, sq.Max,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	index := make(map[string]int)
+	var groups []string
+	for rows.Next() {
+		gs := models.GroupStats{LyricsStats: models.LyricsStats{TopWords: []models.WordCount{}}}
+		if err = rows.Scan(&gs.Group, &gs.Songs, &gs.Lines, &gs.Verses, &gs.Words, &gs.UniqueWords); err != nil {
+			return
+		}
+		index[gs.Group] = len(res)
+		groups = append(groups, gs.Group)
+		res = append(res, gs)
+	}
+	if err = rows.Err(); err != nil || len(groups) == 0 {
+		return
+	}
+
+	rows, err = sr.pool.QueryContext(
+		ctx,
+		`
+		WITH `+splitLinesBy("split_text", "group_name,", "songs WHERE group_name = ANY($1)")+`,
+		`+wordsFrom("group_name,")+`,
+		ranked AS (
+			SELECT group_name, word, count(*) AS n,
+				row_number() OVER (PARTITION BY group_name ORDER BY count(*) DESC, word) AS rank
+			FROM words
+			GROUP BY group_name, word
+		)
+		SELECT group_name, word, n FROM ranked WHERE rank <= $2 ORDER BY group_name, rank
+		`,
+		pq.Array(groups), sq.Top,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			group string
+			wc    models.WordCount
+		)
+		if err = rows.Scan(&group, &wc.Word, &wc.Count); err != nil {
+			return
+		}
+		gs := &res[index[group]]
+		gs.TopWords = append(gs.TopWords, wc)
+	}
+	err = rows.Err()
+	return
+}
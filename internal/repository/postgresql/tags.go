@@ -0,0 +1,231 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// ErrTagExists is returned when a tag name is already taken.
+var ErrTagExists = errors.New("tag already exists")
+
+// songTags selects the sorted tag names of songs s as a text array.
+const songTags = `(
+			SELECT COALESCE(array_agg(t.name ORDER BY t.name), '{}') FROM song_tags st
+			JOIN tags t ON t.id = st.tag_id
+			WHERE st.song_id = s.id
+		)`
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// setSongTags replaces the tags of songId, creating unknown ones.
+func (sr *SongsRepository) setSongTags(ctx context.Context, songId int, tags []string) (err error) {
+	if _, err = sr.pool.ExecContext(ctx, `DELETE FROM song_tags WHERE song_id = $1`, songId); err != nil {
+		return
+	}
+	tags = models.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return
+	}
+	if _, err = sr.pool.ExecContext(
+		ctx,
+		`INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`,
+		pq.Array(tags),
+	); err != nil {
+		return
+	}
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`INSERT INTO song_tags (song_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`,
+		songId, pq.Array(tags),
+	)
+	return
+}
+
+func (sr *SongsRepository) ListTags(ctx context.Context, tq *models.TagsQuery) (res []models.Tag, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListTags")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`SELECT id, name, kind FROM tags WHERE (kind = $1 OR $1 IS NULL) ORDER BY name`,
+		tq.Kind,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag models.Tag
+		if err = rows.Scan(&tag.Id, &tag.Name, &tag.Kind); err != nil {
+			return
+		}
+		res = append(res, tag)
+	}
+	err = rows.Err()
+	return
+}
+
+func (sr *SongsRepository) GetTag(ctx context.Context, tagId int) (tag models.Tag, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetTag")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT id, name, kind FROM tags WHERE id = $1`, tagId)
+	err = row.Scan(&tag.Id, &tag.Name, &tag.Kind)
+	return
+}
+
+func (sr *SongsRepository) CreateTag(ctx context.Context, tc *models.TagCreate) (tag models.Tag, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CreateTag")
+	defer done(&err)
+
+	kind := tc.Kind
+	if kind == "" {
+		kind = models.TagKindTag
+	}
+	names := models.NormalizeTags([]string{tc.Name})
+	if len(names) == 0 {
+		return tag, errors.New("empty tag name")
+	}
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`INSERT INTO tags (name, kind) VALUES ($1, $2) RETURNING id, name, kind`,
+		names[0], kind,
+	)
+	err = row.Scan(&tag.Id, &tag.Name, &tag.Kind)
+	if isUniqueViolation(err) {
+		err = ErrTagExists
+	}
+	return
+}
+
+// UpdateTag returns sql.ErrNoRows if there is no tag tagId.
+func (sr *SongsRepository) UpdateTag(ctx context.Context, tagId int, tu *models.TagUpdate) (tag models.Tag, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "UpdateTag")
+	defer done(&err)
+
+	var name *string
+	if tu.Name != nil {
+		names := models.NormalizeTags([]string{*tu.Name})
+		if len(names) == 0 {
+			return tag, errors.New("empty tag name")
+		}
+		name = &names[0]
+	}
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		UPDATE tags SET name = COALESCE($1, name), kind = COALESCE($2, kind)
+		WHERE id = $3
+		RETURNING id, name, kind
+		`,
+		name, tu.Kind, tagId,
+	)
+	if err = row.Scan(&tag.Id, &tag.Name, &tag.Kind); err != nil {
+		if isUniqueViolation(err) {
+			err = ErrTagExists
+		}
+		return
+	}
+	songIds, err := sr.songsWithTag(ctx, tagId)
+	if err != nil {
+		return
+	}
+	err = sr.retagSongs(ctx, songIds)
+	return
+}
+
+// DeleteTag removes the tag from every song. It returns sql.ErrNoRows if
+// there is no tag tagId.
+func (sr *SongsRepository) DeleteTag(ctx context.Context, tagId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "DeleteTag")
+	defer done(&err)
+
+	songIds, err := sr.songsWithTag(ctx, tagId)
+	if err != nil {
+		return
+	}
+	res, err := sr.pool.ExecContext(ctx, `DELETE FROM tags WHERE id = $1`, tagId)
+	if err != nil {
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = sql.ErrNoRows
+		return
+	}
+	err = sr.retagSongs(ctx, songIds)
+	return
+}
+
+// songsWithTag returns the ids of the songs tagged tagId.
+func (sr *SongsRepository) songsWithTag(ctx context.Context, tagId int) ([]int, error) {
+	rows, err := sr.pool.QueryContext(ctx, `SELECT song_id FROM song_tags WHERE tag_id = $1 ORDER BY song_id`, tagId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var songIds []int
+	for rows.Next() {
+		var songId int
+		if err := rows.Scan(&songId); err != nil {
+			return nil, err
+		}
+		songIds = append(songIds, songId)
+	}
+	return songIds, rows.Err()
+}
+
+// retagSongs does for songs whose tag was renamed or deleted what
+// UpdateSong does for new tags: it forgets their similar songs and logs an
+// update of each.
+func (sr *SongsRepository) retagSongs(ctx context.Context, songIds []int) error {
+	for _, songId := range songIds {
+		if err := sr.forgetSimilarSongs(ctx, songId); err != nil {
+			return err
+		}
+		if err := sr.addSongEvent(ctx, models.SongEventUpdate, songId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountTags counts the songs matching sq per tag, most used first. Tags no
+// matching song carries are left out.
+func (sr *SongsRepository) CountTags(ctx context.Context, sq *models.SongsQuery) (res []models.TagCount, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CountTags")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT t.id, t.name, t.kind, count(*) AS songs FROM songs s
+		JOIN song_tags st ON st.song_id = s.id
+		JOIN tags t ON t.id = st.tag_id
+		WHERE `+songsFilter+`
+		GROUP BY t.id
+		ORDER BY songs DESC, t.name
+		`,
+		songsFilterArgs(sq)...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tc models.TagCount
+		if err = rows.Scan(&tc.Id, &tc.Name, &tc.Kind, &tc.Songs); err != nil {
+			return
+		}
+		res = append(res, tc)
+	}
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,210 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// translationLinesCTE splits the song_texts row of song $1 in language $2
+// into split_text.
+var translationLinesCTE = `
+		WITH ` + splitLines("split_text", "song_texts WHERE song_id = $1 AND lang = $2")
+
+func (sr *SongsRepository) ListSongTexts(ctx context.Context, songId int) (res []models.SongTextInfo, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListSongTexts")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`SELECT lang, is_original, updated_at FROM song_texts WHERE song_id = $1 ORDER BY is_original DESC, lang`,
+		songId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sti models.SongTextInfo
+		if err = rows.Scan(&sti.Lang, &sti.Original, &sti.UpdatedAt); err != nil {
+			return
+		}
+		res = append(res, sti)
+	}
+	err = rows.Err()
+	return
+}
+
+// ErrOriginalText is returned for an attempt to unmark the original text,
+// which would leave songs.text without its language.
+var ErrOriginalText = errors.New("the text in this language is the original; mark another language original instead")
+
+// UpsertSongText adds or replaces the text in lang. Marking it original
+// moves the flag from any other language and replaces songs.text. It
+// returns ErrOriginalText if lang is the original and stu isn't.
+func (sr *SongsRepository) UpsertSongText(ctx context.Context, songId int, lang string, stu *models.SongTextUpsert) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "UpsertSongText")
+	defer done(&err)
+
+	if !stu.Original {
+		var original bool
+		err = sr.pool.QueryRowContext(
+			ctx,
+			`SELECT is_original FROM song_texts WHERE song_id = $1 AND lang = $2 FOR UPDATE`,
+			songId, lang,
+		).Scan(&original)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if original {
+			return ErrOriginalText
+		}
+	} else {
+		if _, err = sr.pool.ExecContext(
+			ctx,
+			`UPDATE song_texts SET is_original = FALSE WHERE song_id = $1 AND lang <> $2 AND is_original`,
+			songId, lang,
+		); err != nil {
+			return
+		}
+	}
+	if _, err = sr.pool.ExecContext(
+		ctx,
+		`
+		INSERT INTO song_texts (song_id, lang, text, is_original) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, lang) DO UPDATE
+		SET text = EXCLUDED.text, is_original = EXCLUDED.is_original, updated_at = now()
+		`,
+		songId, lang, stu.Text, stu.Original,
+	); err != nil {
+		return
+	}
+	if stu.Original {
+		if _, err = sr.pool.ExecContext(ctx, `UPDATE songs SET text = $1 WHERE id = $2`, stu.Text, songId); err != nil {
+			return
+		}
+		// Line timestamps refer to the old text.
+		if _, err = sr.pool.ExecContext(ctx, `DELETE FROM song_line_timestamps WHERE song_id = $1`, songId); err != nil {
+			return
+		}
+		if err = sr.forgetSimilarSongs(ctx, songId); err != nil {
+			return
+		}
+	}
+	err = sr.addSongEvent(ctx, models.SongEventUpdate, songId)
+	return
+}
+
+// GetTranslation returns the full text in lang, or sql.ErrNoRows.
+func (sr *SongsRepository) GetTranslation(ctx context.Context, songId int, lang string) (text string, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetTranslation")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(
+		ctx, `SELECT text FROM song_texts WHERE song_id = $1 AND lang = $2`, songId, lang,
+	).Scan(&text)
+	return
+}
+
+// GetTranslationLines paginates the lines of the text in lang like
+// GetSongText does for the original.
+func (sr *SongsRepository) GetTranslationLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) (res []string, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetTranslationLines")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		translationLinesCTE+`
+		SELECT count(line)
+		FROM split_text
+		`,
+		songId, lang,
+	)
+	if err = row.Scan(&amount); err != nil {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		translationLinesCTE+`
+		SELECT line
+		FROM split_text
+		ORDER BY line_number
+		OFFSET $3
+		LIMIT $4
+		`,
+		songId, lang, pmq.Page*pmq.Max, pmq.Max,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err = rows.Scan(&line); err != nil {
+			return
+		}
+		res = append(res, line)
+	}
+	err = rows.Err()
+	return
+}
+
+// GetParallelLines pages through the original text and its translation in
+// lang side by side, matching lines by number.
+func (sr *SongsRepository) GetParallelLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) (res []models.ParallelLine, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetParallelLines")
+	defer done(&err)
+
+	cte := `
+		WITH ` + splitLines("original", "songs WHERE id = $1") + `,
+		` + splitLines("translation", "song_texts WHERE song_id = $1 AND lang = $2")
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		cte+`
+		SELECT GREATEST((SELECT count(*) FROM original), (SELECT count(*) FROM translation))
+		`,
+		songId, lang,
+	)
+	if err = row.Scan(&amount); err != nil {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		cte+`
+		SELECT COALESCE(o.line_number, t.line_number) AS n, o.line, t.line
+		FROM original o
+		FULL JOIN translation t ON t.line_number = o.line_number
+		ORDER BY n
+		OFFSET $3
+		LIMIT $4
+		`,
+		songId, lang, pmq.Page*pmq.Max, pmq.Max,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			pl                    models.ParallelLine
+			original, translation sql.NullString
+		)
+		if err = rows.Scan(&pl.Number, &original, &translation); err != nil {
+			return
+		}
+		if original.Valid {
+			pl.Original = &original.String
+		}
+		if translation.Valid {
+			pl.Translation = &translation.String
+		}
+		res = append(res, pl)
+	}
+	err = rows.Err()
+	return
+}
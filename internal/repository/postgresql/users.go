@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// ErrInvalidCredentials is returned for an unknown username or a wrong
+// password alike.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type UsersRepositoryI interface {
+	Authenticate(ctx context.Context, username, password string) (models.User, error)
+	GetUser(ctx context.Context, userId int) (models.User, error)
+}
+
+type UsersRepository struct {
+	pool     executor
+	timeouts Timeouts
+}
+
+func NewUsersRepository(pool *sql.DB) *UsersRepository {
+	return &UsersRepository{pool: pool}
+}
+
+// WithTimeouts sets per-method deadlines and returns ur for chaining.
+func (ur *UsersRepository) WithTimeouts(t Timeouts) *UsersRepository {
+	ur.timeouts = t
+	return ur
+}
+
+// CreateUser stores the user with a bcrypt hash of the password.
+func (ur *UsersRepository) CreateUser(ctx context.Context, uc *models.UserCreate) (user models.User, err error) {
+	ctx, done := observe(ctx, ur.timeouts, "CreateUser")
+	defer done(&err)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(uc.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+	row := ur.pool.QueryRowContext(
+		ctx,
+		`
+		INSERT INTO users (username, password_hash, is_admin) VALUES ($1, $2, $3)
+		RETURNING id, username, is_admin, created_at
+		`,
+		uc.Username, string(hash), uc.IsAdmin,
+	)
+	err = row.Scan(&user.Id, &user.Username, &user.IsAdmin, &user.CreatedAt)
+	return
+}
+
+func (ur *UsersRepository) Authenticate(ctx context.Context, username, password string) (user models.User, err error) {
+	ctx, done := observe(ctx, ur.timeouts, "Authenticate")
+	defer done(&err)
+
+	var hash string
+	row := ur.pool.QueryRowContext(
+		ctx,
+		`SELECT id, username, is_admin, created_at, password_hash FROM users WHERE username = $1`,
+		username,
+	)
+	err = row.Scan(&user.Id, &user.Username, &user.IsAdmin, &user.CreatedAt, &hash)
+	if err == sql.ErrNoRows {
+		// Spend the same time as for a wrong password.
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return
+}
+
+// GetUser returns sql.ErrNoRows if there is no user userId.
+func (ur *UsersRepository) GetUser(ctx context.Context, userId int) (user models.User, err error) {
+	ctx, done := observe(ctx, ur.timeouts, "GetUser")
+	defer done(&err)
+
+	row := ur.pool.QueryRowContext(
+		ctx,
+		`SELECT id, username, is_admin, created_at FROM users WHERE id = $1`,
+		userId,
+	)
+	err = row.Scan(&user.Id, &user.Username, &user.IsAdmin, &user.CreatedAt)
+	return
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
@@ -0,0 +1,237 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+const webhookColumns = `w.id, w.url, w.event_types, w.active, w.created_at`
+
+func scanWebhook(row interface{ Scan(...any) error }, w *models.Webhook) error {
+	return row.Scan(&w.Id, &w.URL, pq.Array(&w.Events), &w.Active, &w.CreatedAt)
+}
+
+func (sr *SongsRepository) CreateWebhook(ctx context.Context, wc *models.WebhookCreate) (w models.Webhook, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CreateWebhook")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`INSERT INTO webhooks AS w (url, event_types, secret) VALUES ($1, $2, $3) RETURNING `+webhookColumns,
+		wc.URL, pq.Array(wc.Events), wc.Secret,
+	)
+	err = scanWebhook(row, &w)
+	return
+}
+
+func (sr *SongsRepository) ListWebhooks(ctx context.Context) (res []models.Webhook, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListWebhooks")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks w ORDER BY w.id`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w models.Webhook
+		if err = scanWebhook(rows, &w); err != nil {
+			return
+		}
+		res = append(res, w)
+	}
+	err = rows.Err()
+	return
+}
+
+// GetWebhook returns sql.ErrNoRows for an unknown webhook.
+func (sr *SongsRepository) GetWebhook(ctx context.Context, webhookId int) (w models.Webhook, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "GetWebhook")
+	defer done(&err)
+
+	row := sr.pool.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks w WHERE w.id = $1`, webhookId)
+	err = scanWebhook(row, &w)
+	return
+}
+
+// UpdateWebhook returns sql.ErrNoRows for an unknown webhook. Deliveries of
+// an inactive webhook stay pending until it is activated again.
+func (sr *SongsRepository) UpdateWebhook(ctx context.Context, webhookId int, wu *models.WebhookUpdate) (w models.Webhook, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "UpdateWebhook")
+	defer done(&err)
+
+	var events any
+	if wu.Events != nil {
+		events = pq.Array(*wu.Events)
+	}
+	row := sr.pool.QueryRowContext(
+		ctx,
+		`
+		UPDATE webhooks AS w SET
+			url = COALESCE($1, url),
+			event_types = COALESCE($2, event_types),
+			active = COALESCE($3, active)
+		WHERE w.id = $4
+		RETURNING `+webhookColumns,
+		wu.URL, events, wu.Active, webhookId,
+	)
+	err = scanWebhook(row, &w)
+	return
+}
+
+// DeleteWebhook removes a webhook with its deliveries. It returns
+// sql.ErrNoRows for an unknown webhook.
+func (sr *SongsRepository) DeleteWebhook(ctx context.Context, webhookId int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "DeleteWebhook")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(ctx, `DELETE FROM webhooks WHERE id = $1 RETURNING id`, webhookId).Scan(&webhookId)
+	return
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.payload, d.status, d.attempts,
+		CASE WHEN d.status = 'pending' THEN d.next_attempt_at END,
+		d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+// ListDeliveries paginates deliveries, newest first, optionally of one
+// webhook or in one status.
+func (sr *SongsRepository) ListDeliveries(ctx context.Context, dq *models.DeliveriesQuery) (res []models.WebhookDelivery, amount int, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ListDeliveries")
+	defer done(&err)
+
+	filter := `(d.webhook_id = $1 OR $1 IS NULL) AND (d.status = $2 OR $2 = '')`
+	row := sr.pool.QueryRowContext(ctx, `SELECT count(*) FROM webhook_deliveries d WHERE `+filter, dq.WebhookId, dq.Status)
+	if err = row.Scan(&amount); err != nil || amount == 0 {
+		return
+	}
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		SELECT `+deliveryColumns+` FROM webhook_deliveries d
+		WHERE `+filter+`
+		ORDER BY d.id DESC
+		LIMIT $3
+		OFFSET $4
+		`,
+		dq.WebhookId, dq.Status, dq.Max, dq.Max*dq.Page,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		if err = rows.Scan(
+			&d.Id, &d.WebhookId, &d.EventId, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+		); err != nil {
+			return
+		}
+		d.Payload = payload
+		res = append(res, d)
+	}
+	err = rows.Err()
+	return
+}
+
+// RetryDelivery puts a dead delivery back in the queue with a fresh set of
+// attempts. It returns sql.ErrNoRows unless the delivery is dead.
+func (sr *SongsRepository) RetryDelivery(ctx context.Context, deliveryId int64) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "RetryDelivery")
+	defer done(&err)
+
+	err = sr.pool.QueryRowContext(
+		ctx,
+		`
+		UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now()
+		WHERE id = $1 AND status = 'dead'
+		RETURNING id
+		`,
+		deliveryId,
+	).Scan(&deliveryId)
+	return
+}
+
+// ClaimDeliveries returns up to limit due deliveries of active webhooks and
+// postpones them by lease, so that concurrent dispatchers skip them and a
+// dispatcher that dies before reporting leaves them to be retried.
+func (sr *SongsRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (res []models.OutgoingDelivery, err error) {
+	ctx, done := observe(ctx, sr.timeouts, "ClaimDeliveries")
+	defer done(&err)
+
+	rows, err := sr.pool.QueryContext(
+		ctx,
+		`
+		UPDATE webhook_deliveries d SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT due.id FROM webhook_deliveries due
+			JOIN webhooks active ON active.id = due.webhook_id AND active.active
+			WHERE due.status = 'pending' AND due.next_attempt_at <= now()
+			ORDER BY due.next_attempt_at, due.id
+			LIMIT $1
+			FOR UPDATE OF due SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, w.url, w.secret, d.payload->>'type', d.payload, d.attempts
+		`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d models.OutgoingDelivery
+		if err = rows.Scan(&d.Id, &d.WebhookId, &d.URL, &d.Secret, &d.EventType, &d.Payload, &d.Attempts); err != nil {
+			return
+		}
+		res = append(res, d)
+	}
+	err = rows.Err()
+	return
+}
+
+// CompleteDelivery records a successful attempt.
+func (sr *SongsRepository) CompleteDelivery(ctx context.Context, deliveryId int64, statusCode int) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "CompleteDelivery")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		UPDATE webhook_deliveries SET
+			status = 'delivered', attempts = attempts + 1, delivered_at = now(),
+			last_status_code = $2, last_error = NULL
+		WHERE id = $1
+		`,
+		deliveryId, statusCode,
+	)
+	return
+}
+
+// FailDelivery records a failed attempt. The delivery is tried again at
+// retryAt, or dead-lettered if retryAt is nil. statusCode is nil if no
+// response was received.
+func (sr *SongsRepository) FailDelivery(ctx context.Context, deliveryId int64, statusCode *int, msg string, retryAt *time.Time) (err error) {
+	ctx, done := observe(ctx, sr.timeouts, "FailDelivery")
+	defer done(&err)
+
+	_, err = sr.pool.ExecContext(
+		ctx,
+		`
+		UPDATE webhook_deliveries SET
+			status = CASE WHEN $4::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4, next_attempt_at),
+			attempts = attempts + 1, last_status_code = $2, last_error = $3
+		WHERE id = $1
+		`,
+		deliveryId, statusCode, msg, retryAt,
+	)
+	return
+}
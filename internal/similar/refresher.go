@@ -0,0 +1,50 @@
+// Package similar keeps the stored similar songs up to date in background
+// jobs.
+package similar
+
+import (
+	"context"
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+// Store is the part of the repository the refresher works with.
+type Store interface {
+	RefreshSimilarSongs(ctx context.Context, limit int) (int64, error)
+	Begin() (*postgresql.Transaction, error)
+}
+
+// Refresher runs the models.JobRefreshSimilar jobs.
+type Refresher struct {
+	// A repository per job, as jobs run in transactions.
+	store func() Store
+}
+
+func NewRefresher(store func() Store) *Refresher {
+	return &Refresher{store: store}
+}
+
+// Run is the jobs.Handler of models.JobRefreshSimilar. It stores the best
+// models.MaxSimilar songs of every song, in one transaction so that readers
+// keep the old rankings until it commits.
+func (r *Refresher) Run(ctx context.Context, _ json.RawMessage) error {
+	store := r.store()
+	tr, err := store.Begin()
+	if err != nil {
+		return err
+	}
+	stored, err := store.RefreshSimilarSongs(ctx, models.MaxSimilar)
+	if err != nil {
+		tr.Rollback()
+		return err
+	}
+	if err := tr.Commit(); err != nil {
+		return err
+	}
+	log.WithField("rows", stored).Info("refreshed similar songs")
+	return nil
+}
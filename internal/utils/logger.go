@@ -1,8 +1,8 @@
 package utils
 
 import (
-	"fmt"
 	"math"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"time"
@@ -13,31 +13,45 @@ import (
 	"github.com/nikuma0/test-effective-mobile-golang/config"
 )
 
-var timeFormat = "02/Jan/2006:15:04:05 -0700"
-
 func InitLog(config config.Config) {
-	if config.LogLever == "" {
+	switch config.Logging.Format {
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case "", "text":
+		log.SetFormatter(&log.TextFormatter{})
+	default:
+		log.Fatalf("unknown log format %q", config.Logging.Format)
+	}
+	log.AddHook(NewRedactionHook(config.Logging))
+	if config.Logging.Level == "" {
 		log.SetLevel(log.DebugLevel)
 		return
 	}
-	lvl, err := log.ParseLevel(config.LogLever)
+	lvl, err := log.ParseLevel(config.Logging.Level)
 	if err != nil {
 		log.Fatal(err)
 	}
 	log.SetLevel(lvl)
 }
 
-func LoggerMiddleware() gin.HandlerFunc {
+func LoggerMiddleware(cfg config.LoggingConfig) gin.HandlerFunc {
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknow"
 	}
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = true
+	}
 
 	return func(c *gin.Context) {
 		// other handler can change c.Path so:
 		path := c.Request.URL.Path
 		start := time.Now()
 		c.Next()
+		if skip[path] {
+			return
+		}
 		stop := time.Since(start)
 		latency := int(math.Ceil(float64(stop.Nanoseconds()) / 1000000.0))
 		statusCode := c.Writer.Status()
@@ -49,7 +63,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 			dataLength = 0
 		}
 
-		entry := log.WithFields(log.Fields{
+		entry := Log(c.Request.Context()).WithFields(log.Fields{
 			"hostname":   hostname,
 			"statusCode": statusCode,
 			"latency":    latency, // time to process
@@ -64,14 +78,22 @@ func LoggerMiddleware() gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			entry.Error(c.Errors.ByType(gin.ErrorTypePrivate).String())
 		} else {
-			msg := fmt.Sprintf("%s - %s [%s] \"%s %s\" %d %d \"%s\" \"%s\" (%dms)", clientIP, hostname, time.Now().Format(timeFormat), c.Request.Method, path, statusCode, dataLength, referer, clientUserAgent, latency)
+			// The details are only in the fields, where the redaction
+			// hook can mask or drop them.
+			msg := "request"
 			if statusCode >= http.StatusInternalServerError {
 				entry.Error(msg)
 			} else if statusCode >= http.StatusBadRequest {
-				entry.Warn(msg)
-			} else {
+				if sampled(cfg.SampleWarn) {
+					entry.Warn(msg)
+				}
+			} else if sampled(cfg.SampleInfo) {
 				entry.Info(msg)
 			}
 		}
 	}
 }
+
+func sampled(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+)
+
+// ipFields are log fields holding client addresses.
+var ipFields = map[string]bool{"clientIP": true}
+
+// RedactionHook rewrites entry fields before they are formatted: it drops
+// configured fields, masks client IPs and truncates long values.
+type RedactionHook struct {
+	maskIP    bool
+	drop      map[string]bool
+	maxLength int
+}
+
+func NewRedactionHook(cfg config.LoggingConfig) *RedactionHook {
+	drop := make(map[string]bool, len(cfg.DropFields))
+	for _, f := range cfg.DropFields {
+		drop[f] = true
+	}
+	return &RedactionHook{maskIP: cfg.MaskIP, drop: drop, maxLength: cfg.MaxFieldLength}
+}
+
+func (h *RedactionHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *RedactionHook) Fire(entry *log.Entry) error {
+	for k, v := range entry.Data {
+		if h.drop[k] {
+			delete(entry.Data, k)
+			continue
+		}
+		if h.maskIP && ipFields[k] {
+			if s, ok := v.(string); ok {
+				entry.Data[k] = MaskIP(s)
+			}
+			continue
+		}
+		if h.maxLength > 0 {
+			entry.Data[k] = h.truncate(v)
+		}
+	}
+	if h.maxLength > 0 {
+		entry.Message = h.cut(entry.Message)
+	}
+	return nil
+}
+
+// truncate cuts long strings. Structs, maps and slices are rendered as JSON
+// first so that nested text (e.g. lyrics in an update) is cut too.
+func (h *RedactionHook) truncate(v any) any {
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case error:
+		return v
+	default:
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		switch rv.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return v
+			}
+			s = string(b)
+		default:
+			return v
+		}
+	}
+	return h.cut(s)
+}
+
+// cut shortens s to at most maxLength bytes, without splitting a UTF-8
+// character, and marks the cut.
+func (h *RedactionHook) cut(s string) string {
+	if len(s) <= h.maxLength {
+		return s
+	}
+	n := h.maxLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// MaskIP zeroes the host part of an address: the last octet of IPv4 and
+// everything past the /48 prefix of IPv6. Unparsable input is returned as is.
+func MaskIP(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return addr
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	loggerKey
+)
+
+// RequestIDMiddleware accepts the caller's X-Request-ID or generates one,
+// echoes it in the response and stores it, together with a logger carrying
+// it, in the request context.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey, id)
+		fields := log.Fields{"requestId": id}
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			fields["traceId"] = sc.TraceID().String()
+		}
+		ctx = WithLogger(ctx, log.WithFields(fields))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithLogger(ctx context.Context, entry *log.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey, entry)
+}
+
+// Log returns the request-scoped logger, falling back to the standard logger
+// outside of a request.
+func Log(ctx context.Context) *log.Entry {
+	if entry, ok := ctx.Value(loggerKey).(*log.Entry); ok {
+		return entry
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
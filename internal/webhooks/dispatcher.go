@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/tracing"
+)
+
+const (
+	// batchSize bounds the deliveries claimed, and sent concurrently, at once.
+	batchSize = 20
+	// maxErrorBody bounds the part of a failed response kept as its error.
+	maxErrorBody = 256
+)
+
+// Store is the part of the repository the dispatcher works with.
+type Store interface {
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.OutgoingDelivery, error)
+	CompleteDelivery(ctx context.Context, deliveryId int64, statusCode int) error
+	FailDelivery(ctx context.Context, deliveryId int64, statusCode *int, msg string, retryAt *time.Time) error
+}
+
+type Dispatcher struct {
+	store  Store
+	cfg    config.WebhooksConfig
+	client *http.Client
+}
+
+func NewDispatcher(store Store, cfg config.WebhooksConfig) *Dispatcher {
+	return &Dispatcher{store: store, cfg: cfg, client: tracing.HTTPClient(&http.Client{Timeout: cfg.Timeout})}
+}
+
+// lease is how long a claimed delivery is hidden from other dispatchers;
+// longer than any request, so that it is only retried if we die.
+func (d *Dispatcher) lease() time.Duration {
+	return 2*d.cfg.Timeout + time.Minute
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func (d *Dispatcher) Backoff(failures int) time.Duration {
+	wait := d.cfg.Backoff
+	for i := 1; i < failures && wait < d.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, d.cfg.BackoffMax)
+}
+
+// Run dispatches due deliveries until ctx is cancelled, checking after
+// every value from wake and at least every PollInterval.
+func (d *Dispatcher) Run(ctx context.Context, wake <-chan struct{}) {
+	for {
+		n, err := d.DispatchDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Error("dispatching webhooks")
+		}
+		if n == batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				wake = nil
+			}
+		case <-time.After(d.cfg.PollInterval):
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns its size.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimDeliveries(ctx, batchSize, d.lease())
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	for _, dl := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.dispatch(ctx, dl); err != nil && ctx.Err() == nil {
+				log.WithError(err).WithField("deliveryId", dl.Id).Error("recording webhook delivery")
+			}
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// dispatch makes one attempt and records its outcome.
+func (d *Dispatcher) dispatch(ctx context.Context, dl models.OutgoingDelivery) error {
+	statusCode, err := d.send(ctx, dl)
+	if err == nil {
+		return d.store.CompleteDelivery(ctx, dl.Id, statusCode)
+	}
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	var retryAt *time.Time
+	if failures := dl.Attempts + 1; failures < d.cfg.MaxAttempts {
+		at := time.Now().Add(d.Backoff(failures))
+		retryAt = &at
+	}
+	log.WithError(err).WithFields(log.Fields{"deliveryId": dl.Id, "webhookId": dl.WebhookId, "dead": retryAt == nil}).
+		Warn("webhook delivery failed")
+	return d.store.FailDelivery(ctx, dl.Id, code, err.Error(), retryAt)
+}
+
+// send posts the payload and returns the response status, 0 if there was
+// none. Any status but 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, dl models.OutgoingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "songs-api-webhooks")
+	// The same for every attempt, so that receivers can drop duplicates.
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(dl.Id, 10))
+	req.Header.Set("X-Webhook-Event", dl.EventType)
+	req.Header.Set(SignatureHeader, Sign(dl.Secret, time.Now(), dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,73 @@
+// Package webhooks sends queued song events to webhook subscribers.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>" of
+// "<unix time>.<body>" keyed with the webhook secret. Receivers should
+// reject old timestamps to prevent replays.
+const SignatureHeader = "X-Webhook-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// NewSecret returns a random secret for a webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func mac(secret string, t int64, body []byte) []byte {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(strconv.FormatInt(t, 10)))
+	m.Write([]byte{'.'})
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+// Sign returns the SignatureHeader value for body sent at now.
+func Sign(secret string, now time.Time, body []byte) string {
+	t := now.Unix()
+	return "t=" + strconv.FormatInt(t, 10) + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// Verify checks a SignatureHeader value against body and rejects signatures
+// older than tolerance. It is what a receiver written in Go would run.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var (
+		t   int64
+		sig []byte
+		err error
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			if t, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return ErrInvalidSignature
+			}
+		case "v1":
+			if sig, err = hex.DecodeString(value); err != nil {
+				return ErrInvalidSignature
+			}
+		}
+	}
+	if t == 0 || sig == nil || !hmac.Equal(sig, mac(secret, t, body)) {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(t, 0)); d > tolerance || d < -tolerance {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+CREATE TABLE song_line_timestamps (
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	line_number INTEGER NOT NULL,
+	start_ms INTEGER NOT NULL CHECK (start_ms >= 0),
+	words JSONB,
+	PRIMARY KEY (song_id, line_number)
+)
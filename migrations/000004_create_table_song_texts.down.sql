@@ -0,0 +1,3 @@
+DROP TRIGGER songs_sync_original_text ON songs;
+DROP FUNCTION sync_original_song_text();
+DROP TABLE song_texts;
@@ -0,0 +1,25 @@
+CREATE TABLE song_texts (
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	lang TEXT NOT NULL,
+	text TEXT NOT NULL,
+	is_original BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (song_id, lang)
+);
+
+CREATE UNIQUE INDEX song_texts_one_original ON song_texts (song_id) WHERE is_original;
+
+-- songs.text stays the source of the original text; keep its song_texts
+-- copy in step when it is edited through PATCH /songs/:id.
+CREATE FUNCTION sync_original_song_text() RETURNS trigger AS $$
+BEGIN
+	UPDATE song_texts
+	SET text = COALESCE(NEW.text, ''), updated_at = now()
+	WHERE song_id = NEW.id AND is_original AND text IS DISTINCT FROM COALESCE(NEW.text, '');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_sync_original_text
+AFTER UPDATE OF text ON songs
+FOR EACH ROW EXECUTE FUNCTION sync_original_song_text();
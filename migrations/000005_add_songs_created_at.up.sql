@@ -0,0 +1,2 @@
+ALTER TABLE songs ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX songs_created_at_idx ON songs (created_at DESC);
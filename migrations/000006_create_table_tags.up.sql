@@ -0,0 +1,13 @@
+CREATE TABLE tags (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	kind TEXT NOT NULL DEFAULT 'tag' CHECK (kind IN ('tag', 'genre'))
+);
+
+CREATE TABLE song_tags (
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+	PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE INDEX song_tags_tag_id_idx ON song_tags (tag_id);
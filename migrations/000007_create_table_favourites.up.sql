@@ -0,0 +1,8 @@
+CREATE TABLE favourites (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX favourites_song_id_idx ON favourites (song_id);
@@ -0,0 +1,23 @@
+CREATE TABLE playlists (
+	id SERIAL PRIMARY KEY,
+	owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX playlists_owner_id_idx ON playlists (owner_id);
+
+-- Positions are 1-based and gapless; the constraint is deferred so that a
+-- reorder can shuffle them within one statement.
+CREATE TABLE playlist_entries (
+	id SERIAL PRIMARY KEY,
+	playlist_id INTEGER NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	position INTEGER NOT NULL CHECK (position > 0),
+	added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	CONSTRAINT playlist_entries_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX playlist_entries_song_id_idx ON playlist_entries (song_id);
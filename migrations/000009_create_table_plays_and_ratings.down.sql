@@ -0,0 +1,6 @@
+DROP TABLE ratings;
+DROP TABLE song_plays;
+ALTER TABLE songs
+	DROP COLUMN play_count,
+	DROP COLUMN rating_count,
+	DROP COLUMN rating_sum;
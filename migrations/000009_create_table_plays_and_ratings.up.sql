@@ -0,0 +1,29 @@
+-- Aggregates kept up to date by the repository in the statements that
+-- record plays and ratings.
+ALTER TABLE songs
+	ADD COLUMN play_count BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX songs_play_count_idx ON songs (play_count DESC, id);
+CREATE INDEX songs_rating_idx ON songs ((rating_sum::numeric / NULLIF(rating_count, 0)) DESC NULLS LAST, rating_count DESC, id);
+
+-- The client chosen event id makes retried batches idempotent.
+CREATE TABLE song_plays (
+	event_id TEXT PRIMARY KEY,
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+	played_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX song_plays_song_id_idx ON song_plays (song_id);
+
+CREATE TABLE ratings (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	stars SMALLINT NOT NULL CHECK (stars BETWEEN 1 AND 5),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX ratings_song_id_idx ON ratings (song_id);
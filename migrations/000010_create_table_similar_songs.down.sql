@@ -0,0 +1 @@
+DROP TABLE similar_songs;
@@ -0,0 +1,13 @@
+-- Filled by the "similar" command; songs without rows are scored on demand.
+CREATE TABLE similar_songs (
+	song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	similar_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+	rank INTEGER NOT NULL,
+	score DOUBLE PRECISION NOT NULL,
+	lyrics_score DOUBLE PRECISION NOT NULL,
+	tags_score DOUBLE PRECISION NOT NULL,
+	same_group BOOLEAN NOT NULL,
+	PRIMARY KEY (song_id, rank)
+);
+
+CREATE INDEX similar_songs_similar_id_idx ON similar_songs (similar_id);
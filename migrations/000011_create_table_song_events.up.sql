@@ -0,0 +1,8 @@
+-- Log of song changes behind GET /songs/events. Rows outlive their song, so
+-- song_id has no foreign key. Ids follow commit order, see addSongEvent.
+CREATE TABLE song_events (
+	id BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL CHECK (type IN ('create', 'update', 'delete')),
+	song_id INTEGER NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,28 @@
+CREATE TABLE webhooks (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	event_types TEXT[] NOT NULL,
+	secret TEXT NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Outbox: a row per subscribed webhook is written in the transaction that
+-- logs the song event, and the dispatcher sends it after the commit.
+CREATE TABLE webhook_deliveries (
+	id BIGSERIAL PRIMARY KEY,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	event_id BIGINT NOT NULL REFERENCES song_events (id),
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_status_code INTEGER,
+	last_error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id);
+CREATE INDEX webhook_deliveries_dead_idx ON webhook_deliveries (id) WHERE status = 'dead';
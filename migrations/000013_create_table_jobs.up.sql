@@ -0,0 +1,20 @@
+-- Background job queue. Jobs are enqueued in the transaction that needs
+-- them and claimed by the workers with SKIP LOCKED; run_at is when a queued
+-- job is due and, while it runs, when its lease expires.
+CREATE TABLE jobs (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	payload JSONB NOT NULL DEFAULT '{}',
+	-- Deduplicates jobs, such as one per scheduled run.
+	key TEXT UNIQUE,
+	status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'dead')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_due_idx ON jobs (run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX jobs_finished_at_idx ON jobs (finished_at) WHERE status = 'done';
+CREATE INDEX jobs_kind_idx ON jobs (kind, id);
@@ -0,0 +1,3 @@
+-- Songs created before enrichment existed were never looked up.
+ALTER TABLE songs ADD COLUMN enrichment_status TEXT NOT NULL DEFAULT 'none'
+	CHECK (enrichment_status IN ('none', 'pending', 'done', 'failed'));
@@ -0,0 +1 @@
+DROP TABLE song_links;
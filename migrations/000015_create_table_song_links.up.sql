@@ -0,0 +1,16 @@
+-- Latest check of every song link. A row is stale once the song's link no
+-- longer equals url.
+CREATE TABLE song_links (
+	song_id INTEGER PRIMARY KEY REFERENCES songs (id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	-- NULL if there was no response.
+	status_code INTEGER,
+	-- Where redirects led, if anywhere else.
+	redirect_url TEXT,
+	error TEXT,
+	broken BOOLEAN NOT NULL,
+	checked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX song_links_checked_at_idx ON song_links (checked_at);
+CREATE INDEX song_links_broken_idx ON song_links (song_id) WHERE broken;
@@ -0,0 +1 @@
+ALTER TABLE song_events DROP COLUMN tx_id;
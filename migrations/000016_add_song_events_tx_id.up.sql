@@ -0,0 +1,7 @@
+-- The transaction that logged the event. Ids no longer follow commit order
+-- as 000011 says: they are taken before commit, so a smaller one can be
+-- committed after a larger one. Readers order by tx_id and skip transactions
+-- that may still be running, see ListSongEvents.
+ALTER TABLE song_events ADD COLUMN tx_id xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX song_events_tx_id_idx ON song_events (tx_id, id);
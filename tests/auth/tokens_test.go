@@ -0,0 +1,33 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/auth"
+)
+
+func TestTokens(t *testing.T) {
+	tokens := auth.NewTokens("secret", time.Hour)
+	now := time.Now()
+	token, expires := tokens.Issue(42, now)
+	assert.WithinDuration(t, now.Add(time.Hour), expires, time.Second)
+
+	userId, err := tokens.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, 42, userId)
+
+	_, err = tokens.Verify(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "expired")
+
+	_, err = tokens.Verify("43"+token[2:], now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "tampered")
+
+	_, err = tokens.Verify("garbage", now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	assert.Nil(t, auth.NewTokens("", time.Hour), "no secret disables tokens")
+}
@@ -0,0 +1,55 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/cache"
+)
+
+func TestCache(t *testing.T) {
+	c := cache.New[string, int](time.Minute, 10)
+	c.Set("a", 1)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := cache.New[string, int](10*time.Millisecond, 10)
+	c.Set("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := cache.New[int, int](time.Minute, 3)
+	for i := 1; i <= 3; i++ {
+		c.Set(i, i)
+	}
+	c.Get(1)
+	c.Set(4, 4)
+
+	assert.Equal(t, 3, c.Len())
+	_, ok := c.Get(2)
+	assert.False(t, ok, "the least recently used entry is evicted")
+	for _, k := range []int{1, 3, 4} {
+		_, ok := c.Get(k)
+		assert.True(t, ok, k)
+	}
+
+	for i := 0; i < 1000; i++ {
+		c.Set(100+i, i)
+	}
+	assert.Equal(t, 3, c.Len())
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,164 @@
+package enrichment_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/enrichment"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+// newUpstream fakes the music info service, knowing one song.
+func newUpstream(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" || r.Header.Get("X-API-Key") != "key" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Query().Get("group") + " - " + r.URL.Query().Get("song") {
+		case "Muse - Supermassive Black Hole":
+			w.Write([]byte(`{"releaseDate":"16.07.2006","text":"Ooh baby, don't you know I suffer?","link":"https://www.youtube.com/watch?v=Xsp3_a-PMTw"}`))
+		case "Muse - Broken":
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(srv *httptest.Server) *enrichment.Client {
+	return enrichment.NewClient(config.EnrichmentConfig{URL: srv.URL + "/", APIKey: "key", Timeout: time.Second})
+}
+
+func TestLookup(t *testing.T) {
+	client := newClient(newUpstream(t))
+	ctx := context.Background()
+
+	info, err := client.Lookup(ctx, "Muse", "Supermassive Black Hole")
+	require.NoError(t, err)
+	assert.Equal(t, "Ooh baby, don't you know I suffer?", info.Text)
+	assert.Equal(t, "https://www.youtube.com/watch?v=Xsp3_a-PMTw", info.Link)
+	require.NotNil(t, info.ReleaseDate)
+	assert.Equal(t, time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), time.Time(*info.ReleaseDate))
+
+	_, err = client.Lookup(ctx, "Muse", "Unknown")
+	assert.ErrorIs(t, err, enrichment.ErrNotFound)
+
+	_, err = client.Lookup(ctx, "Muse", "Broken")
+	assert.EqualError(t, err, "music info service: status 503: try later")
+}
+
+func TestLookupTraceparent(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+	tp := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "lookup")
+	_, err := newClient(srv).Lookup(ctx, "Muse", "Supermassive Black Hole")
+	span.End()
+	require.NoError(t, err)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
+
+// fakeStore records what the enricher does.
+type fakeStore struct {
+	applied *models.SongEnrichment
+	fields  []string
+	status  string
+	deleted bool
+}
+
+func (s *fakeStore) ApplyEnrichment(ctx context.Context, ej *models.EnrichSongJob, info *models.SongEnrichment) error {
+	if s.deleted {
+		return sql.ErrNoRows
+	}
+	s.applied, s.fields = info, ej.Fields
+	return nil
+}
+
+func (s *fakeStore) SetEnrichmentStatus(ctx context.Context, songId int, status string) error {
+	s.status = status
+	return nil
+}
+
+func (s *fakeStore) Begin() (*postgresql.Transaction, error) {
+	return &postgresql.Transaction{}, nil
+}
+
+func payload(t *testing.T, song string) json.RawMessage {
+	p, err := json.Marshal(models.EnrichSongJob{SongId: 1, Group: "Muse", Song: song, Fields: []string{models.EnrichText}})
+	require.NoError(t, err)
+	return p
+}
+
+func TestEnricher(t *testing.T) {
+	srv := newUpstream(t)
+	var applied []int
+	run := func(store *fakeStore, song string) error {
+		applied = nil
+		e := enrichment.NewEnricher(newClient(srv), func() enrichment.Store { return store }).
+			OnApplied(func(songId int) { applied = append(applied, songId) })
+		return e.Run(context.Background(), payload(t, song))
+	}
+
+	t.Run("Found", func(t *testing.T) {
+		store := &fakeStore{}
+		require.NoError(t, run(store, "Supermassive Black Hole"))
+		require.NotNil(t, store.applied)
+		assert.Equal(t, "Ooh baby, don't you know I suffer?", store.applied.Text)
+		assert.Equal(t, []string{models.EnrichText}, store.fields)
+		assert.Equal(t, []int{1}, applied)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		store := &fakeStore{}
+		err := run(store, "Unknown")
+		assert.ErrorIs(t, err, enrichment.ErrNotFound)
+		assert.Equal(t, models.EnrichmentFailed, store.status)
+		assert.Nil(t, store.applied)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		// Retried, so not failed yet.
+		store := &fakeStore{}
+		assert.Error(t, run(store, "Broken"))
+		assert.Empty(t, store.status)
+	})
+
+	t.Run("SongDeleted", func(t *testing.T) {
+		assert.NoError(t, run(&fakeStore{deleted: true}, "Supermassive Black Hole"))
+		assert.Empty(t, applied)
+	})
+
+	t.Run("MalformedPayload", func(t *testing.T) {
+		e := enrichment.NewEnricher(newClient(srv), func() enrichment.Store { return &fakeStore{} })
+		err := e.Run(context.Background(), json.RawMessage(`[]`))
+		var typeErr *json.UnmarshalTypeError
+		assert.True(t, errors.As(err, &typeErr))
+	})
+}
@@ -0,0 +1,53 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/events"
+)
+
+func received(ch <-chan struct{}) bool {
+	select {
+	case _, ok := <-ch:
+		return ok
+	default:
+		return false
+	}
+}
+
+func TestBroker(t *testing.T) {
+	t.Run("Coalesces", func(t *testing.T) {
+		b := events.NewBroker()
+		a, unsubscribeA := b.Subscribe()
+		defer unsubscribeA()
+		c, unsubscribeC := b.Subscribe()
+
+		b.Notify()
+		b.Notify()
+		assert.True(t, received(a))
+		assert.False(t, received(a), "pending wakeups are merged")
+		assert.True(t, received(c))
+
+		unsubscribeC()
+		b.Notify()
+		_, ok := <-c
+		assert.False(t, ok, "unsubscribing closes the channel")
+		assert.True(t, received(a))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		b := events.NewBroker()
+		a, unsubscribe := b.Subscribe()
+		b.Close()
+		_, ok := <-a
+		assert.False(t, ok)
+		unsubscribe()
+
+		late, _ := b.Subscribe()
+		_, ok = <-late
+		assert.False(t, ok, "subscriptions after Close end at once")
+		b.Notify()
+	})
+}
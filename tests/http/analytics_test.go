@@ -0,0 +1,72 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestAnalytics(t *testing.T) {
+	t.Run("SongsPerGroupFiltered", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 10, Group: utils.Ptr("Muse")}
+		mockRepo.On("CountSongsByGroup", mock.Anything, sq).Return([]models.GroupCount{{Group: "Muse", Songs: 3}}, 1, nil)
+
+		w := performRequest(r, "GET", "/analytics/groups?group=Muse")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":[{"group":"Muse","songs":3}],"page":0,"amount":1,"next":false,"ok":true}`, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("TopGroups", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 2}
+		mockRepo.On("GetTopGroups", mock.Anything, sq).Return([]models.GroupCount(nil), nil)
+
+		w := performRequest(r, "GET", "/analytics/top-groups?max=2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"ok":true,"data":[]}`, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Releases", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 10}
+		mockRepo.On("CountReleases", mock.Anything, sq, "year").Return([]models.ReleaseBucket{
+			{Period: "2023", Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Songs: 2},
+			{Period: "2024", Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Songs: 0},
+		}, nil)
+
+		w := performRequest(r, "GET", "/analytics/releases?interval=year")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"period":"2024","start":"2024-01-01T00:00:00Z","songs":0}`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		r, _, _ := initHelper()
+		w := performRequest(r, "GET", "/analytics/releases?interval=week")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Recent", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 1}
+		added := time.Date(2024, 11, 23, 12, 0, 0, 0, time.UTC)
+		mockRepo.On("GetRecentSongs", mock.Anything, sq).Return([]models.RecentSong{
+			{Song: models.Song{Id: 7, Name: "Uprising", GroupName: "Muse"}, AddedAt: added},
+		}, nil)
+
+		w := performRequest(r, "GET", "/analytics/recent?max=1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":7`)
+		assert.Contains(t, w.Body.String(), `"addedAt":"2024-11-23T12:00:00Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,92 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func TestDuplicates(t *testing.T) {
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		w := performRequest(r, "GET", "/admin/duplicates")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = performRequestWithBody(r, "POST", "/admin/songs/merge", models.SongMerge{TargetId: 1, SourceIds: []int{2}})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		dq := &models.DuplicatesQuery{PageMaxQuery: models.PageMaxQuery{Page: 0, Max: 1}, MinLyrics: 0.5}
+		mockRepo.On("ListDuplicates", mock.Anything, dq).Return([]models.DuplicatePair{{
+			Song:      models.Song{Id: 1, Name: "Uprising", GroupName: "Muse"},
+			Duplicate: models.Song{Id: 7, Name: "uprising.", GroupName: "MUSE"},
+			SameTitle: true,
+			Lyrics:    0.9,
+		}}, 3, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/duplicates?minLyrics=0.5&max=1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"sameTitle":true,"lyrics":0.9`)
+		assert.Contains(t, w.Body.String(), `"next":true`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("DefaultSimilarity", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		dq := models.NewDuplicatesQuery()
+		mockRepo.On("ListDuplicates", mock.Anything, &dq).Return([]models.DuplicatePair(nil), 0, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/duplicates"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+		mockRepo.AssertExpectations(t)
+
+		w = performRawRequest(r, asAdmin("GET", "/admin/duplicates?minLyrics=2"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		sm := &models.SongMerge{TargetId: 1, SourceIds: []int{7, 9}}
+		mockRepo.On("MergeSongs", mock.Anything, sm).Return(models.SongMerged{Id: 1, Merged: []int{7, 9}, Fields: []string{"link"}}, nil)
+
+		w := performRawRequest(r, signedAs(newJSONRequest("POST", "/admin/songs/merge", sm), testAdmin))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":{"id":1,"merged":[7,9],"fields":["link"]}`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("MergeUnknown", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("MergeSongs", mock.Anything, mock.Anything).Return(models.SongMerged{}, sql.ErrNoRows)
+
+		w := performRawRequest(r, signedAs(newJSONRequest("POST", "/admin/songs/merge", models.SongMerge{TargetId: 1, SourceIds: []int{404}}), testAdmin))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("MergeInvalid", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+
+		for _, sm := range []models.SongMerge{
+			{TargetId: 1},
+			{TargetId: 1, SourceIds: []int{1}},
+			{TargetId: 1, SourceIds: []int{2, 2}},
+			{SourceIds: []int{2}},
+		} {
+			w := performRawRequest(r, signedAs(newJSONRequest("POST", "/admin/songs/merge", sm), testAdmin))
+			assert.Equal(t, http.StatusBadRequest, w.Code, sm)
+		}
+		mockRepo.AssertNotCalled(t, "MergeSongs", mock.Anything, mock.Anything)
+	})
+}
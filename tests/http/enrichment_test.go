@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	handlers "github.com/nikuma0/test-effective-mobile-golang/internal/http"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func initEnrichmentHelper() (*gin.Engine, *MockSongsRepository) {
+	mockRepo := new(MockSongsRepository)
+	handler := handlers.NewTest(mockRepo).WithEnrichment()
+	r := gin.Default()
+	handler.Routes(r.Group(""))
+	return r, mockRepo
+}
+
+func TestCreateSongEnrichment(t *testing.T) {
+	t.Run("MissingFieldsQueued", func(t *testing.T) {
+		r, mockRepo := initEnrichmentHelper()
+		scq := &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Link: "https://example.com"}
+		mockRepo.On("CreateSong", mock.Anything, scq).Return(5, nil)
+		mockRepo.On("EnqueueEnrichment", mock.Anything, 5, []string{models.EnrichText, models.EnrichReleaseDate}).Return(nil)
+
+		w := performRequestWithBody(r, "POST", "/songs", scq)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, `{"ok":true,"data":{"id":5,"enrichmentStatus":"pending"}}`, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NothingMissing", func(t *testing.T) {
+		r, mockRepo := initEnrichmentHelper()
+		date := models.DateFormat{}
+		scq := &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia", Link: "https://example.com", ReleaseDate: &date}
+		mockRepo.On("CreateSong", mock.Anything, mock.Anything).Return(5, nil)
+
+		w := performRequestWithBody(r, "POST", "/songs", scq)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"enrichmentStatus":"none"`)
+		mockRepo.AssertNotCalled(t, "EnqueueEnrichment", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		scq := &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom", Link: "https://example.com/uprising"}
+		mockRepo.On("CreateSong", mock.Anything, scq).Return(5, nil)
+
+		w := performRequestWithBody(r, "POST", "/songs", scq)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"enrichmentStatus":"none"`)
+		mockRepo.AssertNotCalled(t, "EnqueueEnrichment", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DisabledRequiresTextAndLink", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		for _, scq := range []models.SongCreateQuery{
+			{Group: "Muse", Song: "Uprising"},
+			{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"},
+			{Group: "Muse", Song: "Uprising", Link: "https://example.com/uprising"},
+		} {
+			w := performRequestWithBody(r, "POST", "/songs", scq)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+		mockRepo.AssertNotCalled(t, "CreateSong", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnrichSong(t *testing.T) {
+	t.Run("Queued", func(t *testing.T) {
+		r, mockRepo := initEnrichmentHelper()
+		mockRepo.On("EnqueueEnrichment", mock.Anything, 3, models.EnrichFields).Return(nil)
+
+		w := performRequest(r, "POST", "/songs/3/enrich")
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, `{"ok":true,"data":{"id":3,"enrichmentStatus":"pending"}}`, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r, mockRepo := initEnrichmentHelper()
+		mockRepo.On("EnqueueEnrichment", mock.Anything, 3, models.EnrichFields).Return(sql.ErrNoRows)
+
+		w := performRequest(r, "POST", "/songs/3/enrich")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "POST", "/songs/three/enrich")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		r, _, _ := initHelper()
+		w := performRequest(r, "POST", "/songs/3/enrich")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,142 @@
+package http_test
+
+import (
+	"bufio"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/events"
+	handlers "github.com/nikuma0/test-effective-mobile-golang/internal/http"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func initEventsHelper(t *testing.T) (*httptest.Server, *events.Broker, *MockSongsRepository) {
+	mockRepo := new(MockSongsRepository)
+	broker := events.NewBroker()
+	handler := handlers.NewTest(mockRepo).WithEvents(broker)
+	r := gin.Default()
+	handler.Routes(r.Group(""))
+	srv := httptest.NewServer(r)
+	t.Cleanup(func() {
+		broker.Close()
+		srv.Close()
+	})
+	return srv, broker, mockRepo
+}
+
+// readEvent reads the lines of the next event up to the blank line ending it,
+// skipping heartbeats.
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && len(lines) > 0:
+			return lines
+		case line == "", strings.HasPrefix(line, ":"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestStreamSongEvents(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("ResumeAndLive", func(t *testing.T) {
+		srv, broker, mockRepo := initEventsHelper(t)
+		mockRepo.On("ListSongEvents", mock.Anything, int64(5), 100).Return([]models.SongEvent{
+			{Id: 6, Type: models.SongEventCreate, SongId: 1, At: at},
+			{Id: 7, Type: models.SongEventUpdate, SongId: 1, At: at},
+		}, nil)
+		mockRepo.On("ListSongEvents", mock.Anything, int64(7), 100).Return([]models.SongEvent{
+			{Id: 8, Type: models.SongEventDelete, SongId: 1, At: at},
+		}, nil).Once()
+		mockRepo.On("ListSongEvents", mock.Anything, int64(8), 100).Return([]models.SongEvent{}, nil).Maybe()
+
+		req, _ := http.NewRequest("GET", srv.URL+"/songs/events", nil)
+		req.Header.Set("Last-Event-ID", "5")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		body := bufio.NewReader(resp.Body)
+		assert.Equal(t, []string{
+			"id:6",
+			"event:create",
+			`data:{"id":6,"type":"create","songId":1,"at":"2026-01-02T03:04:05Z"}`,
+		}, readEvent(t, body))
+		assert.Equal(t, "id:7", readEvent(t, body)[0])
+
+		broker.Notify()
+		assert.Equal(t, []string{
+			"id:8",
+			"event:delete",
+			`data:{"id":8,"type":"delete","songId":1,"at":"2026-01-02T03:04:05Z"}`,
+		}, readEvent(t, body))
+	})
+
+	t.Run("StartsAtNewest", func(t *testing.T) {
+		srv, broker, mockRepo := initEventsHelper(t)
+		mockRepo.On("LastSongEventId", mock.Anything).Return(int64(41), nil)
+		firstRead := make(chan struct{})
+		mockRepo.On("ListSongEvents", mock.Anything, int64(41), 100).Return([]models.SongEvent{}, nil).
+			Run(func(mock.Arguments) { close(firstRead) }).Once()
+		mockRepo.On("ListSongEvents", mock.Anything, int64(41), 100).Return([]models.SongEvent{
+			{Id: 42, Type: models.SongEventUpdate, SongId: 3, At: at},
+		}, nil).Once()
+		mockRepo.On("ListSongEvents", mock.Anything, int64(42), 100).Return([]models.SongEvent{}, nil).Maybe()
+
+		resp, err := http.Get(srv.URL + "/songs/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// Wait for the first, empty read before notifying.
+		<-firstRead
+		broker.Notify()
+		assert.Equal(t, "id:42", readEvent(t, bufio.NewReader(resp.Body))[0])
+	})
+
+	t.Run("InvalidId", func(t *testing.T) {
+		srv, _, _ := initEventsHelper(t)
+		resp, err := http.Get(srv.URL + "/songs/events?lastEventId=abc")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		r, _, _ := initHelper()
+		w := performRequest(r, "GET", "/songs/events")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestDeleteSong(t *testing.T) {
+	t.Run("Simple", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("DeleteSong", mock.Anything, 1).Return(nil)
+		w := performRequest(r, "DELETE", "/songs/1")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("DeleteSong", mock.Anything, 9).Return(sql.ErrNoRows)
+		w := performRequest(r, "DELETE", "/songs/9")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
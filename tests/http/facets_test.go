@@ -0,0 +1,48 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSongFacets(t *testing.T) {
+	songs := []models.Song{{Id: 1, Name: "Uprising", GroupName: "Muse", Tags: []string{}}}
+
+	t.Run("WithoutFacets", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("GetSongs", mock.Anything, mock.Anything).Return(songs, 1, nil)
+
+		w := performRequest(r, "GET", "/songs")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"facets"`)
+		mockRepo.AssertNotCalled(t, "GetSongFacets", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GroupAndYear", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 10, Group: utils.Ptr("Muse"), Facets: "group, year,group"}
+		mockRepo.On("GetSongs", mock.Anything, sq).Return(songs, 1, nil)
+		mockRepo.On("GetSongFacets", mock.Anything, sq, []string{"group", "year"}).Return(models.SongFacets{
+			"group": {{Value: "Muse", Count: 1}},
+			"year":  {},
+		}, nil)
+
+		w := performRequest(r, "GET", "/songs?group=Muse&facets=group,%20year,group")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"ok":true,"facets":{"group":[{"value":"Muse","count":1}],"year":[]}`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UnknownFacet", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		w := performRequest(r, "GET", "/songs?facets=mood")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "GetSongs", mock.Anything, mock.Anything)
+	})
+}
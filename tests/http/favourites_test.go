@@ -0,0 +1,184 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/auth"
+	handlers "github.com/nikuma0/test-effective-mobile-golang/internal/http"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+type MockUsersRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsersRepository) Authenticate(ctx context.Context, username, password string) (models.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUsersRepository) GetUser(ctx context.Context, userId int) (models.User, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+var testTokens = auth.NewTokens("test-secret", time.Hour)
+
+func initAuthHelper() (*gin.Engine, *MockSongsRepository, *MockUsersRepository) {
+	mockRepo := new(MockSongsRepository)
+	mockUsers := new(MockUsersRepository)
+	handler := handlers.NewTest(mockRepo).WithAuth(mockUsers, testTokens)
+	r := gin.Default()
+	handler.Routes(r.Group(""))
+	return r, mockRepo, mockUsers
+}
+
+// testAdmin is who the admin endpoints are called as.
+var testAdmin = models.User{Id: 1, Username: "root", IsAdmin: true}
+
+// signedAs adds a bearer token of user to req.
+func signedAs(req *http.Request, user models.User) *http.Request {
+	token, _ := testTokens.Issue(user.Id, time.Now())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// asAdmin builds a request without a body signed as testAdmin.
+func asAdmin(method, url string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	return signedAs(req, testAdmin)
+}
+
+func TestAuth(t *testing.T) {
+	alice := models.User{Id: 3, Username: "alice"}
+
+	t.Run("Token", func(t *testing.T) {
+		r, _, mockUsers := initAuthHelper()
+		mockUsers.On("Authenticate", mock.Anything, "alice", "secret").Return(alice, nil)
+
+		w := performRequestWithBody(r, "POST", "/auth/token", models.Credentials{Username: "alice", Password: "secret"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"3.`)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		r, _, mockUsers := initAuthHelper()
+		mockUsers.On("Authenticate", mock.Anything, "alice", "nope").Return(models.User{}, postgresql.ErrInvalidCredentials)
+
+		req, _ := http.NewRequest("GET", "/songs", nil)
+		req.SetBasicAuth("alice", "nope")
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("ForgedToken", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		token, _ := auth.NewTokens("other-secret", time.Hour).Issue(3, time.Now())
+
+		req, _ := http.NewRequest("GET", "/songs", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestFavourites(t *testing.T) {
+	alice := models.User{Id: 3, Username: "alice"}
+	token, _ := testTokens.Issue(alice.Id, time.Now())
+	withToken := func(method, url string) *http.Request {
+		req, _ := http.NewRequest(method, url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	t.Run("RequiresUser", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		w := performRequest(r, "PUT", "/songs/1/favourite")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = performRequest(r, "GET", "/me/favourites")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("AddFavourite", mock.Anything, 3, 1).Return(nil)
+
+		w := performRawRequest(r, withToken("PUT", "/songs/1/favourite"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("IsFavouriteFlag", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+		mockRepo.On("GetSongs", mock.Anything, mock.Anything).Return([]models.Song{{Id: 1}, {Id: 2}}, 2, nil)
+		mockRepo.On("GetFavouriteIds", mock.Anything, 3, []int{1, 2}).Return(map[int]bool{2: true}, nil)
+
+		w := performRawRequest(r, withToken("GET", "/songs"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":1,`)
+		assert.Contains(t, w.Body.String(), `"isFavourite":false`)
+		assert.Contains(t, w.Body.String(), `"isFavourite":true`)
+	})
+
+	t.Run("AnonymousHasNoFlag", func(t *testing.T) {
+		r, mockRepo, _ := initAuthHelper()
+		mockRepo.On("GetSongs", mock.Anything, mock.Anything).Return([]models.Song{{Id: 1}}, 1, nil)
+
+		w := performRequest(r, "GET", "/songs")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `isFavourite`)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+		sq := &models.SongsQuery{Page: 1, Max: 1}
+		mockRepo.On("GetFavourites", mock.Anything, 3, sq).Return([]models.Song{{Id: 2}}, 3, nil)
+
+		w := performRawRequest(r, withToken("GET", "/me/favourites?page=1&max=1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"page":1,"amount":3,"next":true`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ListEmpty", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+		mockRepo.On("GetFavourites", mock.Anything, 3, mock.Anything).Return([]models.Song(nil), 0, nil)
+
+		w := performRawRequest(r, withToken("GET", "/me/favourites"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+	})
+
+	t.Run("ListSorted", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+		sq := &models.SongsQuery{Max: 10, Sort: models.SortRating}
+		mockRepo.On("GetFavourites", mock.Anything, 3, sq).Return([]models.Song{{Id: 2}}, 1, nil)
+
+		w := performRawRequest(r, withToken("GET", "/me/favourites?sort=rating"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ListBadSort", func(t *testing.T) {
+		r, _, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+
+		w := performRawRequest(r, withToken("GET", "/me/favourites?sort=name"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
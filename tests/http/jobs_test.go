@@ -0,0 +1,60 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func TestJobs(t *testing.T) {
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		w := performRequest(r, "GET", "/admin/jobs")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		jq := &models.JobsQuery{PageMaxQuery: models.PageMaxQuery{Page: 0, Max: 1}, Kind: "jobs.purge", Status: models.JobDead}
+		mockRepo.On("ListJobs", mock.Anything, jq).Return([]models.Job{{Id: 4, Kind: "jobs.purge", Status: models.JobDead}}, 2, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/jobs?kind=jobs.purge&status=dead&max=1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":4`)
+		assert.Contains(t, w.Body.String(), `"next":true`)
+		mockRepo.AssertExpectations(t)
+
+		w = performRawRequest(r, asAdmin("GET", "/admin/jobs?status=sleeping"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("CountJobs", mock.Anything).Return([]models.JobCount{{Kind: "jobs.purge", Status: models.JobDone, Count: 3}}, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/jobs/summary"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"kind":"jobs.purge","status":"done","count":3}`)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("RetryJob", mock.Anything, int64(4)).Return(nil)
+		mockRepo.On("RetryJob", mock.Anything, int64(5)).Return(sql.ErrNoRows)
+
+		w := performRawRequest(r, asAdmin("POST", "/admin/jobs/4/retry"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = performRawRequest(r, asAdmin("POST", "/admin/jobs/5/retry"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRawRequest(r, asAdmin("POST", "/admin/jobs/x/retry"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
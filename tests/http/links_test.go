@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestLinkReport(t *testing.T) {
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		w := performRequest(r, "GET", "/admin/links/report")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("GetLinkSummary", mock.Anything).Return(models.LinkSummary{Links: 10, Checked: 8, Broken: 2, Redirected: 1}, nil)
+		mockRepo.On("ListBrokenLinks", mock.Anything, &models.PageMaxQuery{Page: 0, Max: 1}).Return([]models.LinkCheck{{
+			SongId:     3,
+			Group:      "Muse",
+			Song:       "Uprising",
+			URL:        "https://example.com/removed",
+			StatusCode: utils.Ptr(404),
+			Broken:     true,
+		}}, 2, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/links/report?max=1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"songId":3`)
+		assert.Contains(t, w.Body.String(), `"statusCode":404`)
+		assert.Contains(t, w.Body.String(), `"next":true`)
+		assert.Contains(t, w.Body.String(), `"summary":{"links":10,"checked":8,"broken":2,"redirected":1}`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("GetLinkSummary", mock.Anything).Return(models.LinkSummary{}, nil)
+		mockRepo.On("ListBrokenLinks", mock.Anything, mock.Anything).Return([]models.LinkCheck(nil), 0, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/admin/links/report"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+	})
+}
+
+func TestSongsBrokenFilter(t *testing.T) {
+	r, _, mockRepo := initHelper()
+	sq := &models.SongsQuery{Page: 0, Max: 10, Broken: utils.Ptr(true)}
+	mockRepo.On("GetSongs", mock.Anything, sq).Return([]models.Song{{Id: 3, Tags: []string{}}}, 1, nil)
+
+	w := performRequest(r, "GET", "/songs?broken=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+
+	w = performRequest(r, "GET", "/songs?broken=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
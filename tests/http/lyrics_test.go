@@ -0,0 +1,80 @@
+package http_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestGetLyricsLRC(t *testing.T) {
+	t.Run("Synced", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("GetSyncedLyrics", mock.Anything, 1).Return(models.SyncedLyrics{
+			Title:  "Song",
+			Artist: "Group",
+			Lines: []models.LyricLine{
+				{Number: 1, Text: "first", StartMs: utils.Ptr(1500)},
+				{Number: 2, Text: "untimed"},
+			},
+		}, nil)
+		w := performRequest(r, "GET", "/songs/1/lyrics.lrc")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[ti:Song]\n[ar:Group]\n[00:01.50]first\n", w.Body.String())
+	})
+
+	t.Run("NotSynced", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("GetSyncedLyrics", mock.Anything, 1).Return(models.SyncedLyrics{
+			Lines: []models.LyricLine{{Number: 1, Text: "untimed"}},
+		}, nil)
+		w := performRequest(r, "GET", "/songs/1/lyrics.lrc")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("NoSong", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("GetSyncedLyrics", mock.Anything, 2).Return(models.SyncedLyrics{}, sql.ErrNoRows)
+		w := performRequest(r, "GET", "/songs/2/lyrics.lrc")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPutLyricsLRC(t *testing.T) {
+	t.Run("Simple", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("SetSyncedLyrics", mock.Anything, 1, []models.LyricLine{
+			{Number: 1, Text: "first", StartMs: utils.Ptr(1000)},
+			{Number: 2, Text: "second", StartMs: utils.Ptr(2000)},
+		}).Return(nil)
+		req, _ := http.NewRequest("PUT", "/songs/1/lyrics.lrc", bytes.NewBufferString("[00:02.00]second\n[00:01.00]first\n"))
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NotTheText", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("SetSyncedLyrics", mock.Anything, 1, mock.Anything).Return(postgresql.ErrLyricsMismatch)
+		req, _ := http.NewRequest("PUT", "/songs/1/lyrics.lrc", bytes.NewBufferString("[00:01.00]other\n"))
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		req, _ := http.NewRequest("PUT", "/songs/1/lyrics.lrc", bytes.NewBufferString("no time tag"))
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+}
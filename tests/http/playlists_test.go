@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestPlaylists(t *testing.T) {
+	alice := models.User{Id: 3, Username: "alice"}
+	bob := models.User{Id: 4, Username: "bob"}
+	as := func(user models.User, method, url string, body any) *http.Request {
+		req := newJSONRequest(method, url, body)
+		token, _ := testTokens.Issue(user.Id, time.Now())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+	private := models.Playlist{Id: 1, OwnerId: alice.Id, Title: "Mine", Visibility: models.VisibilityPrivate}
+	public := models.Playlist{Id: 2, OwnerId: alice.Id, Title: "Road trip", Visibility: models.VisibilityPublic}
+
+	t.Run("Create", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		pc := &models.PlaylistCreate{Title: "Mine"}
+		mockRepo.On("CreatePlaylist", mock.Anything, alice.Id, pc).Return(private, nil)
+
+		w := performRawRequest(r, as(alice, "POST", "/playlists", pc))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"visibility":"private"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("PrivateHiddenFromOthers", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, bob.Id).Return(bob, nil)
+		mockRepo.On("GetPlaylist", mock.Anything, 1).Return(private, nil)
+
+		w := performRawRequest(r, as(bob, "GET", "/playlists/1", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "GET", "/playlists/1")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("PublicReadOnlyForOthers", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, bob.Id).Return(bob, nil)
+		mockRepo.On("GetPlaylist", mock.Anything, 2).Return(public, nil)
+
+		w := performRequest(r, "GET", "/playlists/2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = performRawRequest(r, as(bob, "POST", "/playlists/2/entries", models.PlaylistEntryAdd{SongId: 5}))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockRepo.AssertNotCalled(t, "AddPlaylistEntry", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AddEntry", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		mockRepo.On("GetPlaylist", mock.Anything, 1).Return(private, nil)
+		mockRepo.On("CheckIfExists", mock.Anything, 5).Return(true, nil)
+		pea := &models.PlaylistEntryAdd{SongId: 5, Position: utils.Ptr(1)}
+		mockRepo.On("AddPlaylistEntry", mock.Anything, 1, pea).Return(models.PlaylistEntry{Id: 9, Position: 1, Song: models.Song{Id: 5}}, nil)
+
+		w := performRawRequest(r, as(alice, "POST", "/playlists/1/entries", pea))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":9,"position":1`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidOrder", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		mockRepo.On("GetPlaylist", mock.Anything, 1).Return(private, nil)
+		mockRepo.On("ReorderPlaylist", mock.Anything, 1, []int{3, 1}).Return(postgresql.ErrInvalidOrder)
+
+		w := performRawRequest(r, as(alice, "PUT", "/playlists/1/order", models.PlaylistOrder{EntryIds: []int{3, 1}}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ExportM3U8", func(t *testing.T) {
+		r, mockRepo, _ := initAuthHelper()
+		mockRepo.On("GetPlaylist", mock.Anything, 2).Return(public, nil)
+		mockRepo.On("ExportPlaylist", mock.Anything, 2).Return([]models.PlaylistEntry{
+			{Id: 1, Position: 1, Song: models.Song{Name: "Uprising", GroupName: "Muse", Link: "https://example.com/uprising"}},
+		}, nil)
+
+		w := performRequest(r, "GET", "/playlists/2/export.m3u8")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "audio/x-mpegurl; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=playlist-2.m3u8", w.Header().Get("Content-Disposition"))
+		assert.Contains(t, w.Body.String(), "#EXTINF:-1,Muse - Uprising\nhttps://example.com/uprising\n")
+	})
+}
@@ -0,0 +1,129 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestRecordPlays(t *testing.T) {
+	plays := []models.PlayEvent{{EventId: "a", SongId: 1}, {EventId: "b", SongId: 2}}
+
+	t.Run("Anonymous", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("RecordPlays", mock.Anything, (*int)(nil), plays).Return(models.PlaysResult{Recorded: 1, Ignored: 1}, nil)
+
+		w := performRequestWithBody(r, "POST", "/plays", models.PlaysBatch{Plays: plays})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true,"data":{"recorded":1,"ignored":1}}`, w.Body.String())
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		token, _ := testTokens.Issue(3, time.Now())
+		mockUsers.On("GetUser", mock.Anything, 3).Return(models.User{Id: 3, Username: "alice"}, nil)
+		mockRepo.On("RecordPlays", mock.Anything, utils.Ptr(3), plays).Return(models.PlaysResult{Recorded: 2}, nil)
+
+		req := newJSONRequest("POST", "/plays", models.PlaysBatch{Plays: plays})
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("MissingEventId", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		w := performRequestWithBody(r, "POST", "/plays", models.PlaysBatch{Plays: []models.PlayEvent{{SongId: 1}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "RecordPlays")
+	})
+
+	t.Run("TooMany", func(t *testing.T) {
+		r, _, _ := initHelper()
+		many := make([]models.PlayEvent, models.MaxPlaysBatch+1)
+		for i := range many {
+			many[i] = models.PlayEvent{EventId: "e", SongId: 1}
+		}
+		w := performRequestWithBody(r, "POST", "/plays", models.PlaysBatch{Plays: many})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRatings(t *testing.T) {
+	alice := models.User{Id: 3, Username: "alice"}
+	token, _ := testTokens.Issue(alice.Id, time.Now())
+	withToken := func(req *http.Request) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	t.Run("RequiresUser", func(t *testing.T) {
+		r, _, _ := initAuthHelper()
+		w := performRequestWithBody(r, "PUT", "/songs/1/rating", models.RatingSet{Stars: 4})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Rate", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("RateSong", mock.Anything, alice.Id, 1, 4).Return(nil)
+		rating := models.SongRating{SongId: 1, Popularity: models.Popularity{Plays: 10, Rating: utils.Ptr(4.0), Ratings: 1}, Mine: utils.Ptr(4)}
+		mockRepo.On("GetSongRating", mock.Anything, 1, utils.Ptr(alice.Id)).Return(rating, nil)
+
+		w := performRawRequest(r, withToken(newJSONRequest("PUT", "/songs/1/rating", models.RatingSet{Stars: 4})))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true,"data":{"songId":1,"plays":10,"rating":4,"ratings":1,"mine":4}}`, w.Body.String())
+	})
+
+	t.Run("OutOfRange", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+
+		w := performRawRequest(r, withToken(newJSONRequest("PUT", "/songs/1/rating", models.RatingSet{Stars: 6})))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "RateSong")
+	})
+
+	t.Run("UnknownSong", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		mockRepo.On("CheckIfExists", mock.Anything, 9).Return(false, nil)
+
+		w := performRawRequest(r, withToken(newJSONRequest("PUT", "/songs/9/rating", models.RatingSet{Stars: 3})))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("DeleteNotRated", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, alice.Id).Return(alice, nil)
+		mockRepo.On("DeleteRating", mock.Anything, alice.Id, 1).Return(sql.ErrNoRows)
+
+		req, _ := http.NewRequest("DELETE", "/songs/1/rating", nil)
+		w := performRawRequest(r, withToken(req))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetAnonymous", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("GetSongRating", mock.Anything, 1, (*int)(nil)).Return(models.SongRating{SongId: 1}, nil)
+
+		w := performRequest(r, "GET", "/songs/1/rating")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true,"data":{"songId":1,"plays":0,"rating":null,"ratings":0}}`, w.Body.String())
+	})
+}
+
+func TestSongsSort(t *testing.T) {
+	r, _, mockRepo := initHelper()
+	w := performRequest(r, "GET", "/songs?sort=newest")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetSongs")
+}
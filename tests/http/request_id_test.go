@@ -0,0 +1,37 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	handlers "github.com/nikuma0/test-effective-mobile-golang/internal/http"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestRequestID(t *testing.T) {
+	newRouter := func() *gin.Engine {
+		handler := handlers.NewTest(new(MockSongsRepository))
+		r := gin.New()
+		r.Use(utils.RequestIDMiddleware())
+		handler.Routes(r.Group(""))
+		return r
+	}
+
+	t.Run("Generated", func(t *testing.T) {
+		w := performRequestWithBody(newRouter(), "POST", "/songs", `InvalidJson`)
+		id := w.Header().Get(utils.RequestIDHeader)
+		assert.NotEmpty(t, id)
+		assert.Contains(t, w.Body.String(), `"requestId":"`+id+`"`)
+	})
+
+	t.Run("Propagated", func(t *testing.T) {
+		req, _ := http.NewRequest("PATCH", "/songs/abc", nil)
+		req.Header.Set(utils.RequestIDHeader, "my-request")
+		w := performRawRequest(newRouter(), req)
+		assert.Equal(t, "my-request", w.Header().Get(utils.RequestIDHeader))
+		assert.Contains(t, w.Body.String(), `"requestId":"my-request"`)
+	})
+}
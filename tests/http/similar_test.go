@@ -0,0 +1,51 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func TestListSimilarSongs(t *testing.T) {
+	t.Run("Simple", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("GetSimilarSongs", mock.Anything, 1, 10).Return([]models.SimilarSong{{
+			Song:    models.Song{Id: 2, Name: "Song 2"},
+			Score:   0.4,
+			Reasons: models.SimilarityReasons{Lyrics: 0.5, SameGroup: true},
+		}}, nil)
+
+		w := performRequest(r, "GET", "/songs/1/similar")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"score":0.4,"reasons":{"lyrics":0.5,"tags":0,"sameGroup":true}`)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("GetSimilarSongs", mock.Anything, 1, 3).Return([]models.SimilarSong(nil), nil)
+
+		w := performRequest(r, "GET", "/songs/1/similar?limit=3")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true,"data":[]}`, w.Body.String())
+	})
+
+	t.Run("LimitTooLarge", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		w := performRequest(r, "GET", "/songs/1/similar?limit=51")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "GetSimilarSongs")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 7).Return(false, nil)
+		w := performRequest(r, "GET", "/songs/7/similar")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
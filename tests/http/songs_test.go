@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	handlers "github.com/nikuma0/test-effective-mobile-golang/internal/http"
 	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
@@ -37,6 +40,301 @@ func (m *MockSongsRepository) GetSongText(ctx context.Context, songId int, pmq *
 	return args.Get(0).([]string), args.Get(1).(int), args.Error(1)
 }
 
+func (m *MockSongsRepository) GetSongLines(ctx context.Context, songId int, pmq *models.PageMaxQuery) ([]models.LyricLine, int, error) {
+	args := m.Called(ctx, songId, pmq)
+	return args.Get(0).([]models.LyricLine), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetSyncedLyrics(ctx context.Context, songId int) (models.SyncedLyrics, error) {
+	args := m.Called(ctx, songId)
+	return args.Get(0).(models.SyncedLyrics), args.Error(1)
+}
+
+func (m *MockSongsRepository) SetSyncedLyrics(ctx context.Context, songId int, lines []models.LyricLine) error {
+	args := m.Called(ctx, songId, lines)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) ListSongTexts(ctx context.Context, songId int) ([]models.SongTextInfo, error) {
+	args := m.Called(ctx, songId)
+	return args.Get(0).([]models.SongTextInfo), args.Error(1)
+}
+
+func (m *MockSongsRepository) UpsertSongText(ctx context.Context, songId int, lang string, stu *models.SongTextUpsert) error {
+	args := m.Called(ctx, songId, lang, stu)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) GetTranslation(ctx context.Context, songId int, lang string) (string, error) {
+	args := m.Called(ctx, songId, lang)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetTranslationLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) ([]string, int, error) {
+	args := m.Called(ctx, songId, lang, pmq)
+	return args.Get(0).([]string), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetParallelLines(ctx context.Context, songId int, lang string, pmq *models.PageMaxQuery) ([]models.ParallelLine, int, error) {
+	args := m.Called(ctx, songId, lang, pmq)
+	return args.Get(0).([]models.ParallelLine), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetSongStats(ctx context.Context, songId int, top int) (models.SongStats, error) {
+	args := m.Called(ctx, songId, top)
+	return args.Get(0).(models.SongStats), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetGroupStats(ctx context.Context, sq *models.StatsQuery) ([]models.GroupStats, int, error) {
+	args := m.Called(ctx, sq)
+	return args.Get(0).([]models.GroupStats), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) CountSongsByGroup(ctx context.Context, sq *models.SongsQuery) ([]models.GroupCount, int, error) {
+	args := m.Called(ctx, sq)
+	return args.Get(0).([]models.GroupCount), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetTopGroups(ctx context.Context, sq *models.SongsQuery) ([]models.GroupCount, error) {
+	args := m.Called(ctx, sq)
+	return args.Get(0).([]models.GroupCount), args.Error(1)
+}
+
+func (m *MockSongsRepository) CountReleases(ctx context.Context, sq *models.SongsQuery, interval string) ([]models.ReleaseBucket, error) {
+	args := m.Called(ctx, sq, interval)
+	return args.Get(0).([]models.ReleaseBucket), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetRecentSongs(ctx context.Context, sq *models.SongsQuery) ([]models.RecentSong, error) {
+	args := m.Called(ctx, sq)
+	return args.Get(0).([]models.RecentSong), args.Error(1)
+}
+
+func (m *MockSongsRepository) ListTags(ctx context.Context, tq *models.TagsQuery) ([]models.Tag, error) {
+	args := m.Called(ctx, tq)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetTag(ctx context.Context, tagId int) (models.Tag, error) {
+	args := m.Called(ctx, tagId)
+	return args.Get(0).(models.Tag), args.Error(1)
+}
+
+func (m *MockSongsRepository) CreateTag(ctx context.Context, tc *models.TagCreate) (models.Tag, error) {
+	args := m.Called(ctx, tc)
+	return args.Get(0).(models.Tag), args.Error(1)
+}
+
+func (m *MockSongsRepository) UpdateTag(ctx context.Context, tagId int, tu *models.TagUpdate) (models.Tag, error) {
+	args := m.Called(ctx, tagId, tu)
+	return args.Get(0).(models.Tag), args.Error(1)
+}
+
+func (m *MockSongsRepository) DeleteTag(ctx context.Context, tagId int) error {
+	args := m.Called(ctx, tagId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) CountTags(ctx context.Context, sq *models.SongsQuery) ([]models.TagCount, error) {
+	args := m.Called(ctx, sq)
+	return args.Get(0).([]models.TagCount), args.Error(1)
+}
+
+func (m *MockSongsRepository) AddFavourite(ctx context.Context, userId, songId int) error {
+	args := m.Called(ctx, userId, songId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) RemoveFavourite(ctx context.Context, userId, songId int) error {
+	args := m.Called(ctx, userId, songId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) GetFavourites(ctx context.Context, userId int, sq *models.SongsQuery) ([]models.Song, int, error) {
+	args := m.Called(ctx, userId, sq)
+	return args.Get(0).([]models.Song), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetFavouriteIds(ctx context.Context, userId int, songIds []int) (map[int]bool, error) {
+	args := m.Called(ctx, userId, songIds)
+	return args.Get(0).(map[int]bool), args.Error(1)
+}
+
+func (m *MockSongsRepository) CreatePlaylist(ctx context.Context, ownerId int, pc *models.PlaylistCreate) (models.Playlist, error) {
+	args := m.Called(ctx, ownerId, pc)
+	return args.Get(0).(models.Playlist), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetPlaylist(ctx context.Context, playlistId int) (models.Playlist, error) {
+	args := m.Called(ctx, playlistId)
+	return args.Get(0).(models.Playlist), args.Error(1)
+}
+
+func (m *MockSongsRepository) UpdatePlaylist(ctx context.Context, playlistId int, pu *models.PlaylistUpdate) (models.Playlist, error) {
+	args := m.Called(ctx, playlistId, pu)
+	return args.Get(0).(models.Playlist), args.Error(1)
+}
+
+func (m *MockSongsRepository) DeletePlaylist(ctx context.Context, playlistId int) error {
+	args := m.Called(ctx, playlistId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) ListPlaylists(ctx context.Context, ownerId int, pmq *models.PageMaxQuery) ([]models.Playlist, int, error) {
+	args := m.Called(ctx, ownerId, pmq)
+	return args.Get(0).([]models.Playlist), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) GetPlaylistEntries(ctx context.Context, playlistId int, pmq *models.PageMaxQuery) ([]models.PlaylistEntry, int, error) {
+	args := m.Called(ctx, playlistId, pmq)
+	return args.Get(0).([]models.PlaylistEntry), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) ExportPlaylist(ctx context.Context, playlistId int) ([]models.PlaylistEntry, error) {
+	args := m.Called(ctx, playlistId)
+	return args.Get(0).([]models.PlaylistEntry), args.Error(1)
+}
+
+func (m *MockSongsRepository) AddPlaylistEntry(ctx context.Context, playlistId int, pea *models.PlaylistEntryAdd) (models.PlaylistEntry, error) {
+	args := m.Called(ctx, playlistId, pea)
+	return args.Get(0).(models.PlaylistEntry), args.Error(1)
+}
+
+func (m *MockSongsRepository) RemovePlaylistEntry(ctx context.Context, playlistId, entryId int) error {
+	args := m.Called(ctx, playlistId, entryId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) ReorderPlaylist(ctx context.Context, playlistId int, entryIds []int) error {
+	args := m.Called(ctx, playlistId, entryIds)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) RecordPlays(ctx context.Context, userId *int, plays []models.PlayEvent) (models.PlaysResult, error) {
+	args := m.Called(ctx, userId, plays)
+	return args.Get(0).(models.PlaysResult), args.Error(1)
+}
+
+func (m *MockSongsRepository) RateSong(ctx context.Context, userId, songId, stars int) error {
+	args := m.Called(ctx, userId, songId, stars)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) DeleteRating(ctx context.Context, userId, songId int) error {
+	args := m.Called(ctx, userId, songId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) GetSongRating(ctx context.Context, songId int, userId *int) (models.SongRating, error) {
+	args := m.Called(ctx, songId, userId)
+	return args.Get(0).(models.SongRating), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetSimilarSongs(ctx context.Context, songId, limit int) ([]models.SimilarSong, error) {
+	args := m.Called(ctx, songId, limit)
+	return args.Get(0).([]models.SimilarSong), args.Error(1)
+}
+
+func (m *MockSongsRepository) RefreshSimilarSongs(ctx context.Context, limit int) (int64, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSongsRepository) DeleteSong(ctx context.Context, songId int) error {
+	args := m.Called(ctx, songId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) ListSongEvents(ctx context.Context, afterId int64, limit int) ([]models.SongEvent, error) {
+	args := m.Called(ctx, afterId, limit)
+	return args.Get(0).([]models.SongEvent), args.Error(1)
+}
+
+func (m *MockSongsRepository) LastSongEventId(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSongsRepository) CreateWebhook(ctx context.Context, wc *models.WebhookCreate) (models.Webhook, error) {
+	args := m.Called(ctx, wc)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (m *MockSongsRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockSongsRepository) GetWebhook(ctx context.Context, webhookId int) (models.Webhook, error) {
+	args := m.Called(ctx, webhookId)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (m *MockSongsRepository) UpdateWebhook(ctx context.Context, webhookId int, wu *models.WebhookUpdate) (models.Webhook, error) {
+	args := m.Called(ctx, webhookId, wu)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (m *MockSongsRepository) DeleteWebhook(ctx context.Context, webhookId int) error {
+	args := m.Called(ctx, webhookId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) ListDeliveries(ctx context.Context, dq *models.DeliveriesQuery) ([]models.WebhookDelivery, int, error) {
+	args := m.Called(ctx, dq)
+	return args.Get(0).([]models.WebhookDelivery), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) RetryDelivery(ctx context.Context, deliveryId int64) error {
+	args := m.Called(ctx, deliveryId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) EnqueueJob(ctx context.Context, je *models.JobEnqueue) (int64, error) {
+	args := m.Called(ctx, je)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSongsRepository) ListJobs(ctx context.Context, jq *models.JobsQuery) ([]models.Job, int, error) {
+	args := m.Called(ctx, jq)
+	return args.Get(0).([]models.Job), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) CountJobs(ctx context.Context) ([]models.JobCount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.JobCount), args.Error(1)
+}
+
+func (m *MockSongsRepository) RetryJob(ctx context.Context, jobId int64) error {
+	args := m.Called(ctx, jobId)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) EnqueueEnrichment(ctx context.Context, songId int, fields []string) error {
+	args := m.Called(ctx, songId, fields)
+	return args.Error(0)
+}
+
+func (m *MockSongsRepository) GetLinkSummary(ctx context.Context) (models.LinkSummary, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.LinkSummary), args.Error(1)
+}
+
+func (m *MockSongsRepository) ListBrokenLinks(ctx context.Context, pmq *models.PageMaxQuery) ([]models.LinkCheck, int, error) {
+	args := m.Called(ctx, pmq)
+	return args.Get(0).([]models.LinkCheck), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) ListDuplicates(ctx context.Context, dq *models.DuplicatesQuery) ([]models.DuplicatePair, int, error) {
+	args := m.Called(ctx, dq)
+	return args.Get(0).([]models.DuplicatePair), args.Int(1), args.Error(2)
+}
+
+func (m *MockSongsRepository) MergeSongs(ctx context.Context, sm *models.SongMerge) (models.SongMerged, error) {
+	args := m.Called(ctx, sm)
+	return args.Get(0).(models.SongMerged), args.Error(1)
+}
+
 func (m *MockSongsRepository) GetSong(ctx context.Context, sdq *models.SongDetailQuery) (models.SongDetail, error) {
 	args := m.Called(ctx, sdq)
 	return args.Get(0).(models.SongDetail), args.Error(1)
@@ -52,9 +350,14 @@ func (m *MockSongsRepository) GetSongs(ctx context.Context, sq *models.SongsQuer
 	return args.Get(0).([]models.Song), args.Int(1), args.Error(2)
 }
 
-func (m *MockSongsRepository) CreateSong(ctx context.Context, scq *models.SongCreateQuery) error {
+func (m *MockSongsRepository) GetSongFacets(ctx context.Context, sq *models.SongsQuery, facets []string) (models.SongFacets, error) {
+	args := m.Called(ctx, sq, facets)
+	return args.Get(0).(models.SongFacets), args.Error(1)
+}
+
+func (m *MockSongsRepository) CreateSong(ctx context.Context, scq *models.SongCreateQuery) (int, error) {
 	args := m.Called(ctx, scq)
-	return args.Error(0)
+	return args.Int(0), args.Error(1)
 }
 
 func (m *MockSongsRepository) UpdateSong(ctx context.Context, su *models.SongUpdate, songId int) error {
@@ -75,6 +378,7 @@ func TestCreateSong(t *testing.T) {
 		Group: "Group",
 		Song:  "Song",
 		Text:  "Text",
+		Link:  "https://example.com/song",
 	}
 	cases := []struct {
 		name           string
@@ -89,7 +393,7 @@ func TestCreateSong(t *testing.T) {
 			body:           &simpleSongCreateQuery,
 			createSongData: &simpleSongCreateQuery,
 			exceptedStatus: http.StatusCreated,
-			exceptedBody:   `{"ok":true,"msg":"created"}`,
+			exceptedBody:   `{"ok":true,"data":{"id":1,"enrichmentStatus":"none"}}`,
 			isRepoCalled:   true,
 		},
 		{
@@ -114,7 +418,7 @@ func TestCreateSong(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			r, _, mockRepo := initHelper()
 			if tc.isRepoCalled {
-				mockRepo.On("CreateSong", mock.Anything, tc.createSongData).Return(nil)
+				mockRepo.On("CreateSong", mock.Anything, tc.createSongData).Return(1, nil)
 			}
 			w := performRequestWithBody(r, "POST", "/songs", tc.body)
 			assert.Equal(t, tc.exceptedStatus, w.Code)
@@ -150,25 +454,65 @@ func TestSongs(t *testing.T) {
 			Group:       "Group",
 			Song:        "Song",
 			Text:        "Text",
+			Link:        "https://example.com/song",
 			ReleaseDate: utils.Ptr(models.DateFormat(time.Date(2022, 11, 8, 0, 0, 0, 0, time.UTC))),
 		}
-		mockRepo.On("CreateSong", mock.Anything, newSong).Return(nil)
+		mockRepo.On("CreateSong", mock.Anything, newSong).Return(7, nil)
 		w := performRequestWithBody(r, "POST", "/songs", newSong)
 		assert.Equal(t, http.StatusCreated, w.Code)
 		assert.Contains(t, w.Body.String(), `"ok":true`)
-		assert.Contains(t, w.Body.String(), `"msg":"created"`)
+		assert.Contains(t, w.Body.String(), `"id":7`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update song logs field names only", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		hook := logtest.NewGlobal()
+		level := log.GetLevel()
+		log.SetLevel(log.DebugLevel)
+		t.Cleanup(func() {
+			log.SetLevel(level)
+			log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+		})
+
+		su := &models.SongUpdate{Text: utils.Ptr("Secret verse"), Link: utils.Ptr("https://example.com/private")}
+		mockRepo.On("CheckIfExists", mock.Anything, 7).Return(true, nil)
+		mockRepo.On("UpdateSong", mock.Anything, su).Return(nil)
+		w := performRequestWithBody(r, "PATCH", "/songs/7", su)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var entry *log.Entry
+		for _, e := range hook.AllEntries() {
+			if e.Message == "Songs updated" {
+				entry = e
+			}
+		}
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"text", "link"}, entry.Data["fields"])
+		line, err := entry.String()
+		require.NoError(t, err)
+		assert.NotContains(t, line, "Secret verse")
+		assert.NotContains(t, line, "private")
 		mockRepo.AssertExpectations(t)
 	})
 }
 
 func performRequest(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest(method, path, nil)
+	return performRawRequest(r, req)
+}
+
+func performRawRequest(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	return w
 }
 
 func performRequestWithBody(r *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	return performRawRequest(r, newJSONRequest(method, path, body))
+}
+
+func newJSONRequest(method, path string, body interface{}) *http.Request {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		panic(err)
@@ -176,7 +520,5 @@ func performRequestWithBody(r *gin.Engine, method, path string, body interface{}
 
 	req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
-	return w
+	return req
 }
@@ -0,0 +1,80 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSongStats(t *testing.T) {
+	stats := models.SongStats{
+		SongId: 1,
+		LyricsStats: models.LyricsStats{
+			Lines: 4, Verses: 2, Words: 8, UniqueWords: 3,
+			TopWords: []models.WordCount{{Word: "la", Count: 5}, {Word: "hey", Count: 2}, {Word: "you", Count: 1}},
+		},
+	}
+
+	t.Run("CachedUntilUpdate", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("GetSongStats", mock.Anything, 1, 100).Return(stats, nil)
+
+		w := performRequest(r, "GET", "/songs/1/stats?top=2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"topWords":[{"word":"la","count":5},{"word":"hey","count":2}]`)
+
+		w = performRequest(r, "GET", "/songs/1/stats")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"uniqueWords":3`)
+		mockRepo.AssertNumberOfCalls(t, "GetSongStats", 1)
+
+		mockRepo.On("UpdateSong", mock.Anything, mock.Anything).Return(nil)
+		w = performRequestWithBody(r, "PATCH", "/songs/1", models.SongUpdate{Text: utils.Ptr("new text")})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		performRequest(r, "GET", "/songs/1/stats")
+		mockRepo.AssertNumberOfCalls(t, "GetSongStats", 2)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 2).Return(false, nil)
+
+		w := performRequest(r, "GET", "/songs/2/stats")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockRepo.AssertNotCalled(t, "GetSongStats", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("InvalidTop", func(t *testing.T) {
+		r, _, _ := initHelper()
+		w := performRequest(r, "GET", "/songs/1/stats?top=1000")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGroupStats(t *testing.T) {
+	r, _, mockRepo := initHelper()
+	sq := &models.StatsQuery{Page: 0, Max: 1, Group: utils.Ptr("Muse"), Top: 10}
+	mockRepo.On("GetGroupStats", mock.Anything, sq).Return([]models.GroupStats{
+		{Group: "Muse", Songs: 2, LyricsStats: models.LyricsStats{Lines: 10, TopWords: []models.WordCount{}}},
+	}, 1, nil)
+
+	w := performRequest(r, "GET", "/groups/stats?max=1&group=Muse")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"group":"Muse","songs":2,"lines":10`)
+	assert.Contains(t, w.Body.String(), `"next":false`)
+
+	performRequest(r, "GET", "/groups/stats?max=1&group=Muse")
+	mockRepo.AssertNumberOfCalls(t, "GetGroupStats", 1)
+
+	mockRepo.On("CreateSong", mock.Anything, mock.Anything).Return(1, nil)
+	performRequestWithBody(r, "POST", "/songs", models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom", Link: "https://example.com"})
+	performRequest(r, "GET", "/groups/stats?max=1&group=Muse")
+	mockRepo.AssertNumberOfCalls(t, "GetGroupStats", 2)
+}
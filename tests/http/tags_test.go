@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestTags(t *testing.T) {
+	t.Run("Create", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		tc := &models.TagCreate{Name: "Rock", Kind: "genre"}
+		mockRepo.On("CreateTag", mock.Anything, tc).Return(models.Tag{Id: 1, Name: "rock", Kind: "genre"}, nil)
+
+		w := performRequestWithBody(r, "POST", "/tags", tc)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"ok":true,"data":{"id":1,"name":"rock","kind":"genre"}}`, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CreateTag", mock.Anything, mock.Anything).Return(models.Tag{}, postgresql.ErrTagExists)
+
+		w := performRequestWithBody(r, "POST", "/tags", models.TagCreate{Name: "rock"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("CreateInvalidKind", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		w := performRequestWithBody(r, "POST", "/tags", models.TagCreate{Name: "rock", Kind: "mood"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "CreateTag", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateBlankName", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		w := performRequestWithBody(r, "PATCH", "/tags/5", models.TagUpdate{Name: utils.Ptr("  ")})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "empty tag name")
+		mockRepo.AssertNotCalled(t, "UpdateTag", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListByKind", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("ListTags", mock.Anything, &models.TagsQuery{Kind: utils.Ptr("genre")}).Return([]models.Tag{{Id: 1, Name: "rock", Kind: "genre"}}, nil)
+
+		w := performRequest(r, "GET", "/tags?kind=genre")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"rock"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("DeleteTag", mock.Anything, 5).Return(sql.ErrNoRows)
+
+		w := performRequest(r, "DELETE", "/tags/5")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 10, Group: utils.Ptr("Muse"), Tags: []string{"rock"}}
+		mockRepo.On("CountTags", mock.Anything, sq).Return([]models.TagCount{
+			{Tag: models.Tag{Id: 1, Name: "rock", Kind: "genre"}, Songs: 3},
+			{Tag: models.Tag{Id: 2, Name: "live", Kind: "tag"}, Songs: 1},
+		}, nil)
+
+		w := performRequest(r, "GET", "/tags/counts?group=Muse&tag=rock")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"id":2,"name":"live","kind":"tag","songs":1}`)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSongsTagFilter(t *testing.T) {
+	t.Run("All", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		sq := &models.SongsQuery{Page: 0, Max: 10, Tags: []string{"rock", "live"}, TagMatch: "all"}
+		mockRepo.On("GetSongs", mock.Anything, sq).Return([]models.Song{{Id: 1, Tags: []string{"live", "rock"}}}, 1, nil)
+
+		w := performRequest(r, "GET", "/songs?tag=rock&tag=live&tagMatch=all")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"tags":["live","rock"]`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidMatch", func(t *testing.T) {
+		r, _, _ := initHelper()
+		w := performRequest(r, "GET", "/songs?tag=rock&tagMatch=some")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("AssignOnUpdate", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		su := &models.SongUpdate{Tags: &[]string{"rock"}}
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("UpdateSong", mock.Anything, su).Return(nil)
+
+		w := performRequestWithBody(r, "PATCH", "/songs/1", su)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,78 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestGetSongTextLanguage(t *testing.T) {
+	texts := []models.SongTextInfo{
+		{Lang: "en", Original: true},
+		{Lang: "pt-BR"},
+	}
+	pmq := &models.PageMaxQuery{Page: 0, Max: 10}
+
+	t.Run("AcceptLanguageFallsBackToBase", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("ListSongTexts", mock.Anything, 1).Return(texts, nil)
+		mockRepo.On("GetTranslationLines", mock.Anything, 1, "pt-BR", pmq).Return([]string{"linha"}, 1, nil)
+
+		req, _ := http.NewRequest("GET", "/songs/1/text", nil)
+		req.Header.Set("Accept-Language", "fr;q=0.9, pt;q=0.8")
+		w := performRawRequest(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "pt-BR", w.Header().Get("Content-Language"))
+		assert.Contains(t, w.Body.String(), `"linha"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("ListSongTexts", mock.Anything, 1).Return(texts, nil)
+		mockRepo.On("GetParallelLines", mock.Anything, 1, "pt-BR", pmq).Return([]models.ParallelLine{
+			{Number: 1, Original: utils.Ptr("line"), Translation: utils.Ptr("linha")},
+		}, 1, nil)
+
+		w := performRequest(r, "GET", "/songs/1/text?lang=pt-br&parallel=true")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"number":1,"original":"line","translation":"linha"}`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("MissingTranslation", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+		mockRepo.On("ListSongTexts", mock.Anything, 1).Return(texts, nil)
+
+		w := performRequest(r, "GET", "/songs/1/text?lang=de")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("InvalidLang", func(t *testing.T) {
+		r, _, mockRepo := initHelper()
+		mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+
+		w := performRequest(r, "GET", "/songs/1/text?lang=not_a_language!")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPutSongTextUnmarkOriginal(t *testing.T) {
+	r, _, mockRepo := initHelper()
+	stu := &models.SongTextUpsert{Text: "text"}
+	mockRepo.On("CheckIfExists", mock.Anything, 1).Return(true, nil)
+	mockRepo.On("UpsertSongText", mock.Anything, 1, "en", stu).Return(postgresql.ErrOriginalText)
+
+	w := performRequestWithBody(r, "PUT", "/songs/1/texts/en", stu)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockRepo.AssertExpectations(t)
+}
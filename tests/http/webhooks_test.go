@@ -0,0 +1,124 @@
+package http_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func TestWebhooks(t *testing.T) {
+	alice := models.User{Id: 3, Username: "alice"}
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		r, _, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 3).Return(alice, nil)
+
+		w := performRequest(r, "GET", "/webhooks")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		req, _ := http.NewRequest("GET", "/webhooks", nil)
+		w = performRawRequest(r, signedAs(req, alice))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("CreateGeneratesSecret", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("CreateWebhook", mock.Anything, mock.MatchedBy(func(wc *models.WebhookCreate) bool {
+			return len(wc.Secret) == 64
+		})).Return(models.Webhook{Id: 7, URL: "http://example.com/hook", Events: []string{"create"}, Active: true}, nil)
+
+		body := models.WebhookCreate{URL: "http://example.com/hook", Events: []string{"create"}}
+		w := performRawRequest(r, signedAs(newJSONRequest("POST", "/webhooks", body), testAdmin))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":7`)
+		assert.Contains(t, w.Body.String(), `"secret":"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateInvalid", func(t *testing.T) {
+		r, _, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+
+		for _, body := range []models.WebhookCreate{
+			{URL: "not a url", Events: []string{"create"}},
+			{URL: "http://example.com/hook", Events: []string{"play"}},
+			{URL: "http://example.com/hook"},
+			{URL: "http://example.com/hook", Events: []string{"create"}, Secret: "short"},
+		} {
+			w := performRawRequest(r, signedAs(newJSONRequest("POST", "/webhooks", body), testAdmin))
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("GetWebhook", mock.Anything, 9).Return(models.Webhook{}, sql.ErrNoRows)
+
+		w := performRawRequest(r, asAdmin("GET", "/webhooks/9"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRawRequest(r, asAdmin("GET", "/webhooks/nine"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Deactivate", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("UpdateWebhook", mock.Anything, 7, mock.MatchedBy(func(wu *models.WebhookUpdate) bool {
+			return wu.Active != nil && !*wu.Active && wu.URL == nil
+		})).Return(models.Webhook{Id: 7}, nil)
+
+		req := newJSONRequest("PATCH", "/webhooks/7", map[string]any{"active": false})
+		w := performRawRequest(r, signedAs(req, testAdmin))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("GetWebhook", mock.Anything, 7).Return(models.Webhook{Id: 7}, nil)
+		mockRepo.On("ListDeliveries", mock.Anything, mock.MatchedBy(func(dq *models.DeliveriesQuery) bool {
+			return *dq.WebhookId == 7 && dq.Status == models.DeliveryPending && dq.Max == 1
+		})).Return([]models.WebhookDelivery{{Id: 5, WebhookId: 7, Status: models.DeliveryPending}}, 2, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/webhooks/7/deliveries?status=pending&max=1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":5`)
+		assert.Contains(t, w.Body.String(), `"next":true`)
+		mockRepo.AssertExpectations(t)
+
+		w = performRawRequest(r, asAdmin("GET", "/webhooks/7/deliveries?status=lost"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("DeadLetters", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("ListDeliveries", mock.Anything, mock.MatchedBy(func(dq *models.DeliveriesQuery) bool {
+			return dq.WebhookId == nil && dq.Status == models.DeliveryDead
+		})).Return([]models.WebhookDelivery(nil), 0, nil)
+
+		w := performRawRequest(r, asAdmin("GET", "/webhooks/dead-letters"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		r, mockRepo, mockUsers := initAuthHelper()
+		mockUsers.On("GetUser", mock.Anything, 1).Return(testAdmin, nil)
+		mockRepo.On("RetryDelivery", mock.Anything, int64(5)).Return(nil)
+		mockRepo.On("RetryDelivery", mock.Anything, int64(6)).Return(sql.ErrNoRows)
+
+		w := performRawRequest(r, asAdmin("POST", "/webhooks/deliveries/5/retry"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = performRawRequest(r, asAdmin("POST", "/webhooks/deliveries/6/retry"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
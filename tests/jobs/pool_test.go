@@ -0,0 +1,316 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/jobs"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// fakeStore keeps jobs in memory, claiming those that are due.
+type fakeStore struct {
+	mu     sync.Mutex
+	jobs   []*models.Job
+	purged time.Time
+}
+
+func (s *fakeStore) EnqueueJob(ctx context.Context, je *models.JobEnqueue) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if je.Key != "" && j.Key != nil && *j.Key == je.Key {
+			return 0, nil
+		}
+	}
+	payload, _ := json.Marshal(je.Payload)
+	j := &models.Job{Id: int64(len(s.jobs) + 1), Kind: je.Kind, Payload: payload, Status: models.JobQueued}
+	if je.Key != "" {
+		j.Key = &je.Key
+	}
+	if je.RunAt != nil {
+		j.RunAt = *je.RunAt
+	}
+	s.jobs = append(s.jobs, j)
+	return j.Id, nil
+}
+
+func (s *fakeStore) ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []models.Job
+	for _, j := range s.jobs {
+		if len(res) < limit && j.Status == models.JobQueued && !j.RunAt.After(time.Now()) && slices.Contains(kinds, j.Kind) {
+			j.Status = models.JobRunning
+			j.Attempts++
+			j.RunAt = time.Now().Add(lease)
+			res = append(res, *j)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStore) CompleteJob(ctx context.Context, jobId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[jobId-1].Status = models.JobDone
+	return nil
+}
+
+func (s *fakeStore) FailJob(ctx context.Context, jobId int64, msg string, retryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.jobs[jobId-1]
+	j.LastError = &msg
+	if retryAt == nil {
+		j.Status = models.JobDead
+	} else {
+		// Retry right away rather than wait out the backoff.
+		j.Status, j.RunAt = models.JobQueued, time.Time{}
+	}
+	return nil
+}
+
+func (s *fakeStore) PurgeJobs(ctx context.Context, t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purged = t
+	return 0, nil
+}
+
+func (s *fakeStore) get(id int64) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[id-1]
+}
+
+func testConfig() config.JobsConfig {
+	return config.JobsConfig{
+		Concurrency:  2,
+		MaxAttempts:  3,
+		Backoff:      time.Second,
+		BackoffMax:   5 * time.Second,
+		Timeout:      time.Second,
+		PollInterval: time.Hour,
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, testConfig())
+	var got []string
+	pool.Register("greet", func(ctx context.Context, payload json.RawMessage) error {
+		var p struct{ Name string }
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return jobs.Permanent(err)
+		}
+		got = append(got, p.Name)
+		return nil
+	})
+	ctx := context.Background()
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "greet", Payload: map[string]string{"name": "alice"}})
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "greet", Payload: "not an object"})
+	later := time.Now().Add(time.Hour)
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "greet", Payload: map[string]string{"name": "bob"}, RunAt: &later})
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "unknown"})
+
+	n, err := pool.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"alice"}, got)
+	assert.Equal(t, models.JobDone, store.get(1).Status)
+	// A malformed payload dies without retries.
+	assert.Equal(t, models.JobDead, store.get(2).Status)
+	assert.Equal(t, 1, store.get(2).Attempts)
+	assert.Equal(t, models.JobQueued, store.get(3).Status)
+	// Kinds without a handler are left to servers that have one.
+	assert.Equal(t, 0, store.get(4).Attempts)
+}
+
+func TestRetries(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, testConfig())
+	calls := 0
+	pool.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		if calls == 2 {
+			return errors.New("upstream down")
+		}
+		return nil
+	})
+	pool.Register("broken", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("always")
+	})
+	ctx := context.Background()
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "flaky"})
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "broken"})
+
+	for range 5 {
+		_, err := pool.RunDue(ctx)
+		require.NoError(t, err)
+	}
+	flaky := store.get(1)
+	assert.Equal(t, models.JobDone, flaky.Status)
+	assert.Equal(t, 3, flaky.Attempts)
+	broken := store.get(2)
+	assert.Equal(t, models.JobDead, broken.Status)
+	assert.Equal(t, 3, broken.Attempts)
+	assert.Equal(t, "always", *broken.LastError)
+}
+
+func TestLastAttempt(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, testConfig())
+	var last []bool
+	pool.Register("broken", func(ctx context.Context, payload json.RawMessage) error {
+		last = append(last, jobs.LastAttempt(ctx))
+		return errors.New("always")
+	})
+	store.EnqueueJob(context.Background(), &models.JobEnqueue{Kind: "broken"})
+
+	for range 3 {
+		_, err := pool.RunDue(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []bool{false, false, true}, last)
+	assert.False(t, jobs.LastAttempt(context.Background()))
+}
+
+func TestTimeout(t *testing.T) {
+	store := &fakeStore{}
+	cfg := testConfig()
+	cfg.Timeout = 10 * time.Millisecond
+	pool := jobs.NewPool(store, cfg)
+	pool.Register("slow", func(ctx context.Context, payload json.RawMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	store.EnqueueJob(context.Background(), &models.JobEnqueue{Kind: "slow"})
+
+	_, err := pool.RunDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, context.DeadlineExceeded.Error(), *store.get(1).LastError)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, testConfig())
+	var (
+		mu       sync.Mutex
+		running  int
+		maxSeen  int
+		finished = make(chan struct{}, 5)
+		release  = make(chan struct{})
+	)
+	pool.Register("work", func(ctx context.Context, payload json.RawMessage) error {
+		mu.Lock()
+		running++
+		maxSeen = max(maxSeen, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		finished <- struct{}{}
+		return nil
+	})
+	for range 5 {
+		store.EnqueueJob(context.Background(), &models.JobEnqueue{Kind: "work"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		pool.Run(ctx, nil)
+		close(stopped)
+	}()
+	close(release)
+	for range 5 {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("jobs not run")
+		}
+	}
+	cancel()
+	<-stopped
+
+	assert.LessOrEqual(t, maxSeen, 2)
+	for id := range int64(5) {
+		assert.Eventually(t, func() bool { return store.get(id+1).Status == models.JobDone }, time.Second, time.Millisecond)
+	}
+}
+
+func TestRunWakes(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, testConfig())
+	ran := make(chan struct{}, 1)
+	pool.Register("work", func(ctx context.Context, payload json.RawMessage) error {
+		ran <- struct{}{}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wake := make(chan struct{})
+	go pool.Run(ctx, wake)
+
+	store.EnqueueJob(ctx, &models.JobEnqueue{Kind: "work"})
+	wake <- struct{}{}
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not run after wake-up")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	store := &fakeStore{}
+	cfg := testConfig()
+	cfg.Retention = 24 * time.Hour
+	pool := jobs.NewPool(store, cfg)
+	pool.Register("tick", func(ctx context.Context, payload json.RawMessage) error { return nil })
+	pool.Every("tick", time.Hour)
+
+	// Two servers sharing the database enqueue every run once.
+	ctx, cancel := context.WithCancel(context.Background())
+	go pool.Run(ctx, nil)
+	go pool.Run(ctx, nil)
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		done := 0
+		for _, j := range store.jobs {
+			if j.Status == models.JobDone {
+				done++
+			}
+		}
+		return done == 2
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	require.Len(t, store.jobs, 2)
+	kinds := []string{store.jobs[0].Kind, store.jobs[1].Kind}
+	assert.ElementsMatch(t, []string{jobs.KindPurge, "tick"}, kinds)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), store.purged, time.Minute)
+}
+
+func TestBackoff(t *testing.T) {
+	pool := jobs.NewPool(&fakeStore{}, testConfig())
+	assert.Equal(t, time.Second, pool.Backoff(1))
+	assert.Equal(t, 4*time.Second, pool.Backoff(3))
+	assert.Equal(t, 5*time.Second, pool.Backoff(10))
+}
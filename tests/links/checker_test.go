@@ -0,0 +1,225 @@
+package links_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/links"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+// newSite serves a few pages the way real sites fail.
+func newSite(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/to-file":
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		case "/get-only":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newChecker allows private addresses, as the test servers are on loopback.
+func newChecker(store links.Store, concurrency int) *links.Checker {
+	return links.NewChecker(store, config.LinksConfig{Interval: time.Hour, Concurrency: concurrency, Timeout: 200 * time.Millisecond, AllowPrivate: true})
+}
+
+func TestCheck(t *testing.T) {
+	srv := newSite(t)
+	checker := newChecker(nil, 1)
+	ctx := context.Background()
+
+	t.Run("Ok", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/ok"})
+		assert.Equal(t, 1, lc.SongId)
+		require.NotNil(t, lc.StatusCode)
+		assert.Equal(t, http.StatusOK, *lc.StatusCode)
+		assert.Nil(t, lc.RedirectURL)
+		assert.Nil(t, lc.Error)
+		assert.False(t, lc.Broken)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/removed"})
+		require.NotNil(t, lc.StatusCode)
+		assert.Equal(t, http.StatusNotFound, *lc.StatusCode)
+		assert.True(t, lc.Broken)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/moved"})
+		require.NotNil(t, lc.StatusCode)
+		assert.Equal(t, http.StatusOK, *lc.StatusCode)
+		require.NotNil(t, lc.RedirectURL)
+		assert.Equal(t, srv.URL+"/ok", *lc.RedirectURL)
+		assert.False(t, lc.Broken)
+	})
+
+	t.Run("HEAD not allowed", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/get-only"})
+		require.NotNil(t, lc.StatusCode)
+		assert.Equal(t, http.StatusOK, *lc.StatusCode)
+		assert.False(t, lc.Broken)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/slow"})
+		assert.Nil(t, lc.StatusCode)
+		require.NotNil(t, lc.Error)
+		assert.Equal(t, "timed out", *lc.Error)
+		assert.True(t, lc.Broken)
+	})
+
+	t.Run("Redirect loop", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: srv.URL + "/loop"})
+		assert.Nil(t, lc.StatusCode)
+		require.NotNil(t, lc.Error)
+		assert.Contains(t, *lc.Error, "stopped after 5 redirects")
+		assert.True(t, lc.Broken)
+	})
+
+	t.Run("Not http", func(t *testing.T) {
+		for _, url := range []string{"file:///etc/passwd", srv.URL + "/to-file"} {
+			lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: url})
+			require.NotNil(t, lc.Error, url)
+			assert.Contains(t, *lc.Error, "only http and https links are checked", url)
+			assert.True(t, lc.Broken, url)
+		}
+	})
+
+	t.Run("No server", func(t *testing.T) {
+		lc := checker.Check(ctx, models.LinkTarget{SongId: 1, URL: "http://127.0.0.1:1/"})
+		assert.Nil(t, lc.StatusCode)
+		assert.NotNil(t, lc.Error)
+		assert.True(t, lc.Broken)
+	})
+}
+
+func TestCheckPrivate(t *testing.T) {
+	srv := newSite(t)
+	checker := links.NewChecker(nil, config.LinksConfig{Concurrency: 1, Timeout: 200 * time.Millisecond})
+
+	for _, url := range []string{
+		srv.URL + "/ok",
+		"http://localhost:1/",
+		"http://[::1]:1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://192.168.1.1/",
+		"http://0.0.0.0:1/",
+	} {
+		lc := checker.Check(context.Background(), models.LinkTarget{SongId: 1, URL: url})
+		assert.Nil(t, lc.StatusCode, url)
+		require.NotNil(t, lc.Error, url)
+		assert.Contains(t, *lc.Error, "refusing a loopback, link-local or private address", url)
+		assert.True(t, lc.Broken, url)
+	}
+}
+
+func TestCheckAllConcurrency(t *testing.T) {
+	var running, most atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	t.Cleanup(srv.Close)
+
+	targets := make([]models.LinkTarget, 12)
+	for i := range targets {
+		targets[i] = models.LinkTarget{SongId: i + 1, URL: srv.URL}
+	}
+	checks := newChecker(nil, 3).CheckAll(context.Background(), targets)
+
+	require.Len(t, checks, len(targets))
+	for i, lc := range checks {
+		assert.Equal(t, i+1, lc.SongId)
+		assert.False(t, lc.Broken)
+	}
+	assert.LessOrEqual(t, most.Load(), int32(3))
+	assert.Greater(t, most.Load(), int32(1))
+}
+
+// fakeStore hands out its targets in batches and records the checks.
+type fakeStore struct {
+	mu      sync.Mutex
+	targets []models.LinkTarget
+	saved   []models.LinkCheck
+}
+
+func (s *fakeStore) LinksToCheck(ctx context.Context, t time.Time, limit int) ([]models.LinkTarget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.targets))
+	batch := s.targets[:n]
+	s.targets = s.targets[n:]
+	return batch, nil
+}
+
+func (s *fakeStore) SaveLinkChecks(ctx context.Context, checks []models.LinkCheck) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, checks...)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	srv := newSite(t)
+	store := &fakeStore{}
+	for i := 0; i < 150; i++ {
+		path := "/ok"
+		if i%50 == 0 {
+			path = "/removed"
+		}
+		store.targets = append(store.targets, models.LinkTarget{SongId: i + 1, URL: srv.URL + path})
+	}
+
+	require.NoError(t, newChecker(store, 8).Run(context.Background(), nil))
+
+	require.Len(t, store.saved, 150)
+	broken := 0
+	for _, lc := range store.saved {
+		if lc.Broken {
+			broken++
+		}
+	}
+	assert.Equal(t, 3, broken)
+}
+
+func TestRunNearDeadline(t *testing.T) {
+	store := &fakeStore{targets: []models.LinkTarget{{SongId: 1, URL: "http://127.0.0.1:1/"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, newChecker(store, 1).Run(ctx, nil))
+	assert.Empty(t, store.saved)
+	assert.Len(t, store.targets, 1)
+}
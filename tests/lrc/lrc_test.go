@@ -0,0 +1,55 @@
+package lrc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/lrc"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestParse(t *testing.T) {
+	sl, err := lrc.Parse(`[ti:Song]
+[ar:Group]
+[offset:+500]
+
+[00:12.00][00:45.5]Chorus line
+[00:03.250]Intro
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", sl.Title)
+	assert.Equal(t, "Group", sl.Artist)
+	assert.Equal(t, []models.LyricLine{
+		{Number: 1, Text: "Intro", StartMs: utils.Ptr(2750)},
+		{Number: 2, Text: "Chorus line", StartMs: utils.Ptr(11500)},
+		{Number: 3, Text: "Chorus line", StartMs: utils.Ptr(45000)},
+	}, sl.Lines)
+}
+
+func TestParseEnhanced(t *testing.T) {
+	sl, err := lrc.Parse("[00:01.00] <00:01.00> Hello <00:01.50> world\n")
+	require.NoError(t, err)
+	require.Len(t, sl.Lines, 1)
+	assert.Equal(t, "Hello world", sl.Lines[0].Text)
+	assert.Equal(t, []models.LyricWord{{StartMs: 1000, Text: "Hello"}, {StartMs: 1500, Text: "world"}}, sl.Lines[0].Words)
+
+	assert.Equal(t, "[00:01.00] <00:01.00> Hello <00:01.50> world\n", lrc.Format(sl, true))
+	assert.Equal(t, "[00:01.00]Hello world\n", lrc.Format(sl, false))
+}
+
+func TestParseBlankTimedLine(t *testing.T) {
+	sl, err := lrc.Parse("[00:01.00]First\n[00:12.00]\n[00:14.00] \n[00:20.00]Second\n")
+	require.NoError(t, err)
+	assert.Equal(t, []models.LyricLine{
+		{Number: 1, Text: "First", StartMs: utils.Ptr(1000)},
+		{Number: 2, Text: "Second", StartMs: utils.Ptr(20000)},
+	}, sl.Lines)
+}
+
+func TestParseMissingTimeTag(t *testing.T) {
+	_, err := lrc.Parse("[00:01.00]ok\nnot ok\n")
+	assert.EqualError(t, err, "line 2: missing time tag")
+}
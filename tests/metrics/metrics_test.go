@@ -0,0 +1,30 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/metrics"
+)
+
+func TestMiddlewareUsesRouteTemplate(t *testing.T) {
+	r := gin.New()
+	r.Use(metrics.Middleware())
+	r.GET("/songs/:id/text", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", metrics.Handler())
+
+	req, _ := http.NewRequest("GET", "/songs/42/text", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `songs_http_requests_total{method="GET",route="/songs/:id/text",status="200"} 1`)
+	assert.NotContains(t, w.Body.String(), `/songs/42/text`)
+}
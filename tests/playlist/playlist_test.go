@@ -0,0 +1,38 @@
+package playlist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/playlist"
+)
+
+var entries = []models.PlaylistEntry{
+	{Id: 7, Position: 1, Song: models.Song{Name: "Uprising", GroupName: "Muse", Link: "https://example.com/uprising"}},
+	{Id: 3, Position: 2, Song: models.Song{Name: "No link", GroupName: "Nobody"}},
+	{Id: 9, Position: 3, Song: models.Song{Name: "Tom & Jerry", GroupName: "A <B>", Link: "https://example.com/?a=1&b=2"}},
+}
+
+func TestM3U8(t *testing.T) {
+	assert.Equal(t, "#EXTM3U\n"+
+		"#PLAYLIST:Road trip\n"+
+		"#EXTINF:-1,Muse - Uprising\n"+
+		"https://example.com/uprising\n"+
+		"#EXTINF:-1,A <B> - Tom & Jerry\n"+
+		"https://example.com/?a=1&b=2\n",
+		playlist.M3U8("Road\ntrip", entries))
+}
+
+func TestXSPF(t *testing.T) {
+	b, err := playlist.XSPF("Road trip", entries)
+	require.NoError(t, err)
+	doc := string(b)
+	assert.Contains(t, doc, `<playlist version="1" xmlns="http://xspf.org/ns/0/">`)
+	assert.Contains(t, doc, `<location>https://example.com/?a=1&amp;b=2</location>`)
+	assert.Contains(t, doc, `<creator>A &lt;B&gt;</creator>`)
+	assert.Contains(t, doc, "<track>\n      <title>No link</title>")
+	assert.Contains(t, doc, `<trackNum>3</trackNum>`)
+}
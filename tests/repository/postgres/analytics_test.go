@@ -0,0 +1,46 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestAnalytics(t *testing.T) {
+	db := initHelper(t, true)
+	repo := initRepo(t, db)
+	ctx := context.Background()
+
+	groups, amount, err := repo.CountSongsByGroup(ctx, &models.SongsQuery{Page: 0, Max: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 10, amount)
+	assert.Equal(t, []models.GroupCount{{Group: "Group 1", Songs: 10}, {Group: "Group 10", Songs: 10}, {Group: "Group 2", Songs: 10}}, groups)
+
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{GroupName: utils.Ptr("Group 3")}, 1))
+	top, err := repo.GetTopGroups(ctx, &models.SongsQuery{Max: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []models.GroupCount{{Group: "Group 3", Songs: 11}}, top)
+
+	buckets, err := repo.CountReleases(ctx, &models.SongsQuery{Group: utils.Ptr("Group 3")}, models.IntervalYear)
+	require.NoError(t, err)
+	total := 0
+	for i, b := range buckets {
+		total += b.Songs
+		if i > 0 {
+			assert.Equal(t, buckets[i-1].Start.Year()+1, b.Start.Year())
+		}
+	}
+	assert.Equal(t, 11, total)
+
+	_, err = db.Exec(`UPDATE songs SET created_at = now() + interval '1 hour' WHERE id = 42`)
+	require.NoError(t, err)
+	recent, err := repo.GetRecentSongs(ctx, &models.SongsQuery{Max: 1})
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, 42, recent[0].Id)
+}
@@ -0,0 +1,143 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+func TestListDuplicates(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	lyrics := "Paranoia is in bloom\nThe PR transmissions will resume"
+	create := func(group, song, text string) int {
+		songId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: group, Song: song, Text: text})
+		require.NoError(t, err)
+		return songId
+	}
+	original := create("Dupe Band", "Uprising", lyrics)
+	renamed := create("dupe band", "Uprising (Live)", lyrics+"\nThey will try to push drugs")
+	retitled := create("Dupe-Band", "  UPRISING! ", "")
+	create("Dupe Band", "Resistance", "Love is our resistance")
+	create("Other Band", "Uprising", lyrics)
+
+	dq := models.NewDuplicatesQuery()
+	dq.Max, dq.MinLyrics = 100, 0.6
+	pairs, _, err := repo.ListDuplicates(ctx, &dq)
+	require.NoError(t, err)
+	var ours []models.DuplicatePair
+	for _, dp := range pairs {
+		if dp.Song.Id >= original {
+			ours = append(ours, dp)
+		}
+	}
+	require.Len(t, ours, 2)
+	assert.Equal(t, original, ours[0].Song.Id)
+	assert.Equal(t, retitled, ours[0].Duplicate.Id)
+	assert.True(t, ours[0].SameTitle)
+	assert.Equal(t, "Uprising", ours[0].Song.Name)
+	assert.Equal(t, original, ours[1].Song.Id)
+	assert.Equal(t, renamed, ours[1].Duplicate.Id)
+	assert.False(t, ours[1].SameTitle)
+	assert.InDelta(t, 9.0/14, ours[1].Lyrics, 1e-9)
+
+	dq.MinLyrics = 0.8
+	pairs, _, err = repo.ListDuplicates(ctx, &dq)
+	require.NoError(t, err)
+	for _, dp := range pairs {
+		assert.NotEqual(t, renamed, dp.Duplicate.Id)
+	}
+}
+
+func TestMergeSongs(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+	users := postgresql.NewUsersRepository(db)
+	alice, err := users.CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+	bob, err := users.CreateUser(ctx, &models.UserCreate{Username: "bob", Password: "secret"})
+	require.NoError(t, err)
+
+	target, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Tags: []string{"rock"}})
+	require.NoError(t, err)
+	source, err := repo.CreateSong(ctx, &models.SongCreateQuery{
+		Group: "muse", Song: "Uprising!", Text: "Paranoia is in bloom", Link: "https://example.com/uprising", Tags: []string{"rock", "live"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.SetSyncedLyrics(ctx, source, []models.LyricLine{{Number: 1, Text: "Paranoia is in bloom", StartMs: new(int)}}))
+	require.NoError(t, repo.UpsertSongText(ctx, source, "ru", &models.SongTextUpsert{Text: "Паранойя цветёт"}))
+
+	require.NoError(t, repo.AddFavourite(ctx, alice.Id, target))
+	require.NoError(t, repo.AddFavourite(ctx, alice.Id, source))
+	require.NoError(t, repo.AddFavourite(ctx, bob.Id, source))
+	require.NoError(t, repo.RateSong(ctx, alice.Id, target, 5))
+	require.NoError(t, repo.RateSong(ctx, alice.Id, source, 1))
+	require.NoError(t, repo.RateSong(ctx, bob.Id, source, 3))
+	_, err = repo.RecordPlays(ctx, nil, []models.PlayEvent{{EventId: "a", SongId: target}, {EventId: "b", SongId: source}, {EventId: "c", SongId: source}})
+	require.NoError(t, err)
+	playlist, err := repo.CreatePlaylist(ctx, bob.Id, &models.PlaylistCreate{Title: "Mix"})
+	require.NoError(t, err)
+	_, err = repo.AddPlaylistEntry(ctx, playlist.Id, &models.PlaylistEntryAdd{SongId: source})
+	require.NoError(t, err)
+
+	_, err = repo.MergeSongs(ctx, &models.SongMerge{TargetId: target, SourceIds: []int{source, -1}})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	merged, err := repo.MergeSongs(ctx, &models.SongMerge{TargetId: target, SourceIds: []int{source}})
+	require.NoError(t, err)
+	assert.Equal(t, models.SongMerged{Id: target, Merged: []int{source}, Fields: []string{models.EnrichText, models.EnrichLink}}, merged)
+
+	exists, err := repo.CheckIfExists(ctx, source)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	song, err := repo.GetSong(ctx, &models.SongDetailQuery{Group: "Muse", Song: "Uprising"})
+	require.NoError(t, err)
+	assert.Equal(t, "Paranoia is in bloom", song.Text)
+	assert.Equal(t, "https://example.com/uprising", song.Link)
+	assert.Equal(t, []string{"live", "rock"}, song.Tags)
+
+	lines, _, err := repo.GetSongLines(ctx, target, &models.PageMaxQuery{Max: 10})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.NotNil(t, lines[0].StartMs)
+	translation, err := repo.GetTranslation(ctx, target, "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "Паранойя цветёт", translation)
+
+	favourites, err := repo.GetFavouriteIds(ctx, bob.Id, []int{target})
+	require.NoError(t, err)
+	assert.True(t, favourites[target])
+
+	// Alice's rating of the target is kept, Bob's moves over.
+	rating, err := repo.GetSongRating(ctx, target, &alice.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 5, *rating.Mine)
+	assert.Equal(t, 2, rating.Ratings)
+	assert.Equal(t, 4.0, *rating.Rating)
+	assert.Equal(t, int64(3), rating.Plays)
+
+	entries, _, err := repo.GetPlaylistEntries(ctx, playlist.Id, &models.PageMaxQuery{Max: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, target, entries[0].Song.Id)
+
+	lastId, err := repo.LastSongEventId(ctx)
+	require.NoError(t, err)
+	events, err := repo.ListSongEvents(ctx, lastId-2, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.SongEventDelete, events[0].Type)
+	assert.Equal(t, source, events[0].SongId)
+	assert.Equal(t, models.SongEventUpdate, events[1].Type)
+	assert.Equal(t, target, events[1].SongId)
+}
@@ -0,0 +1,57 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+)
+
+func TestEnrichment(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	songId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Link: "https://example.com/uprising"})
+	require.NoError(t, err)
+	song, err := repo.GetSong(ctx, &models.SongDetailQuery{Group: "Muse", Song: "Uprising"})
+	require.NoError(t, err)
+	assert.Equal(t, models.EnrichmentNone, song.EnrichmentStatus)
+
+	fields := []string{models.EnrichText, models.EnrichReleaseDate}
+	require.NoError(t, repo.EnqueueEnrichment(ctx, songId, fields))
+	assert.ErrorIs(t, repo.EnqueueEnrichment(ctx, -1, fields), sql.ErrNoRows)
+
+	claimed, err := repo.ClaimJobs(ctx, []string{models.JobEnrichSong}, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	var ej models.EnrichSongJob
+	require.NoError(t, json.Unmarshal(claimed[0].Payload, &ej))
+	assert.Equal(t, models.EnrichSongJob{SongId: songId, Group: "Muse", Song: "Uprising", Fields: fields}, ej)
+
+	songs, _, err := repo.GetSongs(ctx, &models.SongsQuery{Group: &ej.Group, Max: 10})
+	require.NoError(t, err)
+	require.Len(t, songs, 1)
+	assert.Equal(t, models.EnrichmentPending, songs[0].EnrichmentStatus)
+
+	// Only the requested fields are set, and only with what was found.
+	date := models.DateFormat(time.Date(2009, 9, 7, 0, 0, 0, 0, time.UTC))
+	info := models.SongEnrichment{Text: "Paranoia is in bloom", Link: "https://example.com/other", ReleaseDate: &date}
+	require.NoError(t, repo.ApplyEnrichment(ctx, &ej, &info))
+	song, err = repo.GetSong(ctx, &models.SongDetailQuery{Group: "Muse", Song: "Uprising"})
+	require.NoError(t, err)
+	assert.Equal(t, models.EnrichmentDone, song.EnrichmentStatus)
+	assert.Equal(t, "Paranoia is in bloom", song.Text)
+	assert.Equal(t, "https://example.com/uprising", song.Link)
+	assert.Equal(t, date, song.ReleaseDate)
+
+	require.NoError(t, repo.SetEnrichmentStatus(ctx, songId, models.EnrichmentFailed))
+	require.NoError(t, repo.DeleteSong(ctx, songId))
+	assert.ErrorIs(t, repo.ApplyEnrichment(ctx, &ej, &info), sql.ErrNoRows)
+}
@@ -0,0 +1,96 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSongEvents(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	last, err := repo.LastSongEventId(ctx)
+	require.NoError(t, err)
+
+	songId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia"})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Name: utils.Ptr("Uprising (live)")}, songId))
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"rock"}}, songId))
+	require.NoError(t, repo.DeleteSong(ctx, songId))
+	assert.ErrorIs(t, repo.DeleteSong(ctx, songId), sql.ErrNoRows)
+
+	events, err := repo.ListSongEvents(ctx, last, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	types := make([]string, len(events))
+	for i, e := range events {
+		assert.Equal(t, songId, e.SongId)
+		types[i] = e.Type
+	}
+	assert.Equal(t, []string{models.SongEventCreate, models.SongEventUpdate, models.SongEventUpdate, models.SongEventDelete}, types)
+
+	events, err = repo.ListSongEvents(ctx, events[1].Id, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.SongEventUpdate, events[0].Type)
+
+	newest, err := repo.LastSongEventId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last+4, newest)
+}
+
+func TestSongEventsCommitOrder(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	songId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia"})
+	require.NoError(t, err)
+	created, err := repo.LastSongEventId(ctx)
+	require.NoError(t, err)
+
+	// Events of a transaction long over, and of one still running, both
+	// taking their ids after the current one's.
+	var done, running int64
+	require.NoError(t, db.QueryRowContext(
+		ctx,
+		`INSERT INTO song_events (type, song_id, tx_id) VALUES ('update', $1, '3') RETURNING id`,
+		songId,
+	).Scan(&done))
+	require.NoError(t, db.QueryRowContext(
+		ctx,
+		`INSERT INTO song_events (type, song_id, tx_id)
+		VALUES ('delete', $1, (pg_current_xact_id()::text::bigint + 1000)::text::xid8) RETURNING id`,
+		songId,
+	).Scan(&running))
+
+	events, err := repo.ListSongEvents(ctx, 0, 1000)
+	require.NoError(t, err)
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.Id
+	}
+	assert.NotContains(t, ids, running)
+	require.Contains(t, ids, done)
+	require.Contains(t, ids, created)
+	assert.Less(t, slices.Index(ids, done), slices.Index(ids, created))
+
+	newest, err := repo.LastSongEventId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, created, newest)
+
+	// Resuming after the older transaction's event still gives the newer.
+	events, err = repo.ListSongEvents(ctx, done, 1000)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, created, events[len(events)-1].Id)
+}
@@ -0,0 +1,47 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+func TestFavourites(t *testing.T) {
+	db := initHelper(t, true)
+	t.Cleanup(func() { db.Exec(`DELETE FROM users`) })
+	ctx := context.Background()
+	user, err := postgresql.NewUsersRepository(db).CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+
+	repo := initRepo(t, db)
+	require.NoError(t, repo.AddFavourite(ctx, user.Id, 1))
+	require.NoError(t, repo.AddFavourite(ctx, user.Id, 2))
+	require.NoError(t, repo.AddFavourite(ctx, user.Id, 2), "adding twice is a no-op")
+
+	songs, amount, err := repo.GetFavourites(ctx, user.Id, &models.SongsQuery{Max: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, amount)
+	require.Len(t, songs, 2)
+	assert.True(t, *songs[0].IsFavourite)
+
+	_, err = repo.RecordPlays(ctx, nil, []models.PlayEvent{{EventId: "a", SongId: 2}})
+	require.NoError(t, err)
+	songs, _, err = repo.GetFavourites(ctx, user.Id, &models.SongsQuery{Max: 10, Sort: models.SortPopularity})
+	require.NoError(t, err)
+	require.Len(t, songs, 2)
+	assert.Equal(t, []int{2, 1}, []int{songs[0].Id, songs[1].Id}, "most played first")
+
+	ids, err := repo.GetFavouriteIds(ctx, user.Id, []int{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 2: true}, ids)
+
+	require.NoError(t, repo.RemoveFavourite(ctx, user.Id, 1))
+	_, amount, err = repo.GetFavourites(ctx, user.Id, &models.SongsQuery{Max: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+}
@@ -0,0 +1,81 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestJobs(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	first, err := repo.EnqueueJob(ctx, &models.JobEnqueue{Kind: "test.a", Payload: map[string]int{"songId": 3}, Key: "a@1"})
+	require.NoError(t, err)
+	assert.NotZero(t, first)
+	dup, err := repo.EnqueueJob(ctx, &models.JobEnqueue{Kind: "test.a", Key: "a@1"})
+	require.NoError(t, err)
+	assert.Zero(t, dup)
+	later := time.Now().Add(time.Hour)
+	_, err = repo.EnqueueJob(ctx, &models.JobEnqueue{Kind: "test.a", RunAt: &later})
+	require.NoError(t, err)
+	_, err = repo.EnqueueJob(ctx, &models.JobEnqueue{Kind: "test.b"})
+	require.NoError(t, err)
+
+	// Only due jobs of the given kinds are claimed, and only once.
+	claimed, err := repo.ClaimJobs(ctx, []string{"test.a"}, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, first, claimed[0].Id)
+	assert.Equal(t, models.JobRunning, claimed[0].Status)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	var payload struct{ SongId int }
+	require.NoError(t, json.Unmarshal(claimed[0].Payload, &payload))
+	assert.Equal(t, 3, payload.SongId)
+	again, err := repo.ClaimJobs(ctx, []string{"test.a"}, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	require.NoError(t, repo.FailJob(ctx, first, "upstream down", utils.Ptr(time.Now().Add(-time.Hour))))
+	claimed, err = repo.ClaimJobs(ctx, []string{"test.a"}, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].Attempts)
+	assert.Equal(t, "upstream down", *claimed[0].LastError)
+	require.NoError(t, repo.FailJob(ctx, first, "gave up", nil))
+
+	dead, amount, err := repo.ListJobs(ctx, &models.JobsQuery{PageMaxQuery: models.NewPageMaxQuery(), Status: models.JobDead})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	require.Len(t, dead, 1)
+	assert.NotNil(t, dead[0].FinishedAt)
+
+	require.NoError(t, repo.RetryJob(ctx, first))
+	assert.ErrorIs(t, repo.RetryJob(ctx, first), sql.ErrNoRows)
+	claimed, err = repo.ClaimJobs(ctx, []string{"test.a", "test.b"}, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	for _, j := range claimed {
+		require.NoError(t, repo.CompleteJob(ctx, j.Id))
+	}
+
+	counts, err := repo.CountJobs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, counts, models.JobCount{Kind: "test.a", Status: models.JobDone, Count: 1})
+	assert.Contains(t, counts, models.JobCount{Kind: "test.a", Status: models.JobQueued, Count: 1})
+	assert.Contains(t, counts, models.JobCount{Kind: "test.b", Status: models.JobDone, Count: 1})
+
+	// finished_at is the start of the test transaction.
+	n, err := repo.PurgeJobs(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
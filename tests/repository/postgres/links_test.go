@@ -0,0 +1,80 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestLinks(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	okId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Link: "https://example.com/uprising"})
+	require.NoError(t, err)
+	brokenId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Resistance", Link: "https://example.com/resistance"})
+	require.NoError(t, err)
+	_, err = repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Unlinked"})
+	require.NoError(t, err)
+
+	// Links without a check are due whatever the time.
+	due, err := repo.LinksToCheck(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.LinkTarget{
+		{SongId: okId, URL: "https://example.com/uprising"},
+		{SongId: brokenId, URL: "https://example.com/resistance"},
+	}, due)
+
+	require.NoError(t, repo.SaveLinkChecks(ctx, []models.LinkCheck{
+		{SongId: okId, URL: "https://example.com/uprising", StatusCode: utils.Ptr(200), RedirectURL: utils.Ptr("https://example.com/muse/uprising")},
+		{SongId: brokenId, URL: "https://example.com/resistance", StatusCode: utils.Ptr(404), Broken: true},
+		{SongId: -1, URL: "https://example.com/deleted", Error: utils.Ptr("timed out"), Broken: true},
+	}))
+
+	due, err = repo.LinksToCheck(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	summary, err := repo.GetLinkSummary(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.LinkSummary{Links: 2, Checked: 2, Broken: 1, Redirected: 1}, summary)
+
+	broken, amount, err := repo.ListBrokenLinks(ctx, &models.PageMaxQuery{Page: 0, Max: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	require.Len(t, broken, 1)
+	assert.Equal(t, brokenId, broken[0].SongId)
+	assert.Equal(t, "Resistance", broken[0].Song)
+	assert.Equal(t, utils.Ptr(404), broken[0].StatusCode)
+	assert.Nil(t, broken[0].Error)
+
+	songs, amount, err := repo.GetSongs(ctx, &models.SongsQuery{Max: 10, Broken: utils.Ptr(true)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	require.Len(t, songs, 1)
+	assert.Equal(t, brokenId, songs[0].Id)
+	_, amount, err = repo.GetSongs(ctx, &models.SongsQuery{Max: 10, Broken: utils.Ptr(false)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, amount)
+
+	// A new link is due again and no longer counts as broken.
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Link: utils.Ptr("https://example.com/resistance-live")}, brokenId))
+	due, err = repo.LinksToCheck(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.LinkTarget{{SongId: brokenId, URL: "https://example.com/resistance-live"}}, due)
+	_, amount, err = repo.GetSongs(ctx, &models.SongsQuery{Max: 10, Broken: utils.Ptr(true)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, amount)
+
+	// Checks are due again once older than t.
+	due, err = repo.LinksToCheck(ctx, time.Now().Add(time.Hour), 1)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+}
@@ -0,0 +1,71 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSyncedLyrics(t *testing.T) {
+	db := initHelper(t, true)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("first\n\nsecond")}, 1))
+		lines := []models.LyricLine{
+			{Text: "first", StartMs: utils.Ptr(1000)},
+			{Text: " second ", StartMs: utils.Ptr(2000), Words: []models.LyricWord{{StartMs: 2000, Text: "second"}}},
+		}
+		require.NoError(t, repo.SetSyncedLyrics(ctx, 1, lines))
+
+		text, total, err := repo.GetSongText(ctx, 1, &models.PageMaxQuery{Page: 0, Max: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []string{"first", "", "second"}, text, "the text and its stanza break are kept")
+
+		page, total, err := repo.GetSongLines(ctx, 1, &models.PageMaxQuery{Page: 1, Max: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []models.LyricLine{
+			{Number: 3, Text: "second", StartMs: utils.Ptr(2000), Words: []models.LyricWord{{StartMs: 2000, Text: "second"}}},
+		}, page)
+
+		sl, err := repo.GetSyncedLyrics(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Song 1", sl.Title)
+		assert.Len(t, sl.Lines, 3)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("first\nsecond")}, 1))
+
+		for _, lines := range [][]models.LyricLine{
+			{{Text: "first", StartMs: utils.Ptr(0)}},
+			{{Text: "first", StartMs: utils.Ptr(0)}, {Text: "other", StartMs: utils.Ptr(1)}},
+			{{Text: "first", StartMs: utils.Ptr(0)}, {Text: "second", StartMs: utils.Ptr(1)}, {Text: "third", StartMs: utils.Ptr(2)}},
+		} {
+			assert.ErrorIs(t, repo.SetSyncedLyrics(ctx, 1, lines), postgresql.ErrLyricsMismatch)
+		}
+	})
+
+	t.Run("TextUpdateDropsTimestamps", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("timed")}, 1))
+		require.NoError(t, repo.SetSyncedLyrics(ctx, 1, []models.LyricLine{{Text: "timed", StartMs: utils.Ptr(0)}}))
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("new text")}, 1))
+
+		lines, _, err := repo.GetSongLines(ctx, 1, &models.PageMaxQuery{Page: 0, Max: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []models.LyricLine{{Number: 1, Text: "new text"}}, lines)
+	})
+}
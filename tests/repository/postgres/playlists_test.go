@@ -0,0 +1,98 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestPlaylists(t *testing.T) {
+	db := initHelper(t, true)
+	t.Cleanup(func() { db.Exec(`DELETE FROM users`) })
+	ctx := context.Background()
+	user, err := postgresql.NewUsersRepository(db).CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+
+	repo := initRepo(t, db)
+	p, err := repo.CreatePlaylist(ctx, user.Id, &models.PlaylistCreate{Title: "Road trip"})
+	require.NoError(t, err)
+	assert.Equal(t, models.VisibilityPrivate, p.Visibility)
+
+	songIds := func() (ids []int) {
+		entries, err := repo.ExportPlaylist(ctx, p.Id)
+		require.NoError(t, err)
+		for i, e := range entries {
+			assert.Equal(t, i+1, e.Position)
+			ids = append(ids, e.Song.Id)
+		}
+		return
+	}
+
+	first, err := repo.AddPlaylistEntry(ctx, p.Id, &models.PlaylistEntryAdd{SongId: 1})
+	require.NoError(t, err)
+	_, err = repo.AddPlaylistEntry(ctx, p.Id, &models.PlaylistEntryAdd{SongId: 2})
+	require.NoError(t, err)
+	third, err := repo.AddPlaylistEntry(ctx, p.Id, &models.PlaylistEntryAdd{SongId: 3, Position: utils.Ptr(1)})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 2}, songIds())
+
+	require.NoError(t, repo.RemovePlaylistEntry(ctx, p.Id, first.Id))
+	assert.Equal(t, []int{3, 2}, songIds())
+
+	entries, amount, err := repo.GetPlaylistEntries(ctx, p.Id, &models.PageMaxQuery{Page: 1, Max: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, amount)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Song.Id)
+
+	assert.ErrorIs(t, repo.ReorderPlaylist(ctx, p.Id, []int{third.Id}), postgresql.ErrInvalidOrder)
+	require.NoError(t, repo.ReorderPlaylist(ctx, p.Id, []int{entries[0].Id, third.Id}))
+	assert.Equal(t, []int{2, 3}, songIds())
+
+	p, err = repo.UpdatePlaylist(ctx, p.Id, &models.PlaylistUpdate{Visibility: utils.Ptr(models.VisibilityPublic)})
+	require.NoError(t, err)
+	assert.Equal(t, "Road trip", p.Title)
+	assert.Equal(t, 2, p.Entries)
+
+	playlists, amount, err := repo.ListPlaylists(ctx, user.Id, &models.PageMaxQuery{Max: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	assert.Equal(t, p.Id, playlists[0].Id)
+}
+
+func TestDeleteSongClosesPlaylistGaps(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	user, err := postgresql.NewUsersRepository(db).CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+	repo := initRepo(t, db)
+	p, err := repo.CreatePlaylist(ctx, user.Id, &models.PlaylistCreate{Title: "Road trip"})
+	require.NoError(t, err)
+
+	doomed, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Doomed"})
+	require.NoError(t, err)
+	for _, songId := range []int{1, doomed, 2, doomed} {
+		_, err = repo.AddPlaylistEntry(ctx, p.Id, &models.PlaylistEntryAdd{SongId: songId})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.DeleteSong(ctx, doomed))
+	_, err = repo.AddPlaylistEntry(ctx, p.Id, &models.PlaylistEntryAdd{SongId: 3})
+	require.NoError(t, err)
+
+	entries, err := repo.ExportPlaylist(ctx, p.Id)
+	require.NoError(t, err)
+	var songIds []int
+	for i, e := range entries {
+		assert.Equal(t, i+1, e.Position)
+		songIds = append(songIds, e.Song.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, songIds)
+	_, err = db.Exec(`SET CONSTRAINTS playlist_entries_position_key IMMEDIATE`)
+	assert.NoError(t, err, "positions are unique")
+}
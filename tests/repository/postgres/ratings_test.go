@@ -0,0 +1,79 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+func TestRecordPlays(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	res, err := repo.RecordPlays(ctx, nil, []models.PlayEvent{
+		{EventId: "a", SongId: 2},
+		{EventId: "b", SongId: 2},
+		{EventId: "c", SongId: 3},
+		{EventId: "d", SongId: 100000},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.PlaysResult{Recorded: 3, Ignored: 1}, res)
+
+	res, err = repo.RecordPlays(ctx, nil, []models.PlayEvent{{EventId: "a", SongId: 2}, {EventId: "e", SongId: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, models.PlaysResult{Recorded: 1, Ignored: 1}, res, "retried events are not counted twice")
+
+	songs, _, err := repo.GetSongs(ctx, &models.SongsQuery{Max: 2, Sort: models.SortPopularity})
+	require.NoError(t, err)
+	require.Len(t, songs, 2)
+	assert.Equal(t, 2, songs[0].Id)
+	assert.Equal(t, int64(3), songs[0].Popularity.Plays)
+	assert.Equal(t, 3, songs[1].Id)
+}
+
+func TestRatings(t *testing.T) {
+	db := initHelper(t, true)
+	t.Cleanup(func() { db.Exec(`DELETE FROM users`) })
+	ctx := context.Background()
+	users := postgresql.NewUsersRepository(db)
+	alice, err := users.CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+	bob, err := users.CreateUser(ctx, &models.UserCreate{Username: "bob", Password: "secret"})
+	require.NoError(t, err)
+
+	repo := initRepo(t, db)
+	require.NoError(t, repo.RateSong(ctx, alice.Id, 5, 2))
+	require.NoError(t, repo.RateSong(ctx, bob.Id, 5, 5))
+	require.NoError(t, repo.RateSong(ctx, alice.Id, 5, 4), "rating again replaces the stars")
+	require.NoError(t, repo.RateSong(ctx, alice.Id, 6, 3))
+
+	rating, err := repo.GetSongRating(ctx, 5, &alice.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rating.Ratings)
+	assert.InDelta(t, 4.5, *rating.Rating, 0.001)
+	assert.Equal(t, 4, *rating.Mine)
+
+	songs, _, err := repo.GetSongs(ctx, &models.SongsQuery{Max: 2, Sort: models.SortRating})
+	require.NoError(t, err)
+	require.Len(t, songs, 2)
+	assert.Equal(t, 5, songs[0].Id)
+	assert.Equal(t, 6, songs[1].Id)
+
+	require.NoError(t, repo.DeleteRating(ctx, bob.Id, 5))
+	assert.ErrorIs(t, repo.DeleteRating(ctx, bob.Id, 5), sql.ErrNoRows)
+	rating, err = repo.GetSongRating(ctx, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rating.Ratings)
+	assert.InDelta(t, 4.0, *rating.Rating, 0.001)
+	assert.Nil(t, rating.Mine)
+
+	_, err = repo.GetSongRating(ctx, 100000, nil)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,69 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func similarIds(songs []models.SimilarSong) []int {
+	ids := make([]int, len(songs))
+	for i, s := range songs {
+		ids[i] = s.Id
+	}
+	return ids
+}
+
+func TestSimilarSongs(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	// Song 2 shares lyrics with song 1, song 3 its tags and song 11 its group.
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("Midnight river\nsilver moon"), Tags: &[]string{"night"}}, 1))
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("midnight river\ngolden sun")}, 2))
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"night"}}, 3))
+
+	// Nothing is stored yet: no songs, and a single refresh is queued.
+	songs, err := repo.GetSimilarSongs(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.Empty(t, songs)
+	_, err = repo.GetSimilarSongs(ctx, 2, 3)
+	require.NoError(t, err)
+	var queued int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM jobs WHERE kind = $1`, models.JobRefreshSimilar).Scan(&queued))
+	assert.Equal(t, 1, queued)
+
+	stored, err := repo.RefreshSimilarSongs(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100*5), stored, "every song has at least 5 songs in its group")
+
+	var rows int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM similar_songs WHERE song_id = 1`).Scan(&rows))
+	assert.Equal(t, 5, rows)
+	songs, err = repo.GetSimilarSongs(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 11}, similarIds(songs))
+	assert.InDelta(t, 1, songs[0].Reasons.Tags, 0.001)
+	assert.Greater(t, songs[1].Reasons.Lyrics, 0.0)
+	assert.True(t, songs[2].Reasons.SameGroup)
+	assert.Equal(t, []string{"night"}, songs[0].Tags)
+
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("quiet harbour")}, 1))
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM similar_songs WHERE song_id = 1`).Scan(&rows))
+	assert.Zero(t, rows, "edited songs wait for the next refresh")
+	songs, err = repo.GetSimilarSongs(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Empty(t, songs)
+
+	_, err = repo.RefreshSimilarSongs(ctx, 5)
+	require.NoError(t, err)
+	songs, err = repo.GetSimilarSongs(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 11}, similarIds(songs))
+}
@@ -10,9 +10,6 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-txdb"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,20 +25,7 @@ const (
 )
 
 func runMigrations(db *sql.DB) {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatalf("failed to create migration driver: %v", err)
-	}
-
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+path.Join(basePath, "migrations"),
-		"postgres",
-		driver,
-	)
-	if err != nil {
-		log.Fatalf("failed to initialize migrations: %v", err)
-	}
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := postgresql.MigrateUp(context.Background(), db); err != nil {
 		log.Fatalf("failed to apply migrations: %v", err)
 	}
 }
@@ -148,7 +132,7 @@ func TestCreateSong(t *testing.T) {
 	for _, tc := range createSongCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := initRepo(t, db)
-			err := repo.CreateSong(ctx, tc.song)
+			songId, err := repo.CreateSong(ctx, tc.song)
 			if tc.returnErr {
 				require.Error(t, err, tc.errMsgAndArgs...)
 				return
@@ -157,6 +141,7 @@ func TestCreateSong(t *testing.T) {
 			}
 			song, err := repo.GetSong(ctx, tc.getSong)
 			require.NoError(t, err)
+			assert.Equal(t, songId, song.Id)
 			assert.Equal(t, tc.song.Song, song.Name)
 			assert.Equal(t, tc.song.Group, song.GroupName)
 			if tc.song.ReleaseDate != nil {
@@ -275,4 +260,15 @@ func TestGetSongText(t *testing.T) {
 		assert.Len(t, lines, 1)
 		assert.Equal(t, lines[0], "Lyrics for song 1")
 	})
+
+	t.Run("GetSongTextSplitsOnNewlines", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE songs SET text = $1 WHERE id = 2`, "First line\nSecond line\\nstill second")
+		require.NoError(t, err)
+		repo := initRepo(t, db)
+		lines, total, err := repo.GetSongText(context.Background(), 2, &models.PageMaxQuery{Page: 0, Max: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []string{"First line", `Second line\nstill second`}, lines)
+	})
 }
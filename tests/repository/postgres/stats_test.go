@@ -0,0 +1,52 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSongStats(t *testing.T) {
+	db := initHelper(t, true)
+	repo := initRepo(t, db)
+	ctx := context.Background()
+	text := "La la la\nHey you\n\nLa la, don't stop\n"
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr(text)}, 1))
+
+	st, err := repo.GetSongStats(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, st.SongId)
+	assert.Equal(t, 3, st.Lines)
+	assert.Equal(t, 2, st.Verses)
+	assert.Equal(t, 9, st.Words)
+	assert.Equal(t, 5, st.UniqueWords)
+	assert.Equal(t, []models.WordCount{{Word: "la", Count: 5}, {Word: "don't", Count: 1}}, st.TopWords)
+}
+
+func TestGroupStats(t *testing.T) {
+	db := initHelper(t, true)
+	repo := initRepo(t, db)
+	ctx := context.Background()
+
+	stats, amount, err := repo.GetGroupStats(ctx, &models.StatsQuery{Page: 0, Max: 2, Top: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 10, amount)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "Group 1", stats[0].Group)
+	assert.Equal(t, 10, stats[0].Songs)
+	assert.Equal(t, 10, stats[0].Lines)
+	assert.Equal(t, 10, stats[0].Verses)
+	assert.Equal(t, 40, stats[0].Words)
+	assert.Equal(t, []models.WordCount{{Word: "for", Count: 10}}, stats[0].TopWords)
+
+	stats, amount, err = repo.GetGroupStats(ctx, &models.StatsQuery{Page: 0, Max: 10, Group: utils.Ptr("Group 3"), Top: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "Group 3", stats[0].Group)
+}
@@ -0,0 +1,114 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestTags(t *testing.T) {
+	db := initHelper(t, true)
+
+	t.Run("CRUD", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		tag, err := repo.CreateTag(ctx, &models.TagCreate{Name: " Rock ", Kind: models.TagKindGenre})
+		require.NoError(t, err)
+		assert.Equal(t, "rock", tag.Name)
+
+		_, err = repo.CreateTag(ctx, &models.TagCreate{Name: "ROCK"})
+		assert.ErrorIs(t, err, postgresql.ErrTagExists)
+
+		tag, err = repo.UpdateTag(ctx, tag.Id, &models.TagUpdate{Name: utils.Ptr("Hard Rock")})
+		require.NoError(t, err)
+		assert.Equal(t, models.Tag{Id: tag.Id, Name: "hard rock", Kind: models.TagKindGenre}, tag)
+
+		require.NoError(t, repo.DeleteTag(ctx, tag.Id))
+		_, err = repo.GetTag(ctx, tag.Id)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.ErrorIs(t, repo.DeleteTag(ctx, tag.Id), sql.ErrNoRows)
+	})
+
+	t.Run("EventsForTaggedSongs", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"ballad"}}, 3))
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"ballad"}}, 4))
+		tags, err := repo.ListTags(ctx, &models.TagsQuery{})
+		require.NoError(t, err)
+		var tagId int
+		for _, tag := range tags {
+			if tag.Name == "ballad" {
+				tagId = tag.Id
+			}
+		}
+		require.NotZero(t, tagId)
+
+		last, err := repo.LastSongEventId(ctx)
+		require.NoError(t, err)
+		_, err = repo.UpdateTag(ctx, tagId, &models.TagUpdate{Name: utils.Ptr("power ballad")})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTag(ctx, tagId))
+
+		events, err := repo.ListSongEvents(ctx, last, 10)
+		require.NoError(t, err)
+		songIds := make([]int, len(events))
+		for i, e := range events {
+			assert.Equal(t, models.SongEventUpdate, e.Type)
+			songIds[i] = e.SongId
+		}
+		assert.Equal(t, []int{3, 4, 3, 4}, songIds)
+	})
+
+	t.Run("FilterAndCount", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"rock", "live"}}, 1))
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"Rock"}}, 2))
+
+		songs, amount, err := repo.GetSongs(ctx, &models.SongsQuery{Max: 10, Tags: []string{"rock", "live"}})
+		require.NoError(t, err)
+		assert.Equal(t, 2, amount)
+		assert.Equal(t, []string{"live", "rock"}, songs[0].Tags)
+
+		songs, amount, err = repo.GetSongs(ctx, &models.SongsQuery{Max: 10, Tags: []string{"rock", "live"}, TagMatch: models.TagMatchAll})
+		require.NoError(t, err)
+		assert.Equal(t, 1, amount)
+		assert.Equal(t, 1, songs[0].Id)
+
+		counts, err := repo.CountTags(ctx, &models.SongsQuery{})
+		require.NoError(t, err)
+		require.Len(t, counts, 2)
+		assert.Equal(t, "rock", counts[0].Name)
+		assert.Equal(t, 2, counts[0].Songs)
+	})
+}
+
+func TestSongFacets(t *testing.T) {
+	db := initHelper(t, true)
+	repo := initRepo(t, db)
+	ctx := context.Background()
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Tags: &[]string{"rock"}}, 10))
+
+	sq := &models.SongsQuery{Group: utils.Ptr("Group 1")}
+	facets, err := repo.GetSongFacets(ctx, sq, []string{models.FacetGroup, models.FacetTag, models.FacetYear})
+	require.NoError(t, err)
+	assert.Equal(t, []models.FacetBucket{{Value: "Group 1", Count: 10}}, facets[models.FacetGroup])
+	assert.Equal(t, []models.FacetBucket{{Value: "rock", Count: 1}}, facets[models.FacetTag])
+	total := 0
+	for _, b := range facets[models.FacetYear] {
+		total += b.Count
+	}
+	assert.Equal(t, 10, total)
+
+	facets, err = repo.GetSongFacets(ctx, &models.SongsQuery{Group: utils.Ptr("nobody")}, []string{models.FacetTag})
+	require.NoError(t, err)
+	assert.Equal(t, models.SongFacets{models.FacetTag: {}}, facets)
+}
@@ -0,0 +1,72 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestSongTexts(t *testing.T) {
+	db := initHelper(t, true)
+
+	t.Run("Translation", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "en", &models.SongTextUpsert{Text: "one\ntwo", Original: true}))
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "de", &models.SongTextUpsert{Text: "eins"}))
+
+		texts, err := repo.ListSongTexts(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, texts, 2)
+		assert.Equal(t, "en", texts[0].Lang)
+		assert.True(t, texts[0].Original)
+
+		lines, total, err := repo.GetTranslationLines(ctx, 1, "de", &models.PageMaxQuery{Page: 0, Max: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []string{"eins"}, lines)
+
+		parallel, total, err := repo.GetParallelLines(ctx, 1, "de", &models.PageMaxQuery{Page: 0, Max: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []models.ParallelLine{
+			{Number: 1, Original: utils.Ptr("one"), Translation: utils.Ptr("eins")},
+			{Number: 2, Original: utils.Ptr("two")},
+		}, parallel)
+	})
+
+	t.Run("OriginalFollowsSongText", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "en", &models.SongTextUpsert{Text: "old", Original: true}))
+		require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Text: utils.Ptr("new")}, 1))
+
+		text, err := repo.GetTranslation(ctx, 1, "en")
+		require.NoError(t, err)
+		assert.Equal(t, "new", text)
+	})
+
+	t.Run("OriginalCantBeUnmarked", func(t *testing.T) {
+		repo := initRepo(t, db)
+		ctx := context.Background()
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "en", &models.SongTextUpsert{Text: "original", Original: true}))
+		err := repo.UpsertSongText(ctx, 1, "en", &models.SongTextUpsert{Text: "changed"})
+		assert.ErrorIs(t, err, postgresql.ErrOriginalText)
+
+		texts, err := repo.ListSongTexts(ctx, 1)
+		require.NoError(t, err)
+		require.NotEmpty(t, texts)
+		assert.Equal(t, "en", texts[0].Lang)
+		assert.True(t, texts[0].Original)
+
+		// Moving the original to another language unmarks it.
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "de", &models.SongTextUpsert{Text: "Original", Original: true}))
+		require.NoError(t, repo.UpsertSongText(ctx, 1, "en", &models.SongTextUpsert{Text: "translation"}))
+	})
+}
@@ -0,0 +1,55 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+)
+
+func TestCreateUser(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() { db.Exec(`DELETE FROM users`) })
+
+	repo := postgresql.NewUsersRepository(db)
+	user, err := repo.CreateUser(ctx, &models.UserCreate{Username: "admin", Password: "secret", IsAdmin: true})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user.Username)
+	assert.True(t, user.IsAdmin)
+
+	var hash string
+	require.NoError(t, db.QueryRow(`SELECT password_hash FROM users WHERE id = $1`, user.Id).Scan(&hash))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+
+	_, err = repo.CreateUser(ctx, &models.UserCreate{Username: "admin", Password: "other"})
+	assert.Error(t, err, "usernames are unique")
+}
+
+func TestAuthenticate(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() { db.Exec(`DELETE FROM users`) })
+
+	repo := postgresql.NewUsersRepository(db)
+	created, err := repo.CreateUser(ctx, &models.UserCreate{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+
+	user, err := repo.Authenticate(ctx, "alice", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, user.Id)
+
+	_, err = repo.Authenticate(ctx, "alice", "wrong")
+	assert.ErrorIs(t, err, postgresql.ErrInvalidCredentials)
+	_, err = repo.Authenticate(ctx, "bob", "secret")
+	assert.ErrorIs(t, err, postgresql.ErrInvalidCredentials)
+
+	user, err = repo.GetUser(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+}
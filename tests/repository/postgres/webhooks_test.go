@@ -0,0 +1,118 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestWebhooks(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	hook, err := repo.CreateWebhook(ctx, &models.WebhookCreate{
+		URL: "http://example.com/hook", Events: []string{models.SongEventUpdate}, Secret: "0123456789abcdef",
+	})
+	require.NoError(t, err)
+	assert.True(t, hook.Active)
+
+	hook, err = repo.UpdateWebhook(ctx, hook.Id, &models.WebhookUpdate{Active: utils.Ptr(false)})
+	require.NoError(t, err)
+	assert.False(t, hook.Active)
+	assert.Equal(t, []string{models.SongEventUpdate}, hook.Events)
+
+	hooks, err := repo.ListWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	require.NoError(t, repo.DeleteWebhook(ctx, hook.Id))
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, hook.Id), sql.ErrNoRows)
+	_, err = repo.GetWebhook(ctx, hook.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestWebhookOutbox(t *testing.T) {
+	db := initHelper(t, true)
+	ctx := context.Background()
+	repo := initRepo(t, db)
+
+	updates, err := repo.CreateWebhook(ctx, &models.WebhookCreate{
+		URL: "http://example.com/updates", Events: []string{models.SongEventUpdate}, Secret: "0123456789abcdef",
+	})
+	require.NoError(t, err)
+	all, err := repo.CreateWebhook(ctx, &models.WebhookCreate{
+		URL:    "http://example.com/all",
+		Events: []string{models.SongEventCreate, models.SongEventUpdate, models.SongEventDelete},
+		Secret: "fedcba9876543210",
+	})
+	require.NoError(t, err)
+
+	songId, err := repo.CreateSong(ctx, &models.SongCreateQuery{Group: "Muse", Song: "Uprising", Text: "Paranoia"})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateSong(ctx, &models.SongUpdate{Name: utils.Ptr("Uprising (live)")}, songId))
+
+	deliveries, amount, err := repo.ListDeliveries(ctx, &models.DeliveriesQuery{PageMaxQuery: models.NewPageMaxQuery(), WebhookId: &all.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 2, amount)
+	require.Len(t, deliveries, 2)
+	var payload struct {
+		Type   string `json:"type"`
+		SongId int    `json:"songId"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	assert.Equal(t, models.SongEventUpdate, payload.Type)
+	assert.Equal(t, songId, payload.SongId)
+
+	_, amount, err = repo.ListDeliveries(ctx, &models.DeliveriesQuery{PageMaxQuery: models.NewPageMaxQuery(), WebhookId: &updates.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+
+	// Claimed deliveries are hidden until their lease runs out.
+	claimed, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 3)
+	again, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	for _, dl := range claimed {
+		switch {
+		case dl.WebhookId == updates.Id:
+			assert.Equal(t, "0123456789abcdef", dl.Secret)
+			require.NoError(t, repo.CompleteDelivery(ctx, dl.Id, 204))
+		case dl.EventType == models.SongEventCreate:
+			require.NoError(t, repo.FailDelivery(ctx, dl.Id, utils.Ptr(500), "status 500", nil))
+		default:
+			require.NoError(t, repo.FailDelivery(ctx, dl.Id, nil, "timeout", utils.Ptr(time.Now().Add(-time.Hour))))
+		}
+	}
+
+	// The retried one is due again.
+	due, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, models.SongEventUpdate, due[0].EventType)
+
+	dead, amount, err := repo.ListDeliveries(ctx, &models.DeliveriesQuery{PageMaxQuery: models.NewPageMaxQuery(), Status: models.DeliveryDead})
+	require.NoError(t, err)
+	assert.Equal(t, 1, amount)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "status 500", *dead[0].LastError)
+
+	require.NoError(t, repo.RetryDelivery(ctx, dead[0].Id))
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, dead[0].Id), sql.ErrNoRows)
+	due, err = repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 0, due[0].Attempts)
+}
@@ -0,0 +1,43 @@
+package similar_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/repository/postgresql"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/similar"
+)
+
+// fakeStore records the refreshes.
+type fakeStore struct {
+	limits []int
+	err    error
+}
+
+func (s *fakeStore) RefreshSimilarSongs(ctx context.Context, limit int) (int64, error) {
+	s.limits = append(s.limits, limit)
+	return int64(limit), s.err
+}
+
+func (s *fakeStore) Begin() (*postgresql.Transaction, error) {
+	return &postgresql.Transaction{}, nil
+}
+
+func TestRefresher(t *testing.T) {
+	t.Run("Refresh", func(t *testing.T) {
+		store := &fakeStore{}
+		r := similar.NewRefresher(func() similar.Store { return store })
+		assert.NoError(t, r.Run(context.Background(), nil))
+		assert.Equal(t, []int{models.MaxSimilar}, store.limits)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		store := &fakeStore{err: errors.New("canceling statement due to statement timeout")}
+		r := similar.NewRefresher(func() similar.Store { return store })
+		assert.EqualError(t, r.Run(context.Background(), nil), "canceling statement due to statement timeout")
+	})
+}
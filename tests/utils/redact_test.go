@@ -0,0 +1,81 @@
+package utils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/utils"
+)
+
+func TestMaskIP(t *testing.T) {
+	assert.Equal(t, "192.168.1.0", utils.MaskIP("192.168.1.42"))
+	assert.Equal(t, "2001:db8:1::", utils.MaskIP("2001:db8:1:2:3:4:5:6"))
+	assert.Equal(t, "unknown", utils.MaskIP("unknown"))
+}
+
+func TestRedactionHook(t *testing.T) {
+	hook := utils.NewRedactionHook(config.LoggingConfig{
+		MaskIP:         true,
+		DropFields:     []string{"userAgent"},
+		MaxFieldLength: 10,
+	})
+	entry := log.NewEntry(log.New()).WithFields(log.Fields{
+		"clientIP":   "10.1.2.3",
+		"userAgent":  "curl/8.0",
+		"statusCode": 200,
+		"update":     models.SongUpdate{Text: utils.Ptr("a very long verse of lyrics")},
+	})
+
+	assert.NoError(t, hook.Fire(entry))
+	assert.Equal(t, "10.1.2.0", entry.Data["clientIP"])
+	assert.NotContains(t, entry.Data, "userAgent")
+	assert.Equal(t, 200, entry.Data["statusCode"])
+	assert.Equal(t, `{"group":n...`, entry.Data["update"])
+}
+
+func TestRedactionHookMessage(t *testing.T) {
+	hook := utils.NewRedactionHook(config.LoggingConfig{MaxFieldLength: 5})
+	entry := log.NewEntry(log.New()).WithField("text", "Привет, мир")
+	entry.Message = "Привет"
+
+	assert.NoError(t, hook.Fire(entry))
+	assert.Equal(t, "Пр...", entry.Message, "cut on a character boundary")
+	assert.Equal(t, "Пр...", entry.Data["text"])
+}
+
+func TestLoggerMiddlewareRedaction(t *testing.T) {
+	cfg := config.LoggingConfig{MaskIP: true, DropFields: []string{"userAgent", "referer"}, SampleInfo: 1, SampleWarn: 1}
+	hooks := log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+	t.Cleanup(func() { log.StandardLogger().ReplaceHooks(hooks) })
+	log.AddHook(utils.NewRedactionHook(cfg))
+	recorded := new(test.Hook)
+	log.AddHook(recorded)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.LoggerMiddleware(cfg))
+	r.GET("/songs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest("GET", "/songs", nil)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req.Header.Set("User-Agent", "secret-agent")
+	req.Header.Set("Referer", "https://example.com/private")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := recorded.LastEntry()
+	require.NotNil(t, entry)
+	line, err := entry.String()
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.2.0", entry.Data["clientIP"])
+	assert.NotContains(t, line, "10.1.2.3")
+	assert.NotContains(t, line, "secret-agent")
+	assert.NotContains(t, line, "example.com/private")
+}
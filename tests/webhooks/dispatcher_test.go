@@ -0,0 +1,226 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/config"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/models"
+	"github.com/nikuma0/test-effective-mobile-golang/internal/webhooks"
+)
+
+// fakeStore keeps deliveries in memory, claiming those that are due.
+type fakeStore struct {
+	mu         sync.Mutex
+	deliveries map[int64]*fakeDelivery
+}
+
+type fakeDelivery struct {
+	models.OutgoingDelivery
+	status     string
+	due        time.Time
+	statusCode *int
+	lastError  string
+}
+
+func newFakeStore(dls ...models.OutgoingDelivery) *fakeStore {
+	s := &fakeStore{deliveries: map[int64]*fakeDelivery{}}
+	for _, dl := range dls {
+		s.deliveries[dl.Id] = &fakeDelivery{OutgoingDelivery: dl, status: models.DeliveryPending}
+	}
+	return s
+}
+
+func (s *fakeStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.OutgoingDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []models.OutgoingDelivery
+	for _, d := range s.deliveries {
+		if d.status == models.DeliveryPending && !d.due.After(time.Now()) && len(res) < limit {
+			d.due = time.Now().Add(lease)
+			res = append(res, d.OutgoingDelivery)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStore) CompleteDelivery(ctx context.Context, deliveryId int64, statusCode int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[deliveryId]
+	d.status, d.statusCode = models.DeliveryDelivered, &statusCode
+	d.Attempts++
+	return nil
+}
+
+func (s *fakeStore) FailDelivery(ctx context.Context, deliveryId int64, statusCode *int, msg string, retryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[deliveryId]
+	d.statusCode, d.lastError = statusCode, msg
+	d.Attempts++
+	if retryAt == nil {
+		d.status = models.DeliveryDead
+	} else {
+		// Retry right away rather than wait out the backoff.
+		d.due = time.Time{}
+	}
+	return nil
+}
+
+func (s *fakeStore) get(id int64) fakeDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[id]
+}
+
+func testConfig() config.WebhooksConfig {
+	return config.WebhooksConfig{
+		MaxAttempts:  3,
+		Backoff:      time.Second,
+		BackoffMax:   5 * time.Second,
+		Timeout:      time.Second,
+		PollInterval: time.Second,
+	}
+}
+
+func delivery(id int64, url string) models.OutgoingDelivery {
+	return models.OutgoingDelivery{
+		Id:        id,
+		WebhookId: 1,
+		URL:       url,
+		Secret:    "0123456789abcdef",
+		EventType: models.SongEventCreate,
+		Payload:   []byte(`{"id":1,"type":"create","songId":2}`),
+	}
+}
+
+func TestDispatchSigned(t *testing.T) {
+	var (
+		header, event, id string
+		body              []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, event, id = r.Header.Get(webhooks.SignatureHeader), r.Header.Get("X-Webhook-Event"), r.Header.Get("X-Webhook-Id")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	store := newFakeStore(delivery(4, srv.URL))
+
+	n, err := webhooks.NewDispatcher(store, testConfig()).DispatchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, models.DeliveryDelivered, store.get(4).status)
+	assert.Equal(t, http.StatusOK, *store.get(4).statusCode)
+
+	assert.Equal(t, "create", event)
+	assert.Equal(t, "4", id)
+	assert.JSONEq(t, `{"id":1,"type":"create","songId":2}`, string(body))
+	assert.NoError(t, webhooks.Verify("0123456789abcdef", header, body, time.Now(), time.Minute))
+	assert.ErrorIs(t, webhooks.Verify("another secret!!", header, body, time.Now(), time.Minute), webhooks.ErrInvalidSignature)
+}
+
+func TestDispatchRetries(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	store := newFakeStore(delivery(1, srv.URL))
+	d := webhooks.NewDispatcher(store, testConfig())
+
+	_, err := d.DispatchDue(context.Background())
+	require.NoError(t, err)
+	dl := store.get(1)
+	assert.Equal(t, models.DeliveryPending, dl.status)
+	assert.Equal(t, http.StatusServiceUnavailable, *dl.statusCode)
+	assert.Equal(t, "status 503: try later", dl.lastError)
+
+	_, err = d.DispatchDue(context.Background())
+	require.NoError(t, err)
+	dl = store.get(1)
+	assert.Equal(t, models.DeliveryDelivered, dl.status)
+	assert.Equal(t, 2, dl.Attempts)
+}
+
+func TestDispatchDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	store := newFakeStore(delivery(1, srv.URL))
+	d := webhooks.NewDispatcher(store, testConfig())
+
+	for range 5 {
+		_, err := d.DispatchDue(context.Background())
+		require.NoError(t, err)
+	}
+	dl := store.get(1)
+	assert.Equal(t, models.DeliveryDead, dl.status)
+	assert.Equal(t, 3, dl.Attempts)
+}
+
+func TestDispatchUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	store := newFakeStore(delivery(1, url))
+
+	_, err := webhooks.NewDispatcher(store, testConfig()).DispatchDue(context.Background())
+	require.NoError(t, err)
+	dl := store.get(1)
+	assert.Nil(t, dl.statusCode)
+	assert.NotEmpty(t, dl.lastError)
+}
+
+func TestRunWakes(t *testing.T) {
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer srv.Close()
+	store := newFakeStore()
+	cfg := testConfig()
+	cfg.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wake := make(chan struct{})
+	go webhooks.NewDispatcher(store, cfg).Run(ctx, wake)
+
+	store.mu.Lock()
+	store.deliveries[1] = &fakeDelivery{OutgoingDelivery: delivery(1, srv.URL), status: models.DeliveryPending}
+	store.mu.Unlock()
+	wake <- struct{}{}
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery not sent after wake-up")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := webhooks.NewDispatcher(newFakeStore(), testConfig())
+	assert.Equal(t, time.Second, d.Backoff(1))
+	assert.Equal(t, 2*time.Second, d.Backoff(2))
+	assert.Equal(t, 4*time.Second, d.Backoff(3))
+	assert.Equal(t, 5*time.Second, d.Backoff(4))
+	assert.Equal(t, 5*time.Second, d.Backoff(40))
+}
@@ -0,0 +1,31 @@
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikuma0/test-effective-mobile-golang/internal/webhooks"
+)
+
+func TestSignature(t *testing.T) {
+	secret, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	body := []byte(`{"id":1}`)
+	now := time.Unix(1700000000, 0)
+	header := webhooks.Sign(secret, now, body)
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+
+	assert.NoError(t, webhooks.Verify(secret, header, body, now.Add(time.Minute), 5*time.Minute))
+	for name, err := range map[string]error{
+		"Body":      webhooks.Verify(secret, header, []byte(`{"id":2}`), now, time.Minute),
+		"Secret":    webhooks.Verify(secret+"x", header, body, now, time.Minute),
+		"Replayed":  webhooks.Verify(secret, header, body, now.Add(time.Hour), 5*time.Minute),
+		"Malformed": webhooks.Verify(secret, "v1=zz", body, now, time.Minute),
+		"Empty":     webhooks.Verify(secret, "", body, now, time.Minute),
+	} {
+		assert.ErrorIs(t, err, webhooks.ErrInvalidSignature, name)
+	}
+}